@@ -2,28 +2,27 @@ package main
 
 import (
 	"context"
+	"errors"
+	"net/http"
 	"os"
-	"time" // Necesario para el tipo de ejemplo ProjectFromAPI y la demo de TTL
+	"os/signal"
+	"syscall"
 
 	// Paquetes del proyecto Windraw
-	// "github.com/mvialf/windraw/internal/app/window-api/models" // Eliminado ya que no se usa directamente en main
+	"github.com/mvialf/windraw/internal/app/window-api/handlers"
+	"github.com/mvialf/windraw/internal/app/window-api/models"
+	"github.com/mvialf/windraw/internal/app/window-api/reports"
 	"github.com/mvialf/windraw/internal/app/window-api/repositories"
-	// "github.com/mvialf/windraw/internal/app/window-api/services" // Descomentar cuando tengas servicios
+	"github.com/mvialf/windraw/internal/app/window-api/services"
 	"github.com/mvialf/windraw/internal/pkg/apiclient"
 	"github.com/mvialf/windraw/internal/pkg/config"
+	"github.com/mvialf/windraw/internal/pkg/constants"
 
 	// Paquetes de terceros
 	"github.com/joho/godotenv"
 	"github.com/sirupsen/logrus" // Asegúrate de ejecutar: go get github.com/sirupsen/logrus
 )
 
-// ProjectFromAPI fue una estructura de ejemplo de tu main.go original.
-type ProjectFromAPI struct {
-	ID        int64     `json:"id"`
-	Name      string    `json:"name"`
-	CreatedAt time.Time `json:"created_at"`
-}
-
 func main() {
 	// 1. Configurar Logger (logrus)
 	logger := logrus.New()
@@ -61,95 +60,165 @@ func main() {
 	// 5. Crear Repositorio de Catálogo de Perfiles (con caché)
 	// Asegúrate que la función NewSupabaseProfileCatalogRepository exista y esté exportada
 	// en tu paquete repositories.
-	profileRepo := repositories.NewSupabaseProfileCatalogRepository(supaClient, logger)
-	logger.Info("Repositorio de Catálogo de Perfiles (con caché) creado.")
-
-	ctx := context.Background()
-	testProfileCatalog(ctx, profileRepo, logger)
-	// testDirectProjectQuery(ctx, supaClient, logger) // Descomenta si quieres correr esta prueba
-
-	logger.Info("Fase de inicialización y pruebas completada.")
-	logger.Info("La aplicación está lista para la lógica del servidor HTTP (pendiente de implementación).")
-
-	// ... (resto del código para el servidor HTTP futuro) ...
-}
-
-// testProfileCatalog encapsula la lógica de prueba para el catálogo de perfiles.
-func testProfileCatalog(ctx context.Context, profileRepo repositories.ProfileCatalogRepository, logger *logrus.Logger) {
-	logger.Info("--- Iniciando prueba del Catálogo de Perfiles ---")
-
-	logger.Info("Llamada 1: profileRepo.GetAllProfiles()")
-	profiles, err := profileRepo.GetAllProfiles(ctx)
-	if err != nil {
-		logger.Errorf("Error en profileRepo.GetAllProfiles() (Llamada 1): %v", err)
-	} else {
-		logger.Infof("Perfiles obtenidos (Llamada 1 - Esperado MISS): %d perfiles.", len(profiles))
-		if len(profiles) > 0 {
-			// Asumiendo que tu models.Profile tiene un campo SKU.
-			// Si no, esta parte de la prueba necesitará ajustarse.
-			skuToTest := profiles[0].SKU
-			logger.Infof("Llamada 2: profileRepo.GetProfileBySKU(%s)", skuToTest)
-			profile, errSku := profileRepo.GetProfileBySKU(ctx, skuToTest)
-			if errSku != nil {
-				logger.Errorf("Error en profileRepo.GetProfileBySKU(%s) (Llamada 2): %v", skuToTest, errSku)
-			} else if profile != nil {
-				logger.Infof("Perfil SKU '%s' obtenido (Llamada 2 - Esperado MISS): %s", skuToTest, profile.Description)
-			} else {
-				logger.Warnf("Perfil SKU '%s' no encontrado (Llamada 2).", skuToTest)
-			}
-
-			logger.Infof("Llamada 3: profileRepo.GetProfileBySKU(%s) - Esperando HIT", skuToTest)
-			profile, errSku = profileRepo.GetProfileBySKU(ctx, skuToTest)
-			if errSku != nil {
-				logger.Errorf("Error en profileRepo.GetProfileBySKU(%s) (Llamada 3): %v", skuToTest, errSku)
-			} else if profile != nil {
-				logger.Infof("Perfil SKU '%s' obtenido (Llamada 3 - Esperado HIT): %s", skuToTest, profile.Description)
-			} else {
-				logger.Warnf("Perfil SKU '%s' no encontrado (Llamada 3).", skuToTest)
-			}
-		} else {
-			logger.Info("No hay perfiles en el catálogo para probar GetProfileBySKU.")
-		}
+	profileDimensions := models.ProfileDimensionMap{
+		SashOverlap: cfg.Catalog.SashOverlapColumn,
+		GlassRebate: cfg.Catalog.GlassRebateColumn,
+		Interlock:   cfg.Catalog.InterlockColumn,
+		Coupling:    cfg.Catalog.CouplingColumn,
+	}
+	if err := profileDimensions.Validate(); err != nil {
+		logger.Fatalf("Error fatal en las columnas de medidas de perfiles: %v", err)
+	}
+	profileRepo := repositories.NewSupabaseProfileCatalogRepository(supaClient, profileDimensions, logger)
+	logger.Infof("Repositorio de Catálogo de Perfiles (con caché) creado. Medidas de cálculo: %+v", profileDimensions)
+
+	// 6. Crear Repositorio de Proyectos según el almacenamiento configurado
+	var projectRepo repositories.ProjectRepository
+	switch cfg.Storage.ProjectStorage {
+	case config.ProjectStorageFile:
+		projectRepo = repositories.NewFileProjectRepository(cfg.Storage.ProjectsDir, logger)
+		logger.Infof("Repositorio de Proyectos (archivos en %s) creado.", cfg.Storage.ProjectsDir)
+	default:
+		projectRepo = repositories.NewSupabaseProjectRepository(supaClient, logger)
+		logger.Info("Repositorio de Proyectos (Supabase) creado.")
 	}
 
-	logger.Info("Llamada 4: profileRepo.GetAllProfiles() - Esperando HIT")
-	_, err = profileRepo.GetAllProfiles(ctx) // Re-asignar a 'profiles' si necesitas el valor
-	if err != nil {
-		logger.Errorf("Error en profileRepo.GetAllProfiles() (Llamada 4): %v", err)
+	// 7. Crear Repositorio de Sistemas de Perfiles y su servicio de selección
+	systemRepo := repositories.NewSupabaseProfileSystemRepository(supaClient, profileDimensions, logger)
+	systemResolver := services.NewProfileSystemResolver(systemRepo, logger)
+	logger.Info("Servicio de selección de Sistemas de Perfiles creado.")
+
+	// 8. Crear Repositorios de colores y stock_items y el servicio que los resuelve
+	colorRepo := repositories.NewSupabaseColorRepository(supaClient, logger)
+	stockItemRepo := repositories.NewSupabaseStockItemRepository(supaClient, logger)
+	stockService := services.NewStockItemService(colorRepo, stockItemRepo, logger)
+	logger.Info("Servicio de resolución de colores e ítems de stock creado.")
+
+	// 9. Crear Repositorio de refuerzos y el servicio que aplica la política configurada
+	reinforcementRepo := repositories.NewSupabaseReinforcementRepository(supaClient, logger)
+	reinforcementService := services.NewReinforcementService(reinforcementRepo,
+		models.ReinforcementPolicy{MinLengthByMaterial: cfg.Reinforcement.MinLengthByMaterial}, logger)
+	logger.Infof("Servicio de refuerzos creado. Reglas por material: %v", cfg.Reinforcement.MinLengthByMaterial)
+
+	// 10. Crear el servicio de despiece (motor de corte + refuerzos)
+	cutService := services.NewCutService(profileRepo, reinforcementService, constants.WELD_MARGIN_MM, logger)
+	logger.Info("Servicio de despiece creado.")
+
+	// 11. Crear el generador de hojas a partir del marco y el sistema de perfiles
+	windGenerator := services.NewWindGeneratorService(systemResolver, logger)
+	logger.Info("Generador de hojas creado.")
+
+	// 12. Crear el servicio de vidrios y junquillos
+	glassService := services.NewGlassService(systemResolver, profileRepo, logger)
+	logger.Info("Servicio de vidrios creado.")
+
+	// 13. Crear el optimizador de corte de barras
+	optimizer := services.NewOptimizerService(stockItemRepo, profileRepo, models.OptimizerSettings{
+		Mode:       constants.OPTIMIZER_MODE_FIRST_FIT,
+		KerfMM:     cfg.Optimizer.KerfMM,
+		TrimMM:     cfg.Optimizer.TrimMM,
+		TimeBudget: cfg.Optimizer.SearchBudget,
+	}, cfg.Optimizer.DefaultBarLengthMM, logger)
+	logger.Info("Optimizador de corte creado.")
+
+	// 14. Crear el inventario de retazos según el almacenamiento configurado
+	var remnantRepo repositories.RemnantRepository
+	switch cfg.Storage.RemnantStorage {
+	case config.ProjectStorageFile:
+		remnantRepo = repositories.NewFileRemnantRepository(cfg.Storage.RemnantsFile, logger)
+		logger.Infof("Repositorio de Retazos (archivo %s) creado.", cfg.Storage.RemnantsFile)
+	default:
+		remnantRepo = repositories.NewSupabaseRemnantRepository(supaClient, logger)
+		logger.Info("Repositorio de Retazos (Supabase) creado.")
+	}
+	remnantService := services.NewRemnantService(remnantRepo, cfg.Remnants.MinLengthMM, logger)
+	logger.Infof("Servicio de retazos creado. Largo mínimo: %d mm", cfg.Remnants.MinLengthMM)
+
+	// 15. Crear el servicio de presupuestos
+	pricingService := services.NewPricingService(stockItemRepo, optimizer, models.PriceList{
+		ProfilePricing:   cfg.Pricing.ProfilePricing,
+		GlassPerM2:       cfg.Pricing.GlassPerM2,
+		HardwarePerWind:  cfg.Pricing.HardwarePerWind,
+		LabourPerElement: cfg.Pricing.LabourPerElement,
+		LabourPerM2:      cfg.Pricing.LabourPerM2,
+	}, logger)
+	logger.Infof("Servicio de presupuestos creado. Perfiles por %s.", cfg.Pricing.ProfilePricing)
+
+	// 16. Crear el generador de listas de materiales
+	bomService := services.NewBOMService(profileRepo, optimizer, logger)
+	logger.Info("Generador de listas de materiales creado.")
+
+	// 17. Crear el exportador de archivos de sierra con los dialectos incluidos y los del archivo
+	sawDialects := reports.BuiltinSawDialects()
+	if _, err := os.Stat(cfg.Export.SawDialectsFile); err == nil {
+		fileDialects, err := reports.LoadSawDialects(cfg.Export.SawDialectsFile)
+		if err != nil {
+			logger.Fatalf("Error fatal al cargar los dialectos de sierra: %v", err)
+		}
+		sawDialects = append(sawDialects, fileDialects...)
 	} else {
-		logger.Infof("Perfiles obtenidos (Llamada 4 - Esperado HIT): %d perfiles.", len(profiles)) // len(profiles) usará el valor de la Llamada 1
+		logger.Infof("Sin archivo de dialectos de sierra en %s; se usan solo los incluidos.", cfg.Export.SawDialectsFile)
+	}
+	sawExporter := services.NewSawExportService(optimizer, sawDialects, logger)
+	logger.Infof("Exportador de sierra creado con %d dialectos.", len(sawExporter.Dialects()))
+
+	// 18. Crear el servicio de dibujos de elevación
+	drawingService := services.NewDrawingService(profileRepo, logger)
+	logger.Info("Servicio de dibujos creado.")
+
+	// --- Servidor HTTP ---
+	// 19. Crear handlers y servidor
+	handler := handlers.NewHandler(handlers.Dependencies{
+		ProfileRepo:    profileRepo,
+		ProjectRepo:    projectRepo,
+		SystemResolver: systemResolver,
+		StockService:   stockService,
+		Reinforcement:  reinforcementService,
+		CutService:     cutService,
+		WindGenerator:  windGenerator,
+		GlassService:   glassService,
+		Optimizer:      optimizer,
+		Remnants:       remnantService,
+		Pricing:        pricingService,
+		BOM:            bomService,
+		SawExporter:    sawExporter,
+		Drawings:       drawingService,
+	}, logger)
+	server := &http.Server{
+		Addr:         cfg.Server.Addr,
+		Handler:      handler.Routes(),
+		ReadTimeout:  cfg.Server.ReadTimeout,
+		WriteTimeout: cfg.Server.WriteTimeout,
 	}
-	logger.Info("--- Prueba del Catálogo de Perfiles finalizada ---")
-}
 
-// testDirectProjectQuery (sin cambios, asumiendo que la adaptarás o eliminarás)
-func testDirectProjectQuery(ctx context.Context, supaClient *apiclient.SupabaseClient, logger *logrus.Logger) {
-	logger.Info("--- Iniciando prueba de consulta directa a 'projects' (legado) ---")
-	path := "/rest/v1/projects"
-	queryParams := "select=id,name,created_at&order=created_at.desc&limit=2"
-	var projects []ProjectFromAPI
+	// 20. Arrancar el servidor y esperar señal de término (SIGINT/SIGTERM)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-	err := supaClient.QueryData(path, queryParams, &projects)
-	if err != nil {
-		logger.Errorf("Error al consultar datos de Supabase ('projects'): %v", err)
-	} else {
-		if len(projects) > 0 {
-			logger.Infof("Consulta directa a 'projects' exitosa. %d proyectos encontrados:", len(projects))
-			for _, p := range projects {
-				logger.Infof("  ID: %d, Name: %s, CreatedAt: %s", p.ID, p.Name, p.CreatedAt.Format(time.RFC3339))
-			}
-		} else {
-			logger.Info("Consulta directa a 'projects' exitosa, pero no se encontraron proyectos.")
+	serverErr := make(chan error, 1)
+	go func() {
+		logger.Infof("Servidor HTTP escuchando en %s", cfg.Server.Addr)
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			serverErr <- err
+		}
+		close(serverErr)
+	}()
+
+	select {
+	case err := <-serverErr:
+		if err != nil {
+			logger.Fatalf("Error fatal en el servidor HTTP: %v", err)
 		}
+	case <-ctx.Done():
+		logger.Info("Señal de término recibida. Cerrando servidor HTTP...")
 	}
 
-	logger.Info("Intentando consulta directa a 'tabla_inexistente'...")
-	var noData []interface{}
-	err = supaClient.QueryData("/rest/v1/tabla_inexistente", "select=*", &noData)
-	if err != nil {
-		logger.Warnf("Error (esperado) al consultar 'tabla_inexistente': %v", err)
-	} else {
-		logger.Info("Consulta a 'tabla_inexistente' no dio error, lo cual es inesperado si no existe.")
+	// 21. Apagado ordenado: se dejan terminar las peticiones en curso
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		logger.Errorf("Error durante el apagado del servidor HTTP: %v", err)
+		return
 	}
-	logger.Info("--- Prueba de consulta directa a 'projects' (legado) finalizada ---")
+	logger.Info("Servidor HTTP detenido correctamente.")
 }
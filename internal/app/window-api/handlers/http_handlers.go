@@ -0,0 +1,1420 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mvialf/windraw/internal/app/window-api/models"
+	"github.com/mvialf/windraw/internal/app/window-api/reports"
+	"github.com/mvialf/windraw/internal/app/window-api/repositories"
+	"github.com/mvialf/windraw/internal/app/window-api/services"
+	"github.com/mvialf/windraw/internal/pkg/constants"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// maxRequestBodyBytes limita el tamaño de los cuerpos JSON aceptados por la API.
+	maxRequestBodyBytes = 1 << 20 // 1 MiB
+)
+
+var errElementNotFound = errors.New("elemento no encontrado")
+
+// Listas de opciones válidas usadas por los constructores de modelos.
+var (
+	validMaterials  = []string{constants.MATERIAL_PVC, constants.MATERIAL_ALUMINIO, constants.MATERIAL_MADERA, constants.MATERIAL_ACERO}
+	validTypes      = []string{constants.TYPE_SLIDING, constants.TYPE_CASEMENT}
+	validStructures = []string{constants.STRUCTURE_VENTANA, constants.STRUCTURE_PUERTA}
+	framePositions  = []string{constants.POSITION_LEFT, constants.POSITION_RIGHT, constants.POSITION_TOP, constants.POSITION_BOTTOM}
+)
+
+// Dependencies agrupa los repositorios y servicios que usan los handlers.
+type Dependencies struct {
+	ProfileRepo    repositories.ProfileCatalogRepository
+	ProjectRepo    repositories.ProjectRepository
+	SystemResolver *services.ProfileSystemResolver
+	StockService   *services.StockItemService
+	Reinforcement  *services.ReinforcementService
+	CutService     *services.CutService
+	WindGenerator  *services.WindGeneratorService
+	GlassService   *services.GlassService
+	Optimizer      *services.OptimizerService
+	Remnants       *services.RemnantService
+	Pricing        *services.PricingService
+	BOM            *services.BOMService
+	SawExporter    *services.SawExportService
+	Drawings       *services.DrawingService
+}
+
+// Handler agrupa las dependencias necesarias para atender las peticiones HTTP de la API.
+type Handler struct {
+	profileRepo    repositories.ProfileCatalogRepository
+	projectRepo    repositories.ProjectRepository
+	systemResolver *services.ProfileSystemResolver
+	stockService   *services.StockItemService
+	reinforcement  *services.ReinforcementService
+	cutService     *services.CutService
+	windGenerator  *services.WindGeneratorService
+	glassService   *services.GlassService
+	optimizer      *services.OptimizerService
+	remnants       *services.RemnantService
+	pricing        *services.PricingService
+	bom            *services.BOMService
+	sawExporter    *services.SawExportService
+	drawings       *services.DrawingService
+	logger         *logrus.Entry
+}
+
+// NewHandler crea un Handler con sus dependencias.
+func NewHandler(deps Dependencies, logger *logrus.Logger) *Handler {
+	return &Handler{
+		profileRepo:    deps.ProfileRepo,
+		projectRepo:    deps.ProjectRepo,
+		systemResolver: deps.SystemResolver,
+		stockService:   deps.StockService,
+		reinforcement:  deps.Reinforcement,
+		cutService:     deps.CutService,
+		windGenerator:  deps.WindGenerator,
+		glassService:   deps.GlassService,
+		optimizer:      deps.Optimizer,
+		remnants:       deps.Remnants,
+		pricing:        deps.Pricing,
+		bom:            deps.BOM,
+		sawExporter:    deps.SawExporter,
+		drawings:       deps.Drawings,
+		logger:         logger.WithField("component", "http"),
+	}
+}
+
+// Routes registra todas las rutas de la API y devuelve el http.Handler raíz,
+// ya envuelto con los middlewares de logging y recuperación de pánicos.
+func (h *Handler) Routes() http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("GET /health", h.health)
+
+	// Catálogo
+	mux.HandleFunc("GET /api/v1/catalog/profiles", h.listProfiles)
+	mux.HandleFunc("GET /api/v1/catalog/profiles/{sku}", h.getProfile)
+	mux.HandleFunc("GET /api/v1/catalog/systems/resolve", h.resolveSystem)
+	mux.HandleFunc("GET /api/v1/catalog/systems/{systemID}/colors", h.listSystemColors)
+	mux.HandleFunc("GET /api/v1/catalog/stock/resolve", h.resolveStock)
+
+	// Proyectos
+	mux.HandleFunc("GET /api/v1/projects", h.listProjects)
+	mux.HandleFunc("POST /api/v1/projects", h.createProject)
+	mux.HandleFunc("GET /api/v1/projects/{projectID}", h.getProject)
+	mux.HandleFunc("PUT /api/v1/projects/{projectID}", h.updateProject)
+	mux.HandleFunc("DELETE /api/v1/projects/{projectID}", h.deleteProject)
+	mux.HandleFunc("POST /api/v1/projects/{projectID}/cutting-plan", h.optimizeCuts)
+	mux.HandleFunc("POST /api/v1/projects/{projectID}/cutting-plan/record", h.recordCuttingPlan)
+	mux.HandleFunc("POST /api/v1/projects/{projectID}/quote", h.quoteProject)
+	mux.HandleFunc("GET /api/v1/projects/{projectID}/bom", h.getBillOfMaterials)
+	mux.HandleFunc("GET /api/v1/projects/{projectID}/cutting-list", h.getCuttingList)
+	mux.HandleFunc("POST /api/v1/projects/{projectID}/saw-job", h.exportSawJob)
+	mux.HandleFunc("GET /api/v1/projects/{projectID}/labels", h.getPieceLabels)
+
+	// Trazabilidad de piezas
+	mux.HandleFunc("GET /api/v1/labels/resolve", h.resolvePieceLabel)
+
+	// Exportación a máquinas
+	mux.HandleFunc("GET /api/v1/saw-dialects", h.listSawDialects)
+
+	// Inventario de retazos
+	mux.HandleFunc("GET /api/v1/remnants", h.listRemnants)
+	mux.HandleFunc("POST /api/v1/remnants", h.createRemnant)
+	mux.HandleFunc("DELETE /api/v1/remnants/{remnantID}", h.deleteRemnant)
+
+	// Componentes de un proyecto
+	mux.HandleFunc("GET /api/v1/projects/{projectID}/components/{componentID}/drawing", h.getComponentDrawing)
+
+	// Elementos de un proyecto
+	mux.HandleFunc("GET /api/v1/projects/{projectID}/elements", h.listElements)
+	mux.HandleFunc("POST /api/v1/projects/{projectID}/elements", h.createElement)
+	mux.HandleFunc("GET /api/v1/projects/{projectID}/elements/{elementID}", h.getElement)
+	mux.HandleFunc("DELETE /api/v1/projects/{projectID}/elements/{elementID}", h.deleteElement)
+	mux.HandleFunc("GET /api/v1/projects/{projectID}/elements/{elementID}/outline", h.getElementOutline)
+	mux.HandleFunc("GET /api/v1/projects/{projectID}/elements/{elementID}/drawing", h.getElementDrawing)
+	mux.HandleFunc("POST /api/v1/projects/{projectID}/elements/{elementID}/reinforcement", h.applyReinforcement)
+	mux.HandleFunc("POST /api/v1/projects/{projectID}/elements/{elementID}/calculate", h.calculateElement)
+	mux.HandleFunc("POST /api/v1/projects/{projectID}/elements/{elementID}/materials", h.assignElementMaterials)
+	mux.HandleFunc("POST /api/v1/projects/{projectID}/elements/{elementID}/winds/sliding", h.generateSlidingWinds)
+	mux.HandleFunc("POST /api/v1/projects/{projectID}/elements/{elementID}/winds/casement", h.generateCasementWinds)
+	mux.HandleFunc("PUT /api/v1/projects/{projectID}/elements/{elementID}/layout", h.setElementLayout)
+	mux.HandleFunc("POST /api/v1/projects/{projectID}/elements/{elementID}/glass", h.calculateGlass)
+
+	return h.recoverMiddleware(h.loggingMiddleware(mux))
+}
+
+//==============================================================================
+// --- Middlewares ---
+//==============================================================================
+
+// statusRecorder captura el código de estado escrito por un handler.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (h *Handler) loggingMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+		h.logger.WithFields(logrus.Fields{
+			"method":   r.Method,
+			"path":     r.URL.Path,
+			"status":   rec.status,
+			"duration": time.Since(start).String(),
+		}).Info("Petición atendida")
+	})
+}
+
+func (h *Handler) recoverMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			if rec := recover(); rec != nil {
+				h.logger.WithField("path", r.URL.Path).Errorf("Pánico recuperado: %v", rec)
+				writeError(w, http.StatusInternalServerError, errors.New("error interno del servidor"))
+			}
+		}()
+		next.ServeHTTP(w, r)
+	})
+}
+
+//==============================================================================
+// --- Helpers de respuesta ---
+//==============================================================================
+
+// errorResponse es el cuerpo JSON devuelto ante cualquier error.
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+func writeJSON(w http.ResponseWriter, status int, payload interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	if payload == nil {
+		return
+	}
+	_ = json.NewEncoder(w).Encode(payload)
+}
+
+// writeAttachment escribe un archivo descargable con el nombre indicado.
+func writeAttachment(w http.ResponseWriter, contentType, filename string, data []byte) {
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": filename}))
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(data)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, errorResponse{Error: err.Error()})
+}
+
+// writeServiceError traduce errores de los servicios de catálogo a códigos HTTP.
+func (h *Handler) writeServiceError(w http.ResponseWriter, err error) {
+	if errors.Is(err, services.ErrMaterialNotFound) || errors.Is(err, services.ErrNoProfileSystem) ||
+		errors.Is(err, services.ErrColorNotAvailable) || errors.Is(err, services.ErrStockItemNotFound) ||
+		errors.Is(err, services.ErrProfileNotFound) || errors.Is(err, repositories.ErrRemnantNotFound) ||
+		errors.Is(err, services.ErrSawDialectNotFound) {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+	if errors.Is(err, models.ErrInvalidCut) || errors.Is(err, services.ErrInvalidWindLayout) ||
+		errors.Is(err, models.ErrInvalidGlass) || errors.Is(err, services.ErrInvalidOptimization) ||
+		errors.Is(err, services.ErrInvalidRemnant) || errors.Is(err, services.ErrInvalidPricing) ||
+		errors.Is(err, services.ErrInvalidDrawing) {
+		writeError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+	if errors.Is(err, repositories.ErrPlanAlreadyRecorded) {
+		writeError(w, http.StatusConflict, err)
+		return
+	}
+	h.logger.WithError(err).Error("Error de servicio")
+	writeError(w, http.StatusBadGateway, err)
+}
+
+// writeRepositoryError traduce errores de repositorio a códigos HTTP.
+func (h *Handler) writeRepositoryError(w http.ResponseWriter, err error) {
+	if errors.Is(err, repositories.ErrProjectNotFound) || errors.Is(err, repositories.ErrRemnantNotFound) {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+	h.logger.WithError(err).Error("Error de repositorio")
+	writeError(w, http.StatusInternalServerError, err)
+}
+
+// decodeJSON decodifica el cuerpo de la petición en target, rechazando campos desconocidos.
+func decodeJSON(w http.ResponseWriter, r *http.Request, target interface{}) error {
+	r.Body = http.MaxBytesReader(w, r.Body, maxRequestBodyBytes)
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(target); err != nil {
+		return fmt.Errorf("cuerpo JSON inválido: %w", err)
+	}
+	return nil
+}
+
+//==============================================================================
+// --- Salud y Catálogo ---
+//==============================================================================
+
+func (h *Handler) health(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+func (h *Handler) listProfiles(w http.ResponseWriter, r *http.Request) {
+	profiles, err := h.profileRepo.GetAllProfiles(r.Context())
+	if err != nil {
+		h.logger.WithError(err).Error("Error listando perfiles")
+		writeError(w, http.StatusBadGateway, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, profiles)
+}
+
+func (h *Handler) getProfile(w http.ResponseWriter, r *http.Request) {
+	sku := r.PathValue("sku")
+	profile, err := h.profileRepo.GetProfileBySKU(r.Context(), sku)
+	if err != nil {
+		h.logger.WithError(err).WithField("sku", sku).Error("Error obteniendo perfil")
+		writeError(w, http.StatusBadGateway, err)
+		return
+	}
+	if profile == nil {
+		writeError(w, http.StatusNotFound, fmt.Errorf("perfil con SKU '%s' no encontrado", sku))
+		return
+	}
+	writeJSON(w, http.StatusOK, profile)
+}
+
+// resolveSystem elige el sistema de perfiles y sus perfiles por tipo.
+// Parámetros: type (constants.TYPE_*) y material (constants.MATERIAL_*).
+func (h *Handler) resolveSystem(w http.ResponseWriter, r *http.Request) {
+	elementType := r.URL.Query().Get("type")
+	material := r.URL.Query().Get("material")
+	if !models.IsValidOption(elementType, validTypes) {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("parámetro 'type' inválido: '%s'. Válidos: %v", elementType, validTypes))
+		return
+	}
+	if material == "" {
+		writeError(w, http.StatusBadRequest, errors.New("el parámetro 'material' es obligatorio"))
+		return
+	}
+
+	resolved, err := h.systemResolver.Resolve(r.Context(), elementType, material)
+	if err != nil {
+		h.writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, resolved)
+}
+
+// listSystemColors devuelve los colores disponibles para un sistema (system_available_colors).
+func (h *Handler) listSystemColors(w http.ResponseWriter, r *http.Request) {
+	systemID, err := strconv.ParseInt(r.PathValue("systemID"), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("systemID inválido: '%s'", r.PathValue("systemID")))
+		return
+	}
+	colors, err := h.stockService.GetSystemColors(r.Context(), systemID)
+	if err != nil {
+		h.writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, colors)
+}
+
+// resolveStock resuelve el sistema y devuelve el ítem de stock de cada tipo de perfil en el color pedido.
+// Parámetros: type (constants.TYPE_*), material (constants.MATERIAL_*) y color_id.
+func (h *Handler) resolveStock(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	elementType := query.Get("type")
+	material := query.Get("material")
+	if !models.IsValidOption(elementType, validTypes) {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("parámetro 'type' inválido: '%s'. Válidos: %v", elementType, validTypes))
+		return
+	}
+	if material == "" {
+		writeError(w, http.StatusBadRequest, errors.New("el parámetro 'material' es obligatorio"))
+		return
+	}
+	colorID, err := strconv.ParseInt(query.Get("color_id"), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("parámetro 'color_id' inválido: '%s'", query.Get("color_id")))
+		return
+	}
+
+	resolved, err := h.systemResolver.Resolve(r.Context(), elementType, material)
+	if err != nil {
+		h.writeServiceError(w, err)
+		return
+	}
+	materials, err := h.stockService.Resolve(r.Context(), resolved, colorID)
+	if err != nil {
+		h.writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, materials)
+}
+
+//==============================================================================
+// --- Proyectos ---
+//==============================================================================
+
+// projectRequest es el cuerpo aceptado para crear o actualizar un proyecto.
+type projectRequest struct {
+	Name    string               `json:"name"`
+	Contact models.Contact       `json:"contact"`
+	Costs   []models.ProjectCost `json:"costs,omitempty"`
+	IvaRate *float64             `json:"iva_rate,omitempty"` // Si se omite se usa constants.IVA_RATE
+}
+
+func (h *Handler) listProjects(w http.ResponseWriter, r *http.Request) {
+	projects, err := h.projectRepo.List(r.Context())
+	if err != nil {
+		h.writeRepositoryError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, projects)
+}
+
+func (h *Handler) createProject(w http.ResponseWriter, r *http.Request) {
+	var req projectRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	ivaRate := constants.IVA_RATE
+	if req.IvaRate != nil {
+		ivaRate = *req.IvaRate
+	}
+	project, err := models.NewProject(req.Name, req.Contact, req.Costs, nil, ivaRate)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if err := h.projectRepo.Create(r.Context(), project); err != nil {
+		h.writeRepositoryError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, project)
+}
+
+func (h *Handler) getProject(w http.ResponseWriter, r *http.Request) {
+	project, err := h.projectRepo.Get(r.Context(), r.PathValue("projectID"))
+	if err != nil {
+		h.writeRepositoryError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, project)
+}
+
+func (h *Handler) updateProject(w http.ResponseWriter, r *http.Request) {
+	var req projectRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	// Se valida con el mismo constructor que en la creación, descartando el ID generado.
+	ivaRate := constants.IVA_RATE
+	if req.IvaRate != nil {
+		ivaRate = *req.IvaRate
+	}
+	validated, err := models.NewProject(req.Name, req.Contact, req.Costs, nil, ivaRate)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	project, err := h.projectRepo.Get(r.Context(), r.PathValue("projectID"))
+	if err != nil {
+		h.writeRepositoryError(w, err)
+		return
+	}
+	project.Name = validated.Name
+	project.Contact = validated.Contact
+	project.Costs = validated.Costs
+	project.IvaRate = validated.IvaRate
+	if err := h.projectRepo.Update(r.Context(), project); err != nil {
+		h.writeRepositoryError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, project)
+}
+
+func (h *Handler) deleteProject(w http.ResponseWriter, r *http.Request) {
+	if err := h.projectRepo.Delete(r.Context(), r.PathValue("projectID")); err != nil {
+		h.writeRepositoryError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// cuttingPlanRequest es el cuerpo aceptado para optimizar el corte de barras de un proyecto.
+// Los campos omitidos toman los valores por defecto de la configuración.
+type cuttingPlanRequest struct {
+	Mode         string          `json:"mode,omitempty"` // constants.OPTIMIZER_MODE_*
+	KerfMM       *float64        `json:"kerf_mm,omitempty"`
+	TrimMM       *float64        `json:"trim_mm,omitempty"`
+	TimeBudgetMS int             `json:"time_budget_ms,omitempty"` // Solo en modo de búsqueda
+	Offcuts      []models.Offcut `json:"offcuts,omitempty"`        // Retazos a usar antes de abrir barras nuevas
+	UseRemnants  bool            `json:"use_remnants,omitempty"`   // Agrega los retazos del inventario del mismo material
+}
+
+// optimizeCuts devuelve el plan de corte barra por barra de las piezas calculadas del proyecto.
+func (h *Handler) optimizeCuts(w http.ResponseWriter, r *http.Request) {
+	var req cuttingPlanRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if req.TimeBudgetMS < 0 {
+		writeError(w, http.StatusBadRequest, errors.New("time_budget_ms no puede ser negativo"))
+		return
+	}
+	project, err := h.projectRepo.Get(r.Context(), r.PathValue("projectID"))
+	if err != nil {
+		h.writeRepositoryError(w, err)
+		return
+	}
+	offcuts := req.Offcuts
+	if req.UseRemnants {
+		stored, err := h.remnants.OffcutsFor(r.Context(), project.Pieces())
+		if err != nil {
+			h.writeServiceError(w, err)
+			return
+		}
+		offcuts = append(offcuts, stored...)
+	}
+	plan, err := h.optimizer.Optimize(r.Context(), project, services.OptimizeOptions{
+		Mode:       req.Mode,
+		KerfMM:     req.KerfMM,
+		TrimMM:     req.TrimMM,
+		TimeBudget: time.Duration(req.TimeBudgetMS) * time.Millisecond,
+		Offcuts:    offcuts,
+	})
+	if err != nil {
+		h.writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, plan)
+}
+
+// recordCuttingPlanRequest es el cuerpo aceptado para registrar un plan de corte ya ejecutado.
+type recordCuttingPlanRequest struct {
+	Plan     models.CuttingPlan `json:"plan"`               // Plan devuelto por POST .../cutting-plan
+	Location string             `json:"location,omitempty"` // Ubicación de los retazos nuevos
+}
+
+// recordCuttingPlan actualiza el inventario de retazos con los cortes de un plan ejecutado.
+func (h *Handler) recordCuttingPlan(w http.ResponseWriter, r *http.Request) {
+	var req recordCuttingPlanRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	project, err := h.projectRepo.Get(r.Context(), r.PathValue("projectID"))
+	if err != nil {
+		h.writeRepositoryError(w, err)
+		return
+	}
+	movements, err := h.remnants.RecordCuts(r.Context(), project.ID, &req.Plan, req.Location)
+	if err != nil {
+		h.writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, movements)
+}
+
+// quoteRequest es el cuerpo aceptado para presupuestar un proyecto.
+// Los precios omitidos toman los valores por defecto de la configuración.
+type quoteRequest struct {
+	ProfilePricing   string             `json:"profile_pricing,omitempty"` // constants.PROFILE_PRICING_*
+	GlassPerM2       map[string]float64 `json:"glass_per_m2,omitempty"`
+	HardwarePerWind  map[string]float64 `json:"hardware_per_wind,omitempty"`
+	LabourPerElement *float64           `json:"labour_per_element,omitempty"`
+	LabourPerM2      *float64           `json:"labour_per_m2,omitempty"`
+	UseRemnants      bool               `json:"use_remnants,omitempty"` // Con valorización por barras: descuenta los retazos del inventario
+	Drawings         *bool              `json:"drawings,omitempty"`     // Agrega la elevación SVG de cada elemento; por defecto true
+}
+
+// quoteProject devuelve el presupuesto del proyecto por componente, módulo y elemento, con la
+// elevación SVG de cada elemento salvo que se pida "drawings": false.
+func (h *Handler) quoteProject(w http.ResponseWriter, r *http.Request) {
+	var req quoteRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	project, err := h.projectRepo.Get(r.Context(), r.PathValue("projectID"))
+	if err != nil {
+		h.writeRepositoryError(w, err)
+		return
+	}
+	opts := services.QuoteOptions{
+		ProfilePricing:   req.ProfilePricing,
+		GlassPerM2:       req.GlassPerM2,
+		HardwarePerWind:  req.HardwarePerWind,
+		LabourPerElement: req.LabourPerElement,
+		LabourPerM2:      req.LabourPerM2,
+	}
+	if req.UseRemnants {
+		if opts.Optimize.Offcuts, err = h.remnants.OffcutsFor(r.Context(), project.Pieces()); err != nil {
+			h.writeServiceError(w, err)
+			return
+		}
+	}
+	quote, err := h.pricing.Quote(r.Context(), project, opts)
+	if err != nil {
+		h.writeServiceError(w, err)
+		return
+	}
+	if req.Drawings == nil || *req.Drawings {
+		if err := h.drawings.AttachToQuote(r.Context(), project, quote); err != nil {
+			h.writeServiceError(w, err)
+			return
+		}
+	}
+	writeJSON(w, http.StatusOK, quote)
+}
+
+// getBillOfMaterials devuelve la lista de materiales del proyecto.
+// Parámetros opcionales: format ("json" por defecto o "csv") y use_remnants ("true" descuenta de
+// las barras a comprar los retazos del inventario).
+func (h *Handler) getBillOfMaterials(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	format := query.Get("format")
+	if format != "" && format != "json" && format != "csv" {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("parámetro 'format' inválido: '%s'. Válidos: json, csv", format))
+		return
+	}
+	useRemnants := false
+	if value := query.Get("use_remnants"); value != "" {
+		var err error
+		if useRemnants, err = strconv.ParseBool(value); err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("parámetro 'use_remnants' inválido: '%s'", value))
+			return
+		}
+	}
+	project, err := h.projectRepo.Get(r.Context(), r.PathValue("projectID"))
+	if err != nil {
+		h.writeRepositoryError(w, err)
+		return
+	}
+	var opts services.OptimizeOptions
+	if useRemnants {
+		if opts.Offcuts, err = h.remnants.OffcutsFor(r.Context(), project.Pieces()); err != nil {
+			h.writeServiceError(w, err)
+			return
+		}
+	}
+	bom, err := h.bom.Generate(r.Context(), project, opts)
+	if err != nil {
+		h.writeServiceError(w, err)
+		return
+	}
+	if format != "csv" {
+		writeJSON(w, http.StatusOK, bom)
+		return
+	}
+	var buf bytes.Buffer
+	if err := reports.WriteBOMCSV(&buf, bom); err != nil {
+		h.logger.WithError(err).Error("Error generando CSV de lista de materiales")
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeAttachment(w, "text/csv; charset=utf-8", project.ID+" - materiales.csv", buf.Bytes())
+}
+
+// getCuttingList devuelve la lista de corte de taller del proyecto.
+// Parámetro opcional: format ("json" por defecto, "csv" o "pdf").
+func (h *Handler) getCuttingList(w http.ResponseWriter, r *http.Request) {
+	format := r.URL.Query().Get("format")
+	if format != "" && format != "json" && format != "csv" && format != "pdf" {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("parámetro 'format' inválido: '%s'. Válidos: json, csv, pdf", format))
+		return
+	}
+	project, err := h.projectRepo.Get(r.Context(), r.PathValue("projectID"))
+	if err != nil {
+		h.writeRepositoryError(w, err)
+		return
+	}
+	list := project.CuttingList()
+
+	var buf bytes.Buffer
+	switch format {
+	case "csv":
+		err = reports.WriteCuttingListCSV(&buf, list)
+	case "pdf":
+		err = reports.WriteCuttingListPDF(&buf, list)
+	default:
+		writeJSON(w, http.StatusOK, list)
+		return
+	}
+	if err != nil {
+		h.logger.WithError(err).Errorf("Error generando lista de corte en %s", format)
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	contentType := "text/csv; charset=utf-8"
+	if format == "pdf" {
+		contentType = "application/pdf"
+	}
+	writeAttachment(w, contentType, project.ID+" - lista de corte."+format, buf.Bytes())
+}
+
+// getPieceLabels devuelve las etiquetas de las piezas del proyecto como JSON, hoja PDF o ZPL.
+// Parámetros: format (json, pdf o zpl), symbology (qr o code128), element (referencia
+// "C1-M1-E1" para reimprimir un solo elemento) y reinforcement=true para etiquetar los refuerzos.
+// En ZPL, width_mm, height_mm y dpmm fijan la etiqueta y la resolución de la impresora.
+func (h *Handler) getPieceLabels(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	format := query.Get("format")
+	if format != "" && format != "json" && format != "pdf" && format != "zpl" {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("parámetro 'format' inválido: '%s'. Válidos: json, pdf, zpl", format))
+		return
+	}
+	symbology := query.Get("symbology")
+	if symbology == "" {
+		symbology = reports.LabelSymbologyQR
+	}
+	if symbology != reports.LabelSymbologyQR && symbology != reports.LabelSymbologyCode128 {
+		writeError(w, http.StatusBadRequest, reports.ErrInvalidSymbology)
+		return
+	}
+	size := reports.DefaultZPLLabel
+	for name, target := range map[string]*float64{"width_mm": &size.WidthMM, "height_mm": &size.HeightMM} {
+		if value := query.Get(name); value != "" {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil || parsed <= 0 {
+				writeError(w, http.StatusBadRequest, fmt.Errorf("parámetro '%s' inválido: '%s'", name, value))
+				return
+			}
+			*target = parsed
+		}
+	}
+	if value := query.Get("dpmm"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed <= 0 {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("parámetro 'dpmm' inválido: '%s'", value))
+			return
+		}
+		size.DotsPerMM = parsed
+	}
+	withReinforcement := false
+	if value := query.Get("reinforcement"); value != "" {
+		var err error
+		if withReinforcement, err = strconv.ParseBool(value); err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("parámetro 'reinforcement' inválido: '%s'", value))
+			return
+		}
+	}
+	project, err := h.projectRepo.Get(r.Context(), r.PathValue("projectID"))
+	if err != nil {
+		h.writeRepositoryError(w, err)
+		return
+	}
+
+	labels := project.Labels(withReinforcement)
+	if element := query.Get("element"); element != "" {
+		filtered := []models.PieceLabel{}
+		for _, label := range labels {
+			if label.Piece.ElementRef == element {
+				filtered = append(filtered, label)
+			}
+		}
+		labels = filtered
+	}
+
+	var buf bytes.Buffer
+	switch format {
+	case "pdf":
+		err = reports.WriteLabelsPDF(&buf, labels, reports.DefaultLabelSheet, symbology)
+	case "zpl":
+		err = reports.WriteLabelsZPL(&buf, labels, size, symbology)
+	default:
+		writeJSON(w, http.StatusOK, labels)
+		return
+	}
+	if err != nil {
+		h.logger.WithError(err).Errorf("Error generando etiquetas en %s", format)
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	contentType := "application/pdf"
+	if format == "zpl" {
+		contentType = "text/plain; charset=utf-8"
+	}
+	writeAttachment(w, contentType, project.ID+" - etiquetas."+format, buf.Bytes())
+}
+
+// resolvePieceLabel devuelve el elemento, la hoja y la posición de la pieza de una etiqueta
+// escaneada, pasada en el parámetro code tal como la lee el lector. La pieza se busca por los
+// IDs de la etiqueta, no por su posición en el proyecto.
+func (h *Handler) resolvePieceLabel(w http.ResponseWriter, r *http.Request) {
+	projectID, elementKey, pieceKey, err := models.ParseLabelPayload(r.URL.Query().Get("code"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	project, err := h.projectRepo.Get(r.Context(), projectID)
+	if err != nil {
+		h.writeRepositoryError(w, err)
+		return
+	}
+	location, err := project.LocatePiece(elementKey, pieceKey)
+	if err != nil {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, location)
+}
+
+// sawJobRequest es el cuerpo aceptado para generar el archivo de trabajo de una sierra.
+// Si se omite plan, se calcula con los mismos parámetros que POST .../cutting-plan.
+type sawJobRequest struct {
+	Dialect string              `json:"dialect"`
+	Plan    *models.CuttingPlan `json:"plan,omitempty"`
+	cuttingPlanRequest
+}
+
+// exportSawJob devuelve el archivo de trabajo del plan de corte del proyecto en el dialecto pedido.
+// Las piezas curvas no van en el archivo: sus códigos se informan en la cabecera X-Bent-Pieces,
+// separados por coma y escapados como segmentos de URL.
+func (h *Handler) exportSawJob(w http.ResponseWriter, r *http.Request) {
+	var req sawJobRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if req.Dialect == "" {
+		writeError(w, http.StatusBadRequest, errors.New("el campo 'dialect' es obligatorio"))
+		return
+	}
+	if req.TimeBudgetMS < 0 {
+		writeError(w, http.StatusBadRequest, errors.New("time_budget_ms no puede ser negativo"))
+		return
+	}
+	project, err := h.projectRepo.Get(r.Context(), r.PathValue("projectID"))
+	if err != nil {
+		h.writeRepositoryError(w, err)
+		return
+	}
+	opts := services.OptimizeOptions{
+		Mode:       req.Mode,
+		KerfMM:     req.KerfMM,
+		TrimMM:     req.TrimMM,
+		TimeBudget: time.Duration(req.TimeBudgetMS) * time.Millisecond,
+		Offcuts:    req.Offcuts,
+	}
+	if req.Plan == nil && req.UseRemnants {
+		stored, err := h.remnants.OffcutsFor(r.Context(), project.Pieces())
+		if err != nil {
+			h.writeServiceError(w, err)
+			return
+		}
+		opts.Offcuts = append(opts.Offcuts, stored...)
+	}
+	job, err := h.sawExporter.Export(r.Context(), project, req.Dialect, req.Plan, opts)
+	if err != nil {
+		h.writeServiceError(w, err)
+		return
+	}
+	if len(job.Bent) > 0 {
+		codes := make([]string, len(job.Bent))
+		for i, piece := range job.Bent {
+			codes[i] = url.PathEscape(piece.Code)
+		}
+		w.Header().Set("X-Bent-Pieces", strings.Join(codes, ","))
+	}
+	writeAttachment(w, job.Dialect.ContentType(), fmt.Sprintf("%s - %s.%s", project.ID, job.Dialect.Name, job.Dialect.Extension()), job.Data)
+}
+
+func (h *Handler) listSawDialects(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, h.sawExporter.Dialects())
+}
+
+//==============================================================================
+// --- Retazos ---
+//==============================================================================
+
+// remnantRequest es el cuerpo aceptado para ingresar un retazo al inventario.
+type remnantRequest struct {
+	ItemSKU  string `json:"item_sku"`
+	Color    string `json:"color,omitempty"`
+	LengthMM int    `json:"length_mm"`
+	Location string `json:"location,omitempty"`
+}
+
+// listRemnants devuelve los retazos del inventario.
+// Parámetros opcionales: item_sku, color y min_length (mm).
+func (h *Handler) listRemnants(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := models.RemnantFilter{ItemSKU: query.Get("item_sku"), Color: query.Get("color")}
+	if value := query.Get("min_length"); value != "" {
+		minLength, err := strconv.Atoi(value)
+		if err != nil || minLength < 0 {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("parámetro 'min_length' inválido: '%s'", value))
+			return
+		}
+		filter.MinLengthMM = minLength
+	}
+	remnants, err := h.remnants.List(r.Context(), filter)
+	if err != nil {
+		h.writeRepositoryError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, remnants)
+}
+
+func (h *Handler) createRemnant(w http.ResponseWriter, r *http.Request) {
+	var req remnantRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	remnant, err := h.remnants.Add(r.Context(), req.ItemSKU, req.Color, req.LengthMM, req.Location)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidRemnant) {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		h.writeRepositoryError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, remnant)
+}
+
+func (h *Handler) deleteRemnant(w http.ResponseWriter, r *http.Request) {
+	if err := h.remnants.Remove(r.Context(), r.PathValue("remnantID")); err != nil {
+		h.writeRepositoryError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+//==============================================================================
+// --- Elementos ---
+//==============================================================================
+
+// elementRequest es el cuerpo aceptado para crear un elemento dentro de un proyecto.
+// Si no se indica componente o módulo, el elemento se añade al primero existente
+// (creándolo si el proyecto aún no tiene ninguno).
+type elementRequest struct {
+	ComponentID string             `json:"component_id,omitempty"`
+	ModuleID    string             `json:"module_id,omitempty"`
+	Width       int                `json:"width"`
+	Height      int                `json:"height"`
+	Material    string             `json:"material"`
+	Type        string             `json:"type"`
+	Structure   string             `json:"structure"`
+	Geometry    string             `json:"geometry,omitempty"` // Por defecto constants.GEOMETRY_RECTANGULAR
+	Shape       *models.FrameShape `json:"shape,omitempty"`    // Medidas de las geometrías no rectangulares
+	CutType     string             `json:"cut_type,omitempty"` // Por defecto constants.CUT_ANGLE
+}
+
+func (h *Handler) listElements(w http.ResponseWriter, r *http.Request) {
+	project, err := h.projectRepo.Get(r.Context(), r.PathValue("projectID"))
+	if err != nil {
+		h.writeRepositoryError(w, err)
+		return
+	}
+	elements := []models.Element{}
+	for _, component := range project.Components {
+		for _, module := range component.Modules {
+			elements = append(elements, module.Elements...)
+		}
+	}
+	writeJSON(w, http.StatusOK, elements)
+}
+
+func (h *Handler) createElement(w http.ResponseWriter, r *http.Request) {
+	var req elementRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	geometry := req.Geometry
+	if geometry == "" {
+		geometry = constants.GEOMETRY_RECTANGULAR
+	}
+	cutType := req.CutType
+	if cutType == "" {
+		cutType = constants.CUT_ANGLE
+	}
+
+	element, err := models.NewElement(req.Width, req.Height, req.Material, req.Type, req.Structure,
+		constants.GEOMETRY_RECTANGULAR, cutType, framePositions, validMaterials, validTypes, validStructures)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if geometry != constants.GEOMETRY_RECTANGULAR {
+		shape := models.FrameShape{}
+		if req.Shape != nil {
+			shape = *req.Shape
+		}
+		if err := element.SetFrameShape(geometry, shape); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+	}
+
+	project, err := h.projectRepo.Get(r.Context(), r.PathValue("projectID"))
+	if err != nil {
+		h.writeRepositoryError(w, err)
+		return
+	}
+	module, err := findOrCreateModule(project, req.ComponentID, req.ModuleID)
+	if err != nil {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+	module.Elements = append(module.Elements, *element)
+	if err := h.projectRepo.Update(r.Context(), project); err != nil {
+		h.writeRepositoryError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, element)
+}
+
+func (h *Handler) getElement(w http.ResponseWriter, r *http.Request) {
+	project, err := h.projectRepo.Get(r.Context(), r.PathValue("projectID"))
+	if err != nil {
+		h.writeRepositoryError(w, err)
+		return
+	}
+	element, _, _ := findElement(project, r.PathValue("elementID"))
+	if element == nil {
+		writeError(w, http.StatusNotFound, errElementNotFound)
+		return
+	}
+	writeJSON(w, http.StatusOK, element)
+}
+
+// getElementOutline devuelve el contorno exterior del marco del elemento (lados, ángulos, área y perímetro).
+func (h *Handler) getElementOutline(w http.ResponseWriter, r *http.Request) {
+	project, err := h.projectRepo.Get(r.Context(), r.PathValue("projectID"))
+	if err != nil {
+		h.writeRepositoryError(w, err)
+		return
+	}
+	element, _, _ := findElement(project, r.PathValue("elementID"))
+	if element == nil {
+		writeError(w, http.StatusNotFound, errElementNotFound)
+		return
+	}
+	outline, err := element.Frame.Outline()
+	if err != nil {
+		writeError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, outline)
+}
+
+// getElementDrawing devuelve la elevación del elemento. Parámetros opcionales: format ("svg" por
+// defecto o "dxf") y scale, la escala de impresión 1:scale; en DXF el dibujo va en mm a tamaño
+// real y scale solo fija el tamaño de textos y cotas.
+func (h *Handler) getElementDrawing(w http.ResponseWriter, r *http.Request) {
+	format, scale, ok := drawingParams(w, r, "svg", "dxf")
+	if !ok {
+		return
+	}
+	project, err := h.projectRepo.Get(r.Context(), r.PathValue("projectID"))
+	if err != nil {
+		h.writeRepositoryError(w, err)
+		return
+	}
+	element, _, _ := findElement(project, r.PathValue("elementID"))
+	if element == nil {
+		writeError(w, http.StatusNotFound, errElementNotFound)
+		return
+	}
+	title := fmt.Sprintf("%s · %dx%d mm", element.Type, element.Width, element.Height)
+	if format == "dxf" {
+		dxf, err := h.drawings.ElementDXF(r.Context(), element, title, scale)
+		if err != nil {
+			h.writeServiceError(w, err)
+			return
+		}
+		writeAttachment(w, "application/dxf", project.ID+" - "+element.ID+".dxf", dxf)
+		return
+	}
+	svg, err := h.drawings.ElementSVG(r.Context(), element, title, scale)
+	if err != nil {
+		h.writeServiceError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "image/svg+xml")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(svg)
+}
+
+// getComponentDrawing devuelve en DXF las elevaciones de todos los elementos del componente, lado
+// a lado. Parámetros opcionales: format (solo "dxf") y scale, como en getElementDrawing.
+func (h *Handler) getComponentDrawing(w http.ResponseWriter, r *http.Request) {
+	_, scale, ok := drawingParams(w, r, "dxf")
+	if !ok {
+		return
+	}
+	project, err := h.projectRepo.Get(r.Context(), r.PathValue("projectID"))
+	if err != nil {
+		h.writeRepositoryError(w, err)
+		return
+	}
+	componentID := r.PathValue("componentID")
+	for i := range project.Components {
+		component := &project.Components[i]
+		if component.ID != componentID {
+			continue
+		}
+		ref := fmt.Sprintf("C%d", i+1)
+		dxf, err := h.drawings.ComponentDXF(r.Context(), component, ref, project.Name+" · "+ref, scale)
+		if err != nil {
+			h.writeServiceError(w, err)
+			return
+		}
+		writeAttachment(w, "application/dxf", project.ID+" - "+ref+".dxf", dxf)
+		return
+	}
+	writeError(w, http.StatusNotFound, fmt.Errorf("componente '%s' no encontrado en el proyecto", componentID))
+}
+
+// drawingParams lee los parámetros format y scale de un dibujo. El primer formato de formats es el
+// valor por defecto. Si alguno es inválido responde 400 y devuelve ok en false.
+func drawingParams(w http.ResponseWriter, r *http.Request, formats ...string) (format string, scale int, ok bool) {
+	query := r.URL.Query()
+	format = query.Get("format")
+	if format == "" {
+		format = formats[0]
+	}
+	if !slices.Contains(formats, format) {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("parámetro 'format' inválido: '%s'. Válidos: %s", format, strings.Join(formats, ", ")))
+		return "", 0, false
+	}
+	if value := query.Get("scale"); value != "" {
+		var err error
+		if scale, err = strconv.Atoi(value); err != nil || scale <= 0 {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("parámetro 'scale' inválido: '%s'", value))
+			return "", 0, false
+		}
+	}
+	return format, scale, true
+}
+
+func (h *Handler) deleteElement(w http.ResponseWriter, r *http.Request) {
+	project, err := h.projectRepo.Get(r.Context(), r.PathValue("projectID"))
+	if err != nil {
+		h.writeRepositoryError(w, err)
+		return
+	}
+	element, module, index := findElement(project, r.PathValue("elementID"))
+	if element == nil {
+		writeError(w, http.StatusNotFound, errElementNotFound)
+		return
+	}
+	module.Elements = append(module.Elements[:index], module.Elements[index+1:]...)
+	if err := h.projectRepo.Update(r.Context(), project); err != nil {
+		h.writeRepositoryError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// applyReinforcement recalcula el refuerzo de las piezas del elemento y guarda el proyecto.
+func (h *Handler) applyReinforcement(w http.ResponseWriter, r *http.Request) {
+	project, err := h.projectRepo.Get(r.Context(), r.PathValue("projectID"))
+	if err != nil {
+		h.writeRepositoryError(w, err)
+		return
+	}
+	element, _, _ := findElement(project, r.PathValue("elementID"))
+	if element == nil {
+		writeError(w, http.StatusNotFound, errElementNotFound)
+		return
+	}
+	if err := h.reinforcement.Apply(r.Context(), element); err != nil {
+		h.writeServiceError(w, err)
+		return
+	}
+	if err := h.projectRepo.Update(r.Context(), project); err != nil {
+		h.writeRepositoryError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, element)
+}
+
+// calculateElement calcula el despiece del elemento (largos, ángulos y refuerzos) y guarda el proyecto.
+func (h *Handler) calculateElement(w http.ResponseWriter, r *http.Request) {
+	project, err := h.projectRepo.Get(r.Context(), r.PathValue("projectID"))
+	if err != nil {
+		h.writeRepositoryError(w, err)
+		return
+	}
+	element, _, _ := findElement(project, r.PathValue("elementID"))
+	if element == nil {
+		writeError(w, http.StatusNotFound, errElementNotFound)
+		return
+	}
+	if err := h.cutService.CalculateElement(r.Context(), element); err != nil {
+		h.writeServiceError(w, err)
+		return
+	}
+	if err := h.projectRepo.Update(r.Context(), project); err != nil {
+		h.writeRepositoryError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, element)
+}
+
+// materialsRequest es el cuerpo aceptado para asignar los materiales de un elemento.
+type materialsRequest struct {
+	ColorID int64 `json:"color_id"` // Color del catálogo, disponible en el sistema del elemento
+}
+
+// assignElementMaterials resuelve el sistema del elemento y, en el color pedido, el ítem de stock de
+// cada pieza (SKU con sufijo de color, color_id y nombre del color), y guarda el proyecto.
+// Es el paso que permite valorizar, optimizar y listar las piezas por color.
+func (h *Handler) assignElementMaterials(w http.ResponseWriter, r *http.Request) {
+	var req materialsRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if req.ColorID <= 0 {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("color_id inválido: %d", req.ColorID))
+		return
+	}
+	project, err := h.projectRepo.Get(r.Context(), r.PathValue("projectID"))
+	if err != nil {
+		h.writeRepositoryError(w, err)
+		return
+	}
+	element, _, _ := findElement(project, r.PathValue("elementID"))
+	if element == nil {
+		writeError(w, http.StatusNotFound, errElementNotFound)
+		return
+	}
+	resolved, err := h.systemResolver.Resolve(r.Context(), element.Type, element.Material)
+	if err != nil {
+		h.writeServiceError(w, err)
+		return
+	}
+	materials, err := h.stockService.Resolve(r.Context(), resolved, req.ColorID)
+	if err != nil {
+		h.writeServiceError(w, err)
+		return
+	}
+	if err := materials.AssignElement(element); err != nil {
+		h.writeServiceError(w, err)
+		return
+	}
+	if err := h.projectRepo.Update(r.Context(), project); err != nil {
+		h.writeRepositoryError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, element)
+}
+
+// slidingWindsRequest es el cuerpo aceptado para generar las hojas de una corredera.
+type slidingWindsRequest struct {
+	Layout string `json:"layout"` // ej. constants.SLIDING_LAYOUT_2_PANELS
+}
+
+// generateSlidingWinds reemplaza las hojas de un elemento corredera según la disposición pedida y guarda el proyecto.
+func (h *Handler) generateSlidingWinds(w http.ResponseWriter, r *http.Request) {
+	var req slidingWindsRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	project, err := h.projectRepo.Get(r.Context(), r.PathValue("projectID"))
+	if err != nil {
+		h.writeRepositoryError(w, err)
+		return
+	}
+	element, _, _ := findElement(project, r.PathValue("elementID"))
+	if element == nil {
+		writeError(w, http.StatusNotFound, errElementNotFound)
+		return
+	}
+	if element.Type != constants.TYPE_SLIDING {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("el elemento es de tipo '%s'; solo se generan hojas correderas para '%s'", element.Type, constants.TYPE_SLIDING))
+		return
+	}
+	if err := h.windGenerator.GenerateSliding(r.Context(), element, req.Layout); err != nil {
+		h.writeServiceError(w, err)
+		return
+	}
+	if err := h.projectRepo.Update(r.Context(), project); err != nil {
+		h.writeRepositoryError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, element)
+}
+
+// casementWindsRequest es el cuerpo aceptado para generar las hojas de un elemento de abatir.
+type casementWindsRequest struct {
+	Sashes []models.CasementSash `json:"sashes"`
+}
+
+// generateCasementWinds reemplaza las hojas de un elemento de abatir por las pedidas y guarda el proyecto.
+func (h *Handler) generateCasementWinds(w http.ResponseWriter, r *http.Request) {
+	var req casementWindsRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	project, err := h.projectRepo.Get(r.Context(), r.PathValue("projectID"))
+	if err != nil {
+		h.writeRepositoryError(w, err)
+		return
+	}
+	element, _, _ := findElement(project, r.PathValue("elementID"))
+	if element == nil {
+		writeError(w, http.StatusNotFound, errElementNotFound)
+		return
+	}
+	if element.Type != constants.TYPE_CASEMENT {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("el elemento es de tipo '%s'; solo se generan hojas de abatir para '%s'", element.Type, constants.TYPE_CASEMENT))
+		return
+	}
+	if err := h.windGenerator.GenerateCasement(r.Context(), element, req.Sashes); err != nil {
+		h.writeServiceError(w, err)
+		return
+	}
+	if err := h.projectRepo.Update(r.Context(), project); err != nil {
+		h.writeRepositoryError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, element)
+}
+
+// setElementLayout divide el elemento en campos según el árbol recibido (un models.Field raíz) y guarda el proyecto.
+func (h *Handler) setElementLayout(w http.ResponseWriter, r *http.Request) {
+	var root models.Field
+	if err := decodeJSON(w, r, &root); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	project, err := h.projectRepo.Get(r.Context(), r.PathValue("projectID"))
+	if err != nil {
+		h.writeRepositoryError(w, err)
+		return
+	}
+	element, _, _ := findElement(project, r.PathValue("elementID"))
+	if element == nil {
+		writeError(w, http.StatusNotFound, errElementNotFound)
+		return
+	}
+	if err := h.windGenerator.GenerateLayout(r.Context(), element, root); err != nil {
+		h.writeServiceError(w, err)
+		return
+	}
+	if err := h.projectRepo.Update(r.Context(), project); err != nil {
+		h.writeRepositoryError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, element)
+}
+
+// calculateGlass calcula los paños de vidrio y junquillos del elemento con el vidrio recibido
+// (un models.GlassSpec) y guarda el proyecto.
+func (h *Handler) calculateGlass(w http.ResponseWriter, r *http.Request) {
+	var spec models.GlassSpec
+	if err := decodeJSON(w, r, &spec); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if err := spec.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	project, err := h.projectRepo.Get(r.Context(), r.PathValue("projectID"))
+	if err != nil {
+		h.writeRepositoryError(w, err)
+		return
+	}
+	element, _, _ := findElement(project, r.PathValue("elementID"))
+	if element == nil {
+		writeError(w, http.StatusNotFound, errElementNotFound)
+		return
+	}
+	if err := h.glassService.CalculateElement(r.Context(), element, spec); err != nil {
+		h.writeServiceError(w, err)
+		return
+	}
+	if err := h.projectRepo.Update(r.Context(), project); err != nil {
+		h.writeRepositoryError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, element.Glasses)
+}
+
+// findOrCreateModule localiza el módulo indicado dentro del proyecto. Con IDs vacíos
+// usa el primer componente/módulo disponible, creándolos si no existen.
+func findOrCreateModule(project *models.Project, componentID, moduleID string) (*models.Module, error) {
+	if componentID == "" && len(project.Components) == 0 {
+		component, err := models.NewComponent("", nil)
+		if err != nil {
+			return nil, err
+		}
+		project.AddComponent(*component)
+	}
+
+	var component *models.Component
+	for i := range project.Components {
+		if componentID == "" || project.Components[i].ID == componentID {
+			component = &project.Components[i]
+			break
+		}
+	}
+	if component == nil {
+		return nil, fmt.Errorf("componente '%s' no encontrado en el proyecto", componentID)
+	}
+
+	if moduleID == "" && len(component.Modules) == 0 {
+		module, err := models.NewModule("", nil, nil)
+		if err != nil {
+			return nil, err
+		}
+		component.Modules = append(component.Modules, *module)
+	}
+	for i := range component.Modules {
+		if moduleID == "" || component.Modules[i].ID == moduleID {
+			return &component.Modules[i], nil
+		}
+	}
+	return nil, fmt.Errorf("módulo '%s' no encontrado en el componente '%s'", moduleID, component.ID)
+}
+
+// findElement busca un elemento por ID y devuelve también su módulo contenedor y su índice.
+func findElement(project *models.Project, elementID string) (*models.Element, *models.Module, int) {
+	for ci := range project.Components {
+		for mi := range project.Components[ci].Modules {
+			module := &project.Components[ci].Modules[mi]
+			for ei := range module.Elements {
+				if module.Elements[ei].ID == elementID {
+					return &module.Elements[ei], module, ei
+				}
+			}
+		}
+	}
+	return nil, nil, -1
+}
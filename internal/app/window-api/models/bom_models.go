@@ -0,0 +1,99 @@
+package models
+
+import (
+	"math"
+	"sort"
+)
+
+// BOMLine es el total de un material (ítem de stock + color) en la lista de materiales.
+type BOMLine struct {
+	StockKey
+	ProfileSKU     string  `json:"profile_sku"`
+	ProfileName    string  `json:"profile_name,omitempty"`
+	Reinforcement  bool    `json:"reinforcement,omitempty"` // Acero de refuerzo
+	Pieces         int     `json:"pieces"`
+	LengthMM       int     `json:"length_mm"` // Suma de los largos de corte
+	Meters         float64 `json:"meters"`
+	BarLengthMM    int     `json:"bar_length_mm"`
+	Bars           int     `json:"bars"`                       // Barras nuevas a comprar según el plan de corte
+	OffcutsUsed    int     `json:"offcuts_used,omitempty"`     // Retazos del inventario usados en lugar de barras
+	WeightPerMeter float64 `json:"weight_per_meter,omitempty"` // kg/m (profile_weigth_meter)
+	WeightKg       float64 `json:"weight_kg"`                  // Peso de las barras a comprar
+}
+
+// BillOfMaterials es la lista de materiales de perfiles de un proyecto.
+type BillOfMaterials struct {
+	ProjectID     string    `json:"project_id"`
+	ProjectName   string    `json:"project_name"`
+	Lines         []BOMLine `json:"lines"`
+	TotalPieces   int       `json:"total_pieces"`
+	TotalMeters   float64   `json:"total_meters"`
+	TotalBars     int       `json:"total_bars"`
+	TotalWeightKg float64   `json:"total_weight_kg"`
+}
+
+// BillOfMaterials suma las piezas calculadas del proyecto (marcos, montantes, hojas, junquillos y
+// refuerzos) por ítem de stock y color. profiles asocia cada SKU de perfil a su ficha del catálogo,
+// de la que se toman el nombre y el peso por metro. Las barras a comprar salen de plan; el peso
+// se calcula sobre esas barras, que es lo que se compra y se transporta.
+//
+// Las líneas van ordenadas con los perfiles primero y los refuerzos al final, cada grupo por SKU y color.
+func (p *Project) BillOfMaterials(profiles map[string]Profile, plan *CuttingPlan) *BillOfMaterials {
+	bom := &BillOfMaterials{ProjectID: p.ID, ProjectName: p.Name, Lines: []BOMLine{}}
+	byKey := make(map[StockKey]*BOMLine)
+	for _, piece := range p.Pieces() {
+		key := piece.StockKey()
+		line, ok := byKey[key]
+		if !ok {
+			profile := profiles[piece.ProfileSKU]
+			line = &BOMLine{
+				StockKey:       key,
+				ProfileSKU:     piece.ProfileSKU,
+				ProfileName:    profile.Name,
+				Reinforcement:  piece.Reinforcement,
+				WeightPerMeter: profile.WeightPerMeter,
+			}
+			byKey[key] = line
+		}
+		line.Pieces++
+		line.LengthMM += piece.Length
+	}
+	if plan != nil {
+		for _, group := range plan.Groups {
+			if line, ok := byKey[group.StockKey]; ok {
+				line.BarLengthMM = group.BarLengthMM
+				line.Bars = group.NewBars
+				line.OffcutsUsed = group.OffcutsUsed
+			}
+		}
+	}
+
+	for _, line := range byKey {
+		line.Meters = roundTo(float64(line.LengthMM)/1000, 3)
+		line.WeightKg = roundTo(float64(line.Bars*line.BarLengthMM)/1000*line.WeightPerMeter, 2)
+		bom.Lines = append(bom.Lines, *line)
+		bom.TotalPieces += line.Pieces
+		bom.TotalMeters += line.Meters
+		bom.TotalBars += line.Bars
+		bom.TotalWeightKg += line.WeightKg
+	}
+	bom.TotalMeters = roundTo(bom.TotalMeters, 3)
+	bom.TotalWeightKg = roundTo(bom.TotalWeightKg, 2)
+	sort.Slice(bom.Lines, func(i, j int) bool {
+		a, b := bom.Lines[i], bom.Lines[j]
+		if a.Reinforcement != b.Reinforcement {
+			return !a.Reinforcement
+		}
+		if a.ItemSKU != b.ItemSKU {
+			return a.ItemSKU < b.ItemSKU
+		}
+		return a.Color < b.Color
+	})
+	return bom
+}
+
+// roundTo redondea value a los decimales indicados.
+func roundTo(value float64, decimals int) float64 {
+	factor := math.Pow(10, float64(decimals))
+	return math.Round(value*factor) / factor
+}
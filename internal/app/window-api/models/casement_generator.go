@@ -0,0 +1,177 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/mvialf/windraw/internal/pkg/constants"
+)
+
+// ErrUnsupportedOpening indica una combinación de tipo de hoja, lado y sentido de apertura
+// que los sistemas de abatir no permiten fabricar (ej. una oscilobatiente hacia el exterior).
+var ErrUnsupportedOpening = errors.New("apertura no soportada")
+
+// CasementSash describe una hoja de abatir pedida por el usuario.
+// OpeningSide es el lado de las bisagras (constants.OPENING_SIDE_*) y OpeningDirection
+// el sentido de apertura (constants.OPENING_INT u OPENING_EXT).
+type CasementSash struct {
+	Kind             string `json:"kind"`
+	OpeningSide      string `json:"opening_side"`
+	OpeningDirection string `json:"opening_direction"`
+}
+
+// casementRule indica los lados de bisagra y sentidos que admite cada tipo de hoja.
+type casementRule struct {
+	sides      []string
+	directions []string
+}
+
+// casementRules son las combinaciones que se pueden fabricar con los sistemas de abatir.
+var casementRules = map[string]casementRule{
+	constants.WIND_KIND_CASEMENT: {
+		sides:      []string{constants.OPENING_SIDE_LEFT, constants.OPENING_SIDE_RIGHT},
+		directions: []string{constants.OPENING_INT, constants.OPENING_EXT},
+	},
+	constants.WIND_KIND_PROJECTING: { // Proyectante: bisagra arriba, abre hacia afuera
+		sides:      []string{constants.OPENING_SIDE_TOP},
+		directions: []string{constants.OPENING_EXT},
+	},
+	constants.WIND_KIND_TILT_TURN: { // Oscilobatiente: el herraje solo existe para apertura interior
+		sides:      []string{constants.OPENING_SIDE_LEFT, constants.OPENING_SIDE_RIGHT},
+		directions: []string{constants.OPENING_INT},
+	},
+	constants.WIND_KIND_TILT_ONLY: { // Oscilante: bisagra abajo, abre hacia adentro
+		sides:      []string{constants.OPENING_SIDE_BOTTOM},
+		directions: []string{constants.OPENING_INT},
+	},
+}
+
+// ValidateCasementSash comprueba que la combinación de tipo, lado y sentido se pueda fabricar.
+// Los errores por combinación no soportada envuelven ErrUnsupportedOpening.
+func ValidateCasementSash(sash CasementSash) error {
+	rule, ok := casementRules[sash.Kind]
+	if !ok {
+		return fmt.Errorf("%w: tipo de hoja '%s' no es de abatir", ErrUnsupportedOpening, sash.Kind)
+	}
+	if !IsValidOption(sash.OpeningDirection, rule.directions) {
+		return fmt.Errorf("%w: '%s' no admite apertura '%s' (válidas: %v)", ErrUnsupportedOpening, sash.Kind, sash.OpeningDirection, rule.directions)
+	}
+	if !IsValidOption(sash.OpeningSide, rule.sides) {
+		return fmt.Errorf("%w: '%s' no admite bisagras en '%s' (válidos: %v)", ErrUnsupportedOpening, sash.Kind, sash.OpeningSide, rule.sides)
+	}
+	return nil
+}
+
+// CasementProfiles son los perfiles del sistema que intervienen en el dimensionado de hojas de abatir.
+// WindIn y WindOut son opcionales; solo se exige el que corresponda al sentido de cada hoja.
+type CasementProfiles struct {
+	Frame   Profile  // constants.PROFILE_TYPE_CASEMENT_FRAME
+	WindIn  *Profile // constants.PROFILE_TYPE_CASEMENT_WIND_IN
+	WindOut *Profile // constants.PROFILE_TYPE_CASEMENT_WIND_OUT
+}
+
+// windProfileFor devuelve el perfil de hoja según el sentido de apertura.
+func (p CasementProfiles) windProfileFor(direction string) (Profile, error) {
+	profile, profileType := p.WindIn, constants.PROFILE_TYPE_CASEMENT_WIND_IN
+	if direction == constants.OPENING_EXT {
+		profile, profileType = p.WindOut, constants.PROFILE_TYPE_CASEMENT_WIND_OUT
+	}
+	if profile == nil {
+		return Profile{}, fmt.Errorf("%w: el sistema no tiene perfil '%s' para apertura %s", ErrUnsupportedOpening, profileType, direction)
+	}
+	return *profile, nil
+}
+
+// GenerateCasementWinds deriva las hojas de abatir de un marco, repartiendo su luz en partes iguales.
+//
+// Dimensionado (mm):
+//   - Luz del marco: ancho/alto exterior menos dos veces el ancho W del perfil de marco.
+//   - Cada hoja cubre su parte de la luz más el solape SashOverlapMM de su perfil sobre el marco
+//     en cada borde que apoya en el marco. En el encuentro entre dos hojas no se suma solape:
+//     topan y la junta la cubre el adaptador de hoja.
+//
+// El perfil de hoja es PROFILE_TYPE_CASEMENT_WIND_IN u _OUT según el sentido de apertura,
+// y el tipo de corte el del perfil (CUT_ANGLE_WIND si no tiene).
+func GenerateCasementWinds(frame Frame, sashes []CasementSash, profiles CasementProfiles) ([]Wind, error) {
+	if len(sashes) == 0 {
+		return nil, errors.New("se debe indicar al menos una hoja de abatir")
+	}
+	lightWidth := float64(frame.Width) - 2*profiles.Frame.W
+	lightHeight := float64(frame.Height) - 2*profiles.Frame.W
+	if lightWidth <= 0 || lightHeight <= 0 {
+		return nil, fmt.Errorf("el marco de %dx%d mm no deja luz con el perfil %s (W=%.1f mm)",
+			frame.Width, frame.Height, profiles.Frame.SKU, profiles.Frame.W)
+	}
+
+	return casementWindsForLight(lightWidth, lightHeight, sashes, profiles, 1)
+}
+
+// casementWindsForLight crea las hojas que reparten a partes iguales una luz de lightWidth x lightHeight mm.
+// firstNumber es el número de la primera hoja en su nombre ("Hoja N ...").
+func casementWindsForLight(lightWidth, lightHeight float64, sashes []CasementSash, profiles CasementProfiles, firstNumber int) ([]Wind, error) {
+	validKinds := []string{constants.WIND_KIND_CASEMENT, constants.WIND_KIND_PROJECTING, constants.WIND_KIND_TILT_TURN, constants.WIND_KIND_TILT_ONLY}
+	positions := []string{constants.POSITION_LEFT, constants.POSITION_RIGHT, constants.POSITION_TOP, constants.POSITION_BOTTOM}
+	share := lightWidth / float64(len(sashes))
+
+	winds := make([]Wind, 0, len(sashes))
+	for i, sash := range sashes {
+		number := firstNumber + i
+		if err := ValidateCasementSash(sash); err != nil {
+			return nil, fmt.Errorf("hoja %d: %w", number, err)
+		}
+		profile, err := profiles.windProfileFor(sash.OpeningDirection)
+		if err != nil {
+			return nil, fmt.Errorf("hoja %d: %w", number, err)
+		}
+
+		width := share
+		if i == 0 {
+			width += profile.SashOverlapMM
+		}
+		if i == len(sashes)-1 {
+			width += profile.SashOverlapMM
+		}
+		height := lightHeight + 2*profile.SashOverlapMM
+
+		cutType := profile.CutType
+		if cutType == "" {
+			cutType = constants.CUT_ANGLE_WIND
+		}
+		name := fmt.Sprintf("Hoja %d %s", number, sash.Kind)
+		wind, err := NewWind(name, sash.Kind, int(math.Round(width)), int(math.Round(height)), cutType,
+			constants.WIND_STATUS_ACTIVE, positions, validKinds, []string{cutType})
+		if err != nil {
+			return nil, fmt.Errorf("error creando la hoja %d: %w", number, err)
+		}
+		wind.OpeningSide = sash.OpeningSide
+		wind.OpeningDirection = sash.OpeningDirection
+		for _, pos := range positions {
+			if err := wind.assignProfile(pos, profile); err != nil {
+				return nil, err
+			}
+		}
+		winds = append(winds, *wind)
+	}
+	return winds, nil
+}
+
+// SetCasementWinds reemplaza las hojas del elemento por las generadas con GenerateCasementWinds
+// y asigna el perfil de marco a las posiciones del marco que aún no tienen perfil.
+func (e *Element) SetCasementWinds(sashes []CasementSash, profiles CasementProfiles) error {
+	if e.Type != constants.TYPE_CASEMENT {
+		return fmt.Errorf("el elemento %s es de tipo '%s', no '%s'", e.ID, e.Type, constants.TYPE_CASEMENT)
+	}
+	if err := e.Frame.requireRectangular("generar hojas de abatir"); err != nil {
+		return err
+	}
+	winds, err := GenerateCasementWinds(e.Frame, sashes, profiles)
+	if err != nil {
+		return err
+	}
+	if err := e.Frame.assignMissingProfile(profiles.Frame); err != nil {
+		return err
+	}
+	e.Winds = winds
+	return nil
+}
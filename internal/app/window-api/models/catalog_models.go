@@ -1,22 +1,179 @@
 package models
 
-import (
-	"time" // <--- AÑADE ESTA LÍNEA
-)
+import "fmt"
 
-// Profile representa un tipo de perfil del catálogo.
+// Profile representa un perfil del catálogo (tabla 'profiles').
+// Las medidas están en mm; las columnas numéricas anulables se leen como 0 cuando son NULL.
+//
+// H es la profundidad total del perfil (sentido interior-exterior) y W su ancho de cara vista, lo
+// que se descuenta cuando otra pieza topa contra él. El esquema no documenta qué mide cada una de
+// las cotas H1 a H4 y W1, así que el cálculo no las usa directamente: las medidas que necesita
+// (SashOverlapMM, GlassRebateMM, InterlockMM y CouplingMM) se copian de la cota que indique la
+// configuración del catálogo (ver ProfileDimensionMap).
 type Profile struct {
-	ID          string   `json:"id"` // O int, dependiendo de tu PK en Supabase
-	SKU         string   `json:"sku"`
-	Description string   `json:"description"`
-	Material    string   `json:"material"` // e.g., constants.MATERIAL_PVC, constants.MATERIAL_ALUMINIO
-	WeightPerM  float64  `json:"weight_per_meter"`
-	Colors      []string `json:"available_colors"` // Si los colores son un array de texto en Supabase
-	// ... otros campos relevantes del perfil: dimensiones, tipo, etc.
-
-	// Campos de auditoría opcionales que Supabase podría gestionar
-	CreatedAt time.Time `json:"created_at"` // Ahora 'time.Time' será reconocido
-	UpdatedAt time.Time `json:"updated_at"` // Ahora 'time.Time' será reconocido
+	ID             int64   `json:"profile_id"`
+	SKU            string  `json:"profile_sku"`
+	Name           string  `json:"profile_name"`
+	Type           string  `json:"profile_type"` // e.g., constants.PROFILE_TYPE_SLIDING_FRAME
+	MaterialID     int64   `json:"material_id"`
+	SupplierID     int64   `json:"supplier_id"`
+	WeightPerMeter float64 `json:"profile_weigth_meter"` // kg/m (el nombre de la columna en la BD es 'weigth')
+
+	// Geometría de la sección (mm)
+	H  float64 `json:"profile_h"`
+	W  float64 `json:"profile_w"`
+	H1 float64 `json:"profile_h1"`
+	H2 float64 `json:"profile_h2"`
+	H3 float64 `json:"profile_h3"`
+	H4 float64 `json:"profile_h4"`
+	W1 float64 `json:"profile_w1"`
+
+	// Medidas de cálculo (mm), copiadas de las cotas por ProfileDimensionMap.Apply
+	SashOverlapMM float64 `json:"sash_overlap_mm,omitempty"` // Solape de la hoja de abatir sobre el marco
+	GlassRebateMM float64 `json:"glass_rebate_mm,omitempty"` // Profundidad del galce donde asienta el vidrio
+	InterlockMM   float64 `json:"interlock_mm,omitempty"`    // Lo que una pieza superpuesta o un traslapo entra sobre el perfil
+	CouplingMM    float64 `json:"coupling_mm,omitempty"`     // Lo que un montante entra en el perfil contiguo
+
+	// Atributos de fabricación
+	CutType     string  `json:"profile_cut_type"` // Tipo de corte por defecto (ej. constants.CUT_ANGLE)
+	CutMarginMM float64 `json:"cut_margin_mm"`    // Margen de corte que se suma a cada pieza
+	TrackCount  int     `json:"track_count"`      // Número de rieles (marcos de corredera)
+	UsesOverlap bool    `json:"uses_overlap"`     // Si la hoja lleva traslapo en el encuentro
+	Position    string  `json:"profile_position"` // Posición en la que se usa el perfil
+	Structure   string  `json:"profile_structure"`
+	WeldMargin  bool    `json:"weld_margin"` // Si requiere margen de soldadura (PVC termosoldado)
+}
+
+// ProfileDimensionMap indica de qué columna de 'profiles' (ej. "profile_h1") se toma cada medida
+// de cálculo del perfil. Una medida sin columna queda en 0.
+type ProfileDimensionMap struct {
+	SashOverlap string
+	GlassRebate string
+	Interlock   string
+	Coupling    string
+}
+
+// DefaultProfileDimensionMap es la correspondencia que se usa si no se configura otra. Ninguna
+// documentación la respalda: es la interpretación de las cotas con la que se escribió el cálculo y
+// debe confirmarse con el proveedor de cada catálogo (ver config.CatalogConfig).
+var DefaultProfileDimensionMap = ProfileDimensionMap{
+	SashOverlap: "profile_h1",
+	GlassRebate: "profile_h2",
+	Interlock:   "profile_h3",
+	Coupling:    "profile_w1",
+}
+
+// profileDimensionColumns son las cotas de 'profiles' que se pueden usar como medida de cálculo.
+var profileDimensionColumns = []string{"profile_h", "profile_w", "profile_h1", "profile_h2", "profile_h3", "profile_h4", "profile_w1"}
+
+// Validate comprueba que cada medida configurada apunte a una cota de la sección del perfil.
+func (m ProfileDimensionMap) Validate() error {
+	for measure, column := range map[string]string{
+		"solape de hoja": m.SashOverlap,
+		"galce":          m.GlassRebate,
+		"encaje":         m.Interlock,
+		"acople":         m.Coupling,
+	} {
+		if column != "" && !IsValidOption(column, profileDimensionColumns) {
+			return fmt.Errorf("columna '%s' no válida para la medida de %s (válidas: %v)", column, measure, profileDimensionColumns)
+		}
+	}
+	return nil
+}
+
+// Apply copia en las medidas de cálculo del perfil el valor de las cotas configuradas.
+func (m ProfileDimensionMap) Apply(p *Profile) {
+	column := func(name string) float64 {
+		switch name {
+		case "profile_h":
+			return p.H
+		case "profile_w":
+			return p.W
+		case "profile_h1":
+			return p.H1
+		case "profile_h2":
+			return p.H2
+		case "profile_h3":
+			return p.H3
+		case "profile_h4":
+			return p.H4
+		case "profile_w1":
+			return p.W1
+		}
+		return 0
+	}
+	p.SashOverlapMM = column(m.SashOverlap)
+	p.GlassRebateMM = column(m.GlassRebate)
+	p.InterlockMM = column(m.Interlock)
+	p.CouplingMM = column(m.Coupling)
+}
+
+// Material representa un material del catálogo (tabla 'materials'), ej. "PVC" o "Aluminio".
+type Material struct {
+	ID   int64  `json:"material_id"`
+	Name string `json:"name"`
+}
+
+// ProfileSystem representa un sistema de perfiles (tabla 'profile_systems').
+// Un sistema agrupa los perfiles compatibles de un proveedor para un tipo de elemento.
+type ProfileSystem struct {
+	ID              int64   `json:"system_id"`
+	Name            string  `json:"name"`
+	SupplierID      int64   `json:"supplier_id"`
+	Type            string  `json:"type"` // Tipo de elemento (sliding / casement)
+	MaterialID      int64   `json:"material_id"`
+	UsesGlassBead   bool    `json:"uses_glass_bead"`
+	GlassMarginMM   float64 `json:"glass_margin_mm"`
+	TopOverlapMM    float64 `json:"top_overlap_mm"`
+	BottomOverlapMM float64 `json:"bottom_overlap_mm"`
+	SideOverlapMM   float64 `json:"side_overlap_mm"`
+	Primacy         *int64  `json:"prymacy"` // Prioridad: 1 es la más alta; nil va al final (la columna en la BD es 'prymacy')
+}
+
+// SystemProfile es una fila de 'system_profile_list': un perfil habilitado en un sistema.
+// Profile viene embebido desde 'profiles' cuando se consulta con select=*,profiles(...).
+type SystemProfile struct {
+	SystemID  int64    `json:"system_id"`
+	ProfileID int64    `json:"profile_id"`
+	Primacy   *int64   `json:"primacy"` // Prioridad entre perfiles del mismo tipo: 1 es la más alta; nil va al final
+	Profile   *Profile `json:"profiles,omitempty"`
+}
+
+// Color representa un color del catálogo (tabla 'colors').
+type Color struct {
+	ID      int64  `json:"color_id"`
+	Name    string `json:"name"`
+	HexCode string `json:"hex_code"`
+}
+
+// SystemColor es una fila de 'system_available_colors': un color ofrecido por un sistema.
+// Color viene embebido desde 'colors' cuando se consulta con select=*,colors(*).
+type SystemColor struct {
+	SystemID        int64  `json:"system_id"`
+	ColorID         int64  `json:"color_id"`
+	ColorCodeSuffix string `json:"color_code_suffix"` // Sufijo que identifica el color en el SKU del ítem
+	Color           *Color `json:"colors,omitempty"`
+}
+
+// StockItem representa un ítem comprable (tabla 'stock_items'): un perfil en un color de un proveedor.
+type StockItem struct {
+	ID         int64    `json:"stock_item_id"`
+	ProfileID  int64    `json:"profile_id"`
+	ColorID    int64    `json:"color_id"`
+	SupplierID int64    `json:"supplier_id"`
+	ItemSKU    string   `json:"item_sku"`
+	Price      float64  `json:"profile_price"`   // Precio por barra
+	LengthMM   float64  `json:"profile_length"`  // Largo de la barra en mm
+	Stock      *float64 `json:"stock,omitempty"` // Stock disponible, si la tabla lo informa
+}
+
+// ProfileReinforcement es una fila de 'profile_reinforcements': el refuerzo (acero) que lleva un perfil.
+// Reinforcement viene embebido desde 'profiles' a través de reinforcement_profile_id.
+type ProfileReinforcement struct {
+	MainProfileID          int64    `json:"main_profile_id"`
+	ReinforcementProfileID int64    `json:"reinforcement_profile_id"`
+	GapMM                  float64  `json:"reinforcement_gap_mm"` // Holgura en cada extremo entre el refuerzo y el largo de la pieza
+	Reinforcement          *Profile `json:"reinforcement,omitempty"`
 }
 
 // Aquí podrías tener otros modelos de catálogo si los necesitas:
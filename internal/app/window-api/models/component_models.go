@@ -22,14 +22,11 @@ type Component struct {
 	Modules []Module `json:"modules"` // Lista de módulos que componen este componente
 }
 
-// Aquí podrías añadir constructores para Component, Module, y AuxProfile si lo necesitas,
-// de forma similar a como los tienes para Project, Element, etc.
-// Ejemplo (muy básico, necesitarías pasar los datos necesarios):
-
-/*
+// NewModule es el constructor para la estructura Module.
+// Si id está vacío se genera uno nuevo con generateID().
 func NewModule(id string, elements []Element, auxProfile *AuxProfile) (*Module, error) {
 	if id == "" {
-		id = generateID() // Asumiendo que generateID() está disponible en el paquete models
+		id = generateID()
 	}
 	if elements == nil {
 		elements = []Element{}
@@ -41,9 +38,11 @@ func NewModule(id string, elements []Element, auxProfile *AuxProfile) (*Module,
 	}, nil
 }
 
+// NewComponent es el constructor para la estructura Component.
+// Si id está vacío se genera uno nuevo con generateID().
 func NewComponent(id string, modules []Module) (*Component, error) {
 	if id == "" {
-		id = generateID() // Asumiendo que generateID() está disponible
+		id = generateID()
 	}
 	if modules == nil {
 		modules = []Module{}
@@ -53,4 +52,3 @@ func NewComponent(id string, modules []Module) (*Component, error) {
 		Modules: modules,
 	}, nil
 }
-*/
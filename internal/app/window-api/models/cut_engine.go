@@ -0,0 +1,167 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/mvialf/windraw/internal/pkg/constants"
+)
+
+// ErrInvalidCut indica que las piezas no se pueden calcular con los datos del elemento
+// (tipo de corte desconocido, perfiles faltantes, largos no positivos...).
+var ErrInvalidCut = errors.New("corte inválido")
+
+// cutErrorf crea un error que envuelve ErrInvalidCut.
+func cutErrorf(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s", ErrInvalidCut, fmt.Sprintf(format, args...))
+}
+
+// jointStyle indica cómo se unen las cuatro piezas de un marco u hoja rectangular.
+type jointStyle int
+
+const (
+	jointMiter             jointStyle = iota // Inglete a 45° en las cuatro esquinas (piezas al largo exterior)
+	jointVerticalThrough                     // Verticales pasantes; horizontales entre verticales a 90°
+	jointHorizontalThrough                   // Horizontales pasantes; verticales entre horizontales a 90°
+	jointVerticalOverlap                     // Verticales pasantes; las horizontales encajan InterlockMM
+	jointHorizontalOverlap                   // Horizontales pasantes; las verticales encajan InterlockMM
+	jointCustom                              // Largos indicados a mano por posición
+)
+
+// jointForCutType traduce un tipo de corte (constants.CUT_* y CUT_*_WIND) a la unión que describe.
+// CUT_SQUARE y CUT_ANGLE comparten valor con CUT_SQUARE_WIND y CUT_ANGLE_WIND, por lo que
+// marcos y hojas usan la misma tabla. El corte cuadrado deja pasantes las piezas verticales.
+func jointForCutType(cutType string) (jointStyle, error) {
+	switch cutType {
+	case constants.CUT_ANGLE_WIND:
+		return jointMiter, nil
+	case constants.CUT_SQUARE_WIND, constants.CUT_VERTICAL_WIND:
+		return jointVerticalThrough, nil
+	case constants.CUT_HORIZONTAL_WIND:
+		return jointHorizontalThrough, nil
+	case constants.CUT_VERTICAL_OVERLAP_WIND:
+		return jointVerticalOverlap, nil
+	case constants.CUT_HORIZONTAL_OVERLAP_WIND:
+		return jointHorizontalOverlap, nil
+	case constants.CUT_CUSTOM_WIND:
+		return jointCustom, nil
+	default:
+		return 0, cutErrorf("tipo de corte no soportado: '%s'", cutType)
+	}
+}
+
+// CutPiece es el resultado del motor de corte para una posición.
+type CutPiece struct {
+	Length     int     // Largo de corte en mm, con márgenes de soldadura y de corte incluidos
+	AngleLeft  float64 // Ángulo de corte del extremo izquierdo (o inferior) en grados
+	AngleRight float64 // Ángulo de corte del extremo derecho (o superior) en grados
+	BendRadius int     // Radio exterior de curvado en mm (solo piezas curvas)
+}
+
+// rectangularCuts calcula las piezas de un contorno rectangular de width x height mm (medidas exteriores).
+// profiles asocia cada posición (constants.POSITION_*) a su perfil; las posiciones ausentes no se calculan,
+// pero los perfiles adyacentes a una pieza que se descuenta deben estar presentes.
+//
+// Reglas:
+//   - Inglete: cada pieza mide el lado exterior, cortada a 45° en ambos extremos.
+//   - Pasante/entre piezas: la pieza pasante mide el lado exterior; la otra descuenta el ancho W
+//     de cada perfil contra el que topa.
+//   - Superpuesto: como el anterior, pero la pieza que topa entra InterlockMM (encaje) en cada
+//     pieza pasante.
+//   - Personalizado: el largo nominal se toma de custom y los ángulos de angles (90° si no se indican).
+//
+// Sobre el largo nominal se suma weldMarginMM por cada extremo no recto si el perfil lleva
+// weld_margin (PVC termosoldado) y, al final, cut_margin_mm del perfil. El resultado se redondea al mm.
+func rectangularCuts(width, height int, cutType string, profiles map[string]Profile,
+	custom map[string]int, angles map[string][2]float64, weldMarginMM float64) (map[string]CutPiece, error) {
+
+	joint, err := jointForCutType(cutType)
+	if err != nil {
+		return nil, err
+	}
+
+	adjacent := func(pos string) (Profile, error) {
+		profile, ok := profiles[pos]
+		if !ok {
+			return Profile{}, cutErrorf("falta el perfil de la posición adyacente '%s' para descontar su ancho", pos)
+		}
+		return profile, nil
+	}
+
+	pieces := make(map[string]CutPiece, len(profiles))
+	for pos, profile := range profiles {
+		vertical := pos == constants.POSITION_LEFT || pos == constants.POSITION_RIGHT
+		if !vertical && pos != constants.POSITION_TOP && pos != constants.POSITION_BOTTOM {
+			return nil, cutErrorf("posición '%s' no válida para un contorno rectangular", pos)
+		}
+		side := float64(width)
+		if vertical {
+			side = float64(height)
+		}
+
+		nominal := side
+		angleL, angleR := 90.0, 90.0
+		switch joint {
+		case jointMiter:
+			angleL, angleR = 45.0, 45.0
+		case jointVerticalThrough, jointHorizontalThrough, jointVerticalOverlap, jointHorizontalOverlap:
+			throughIsVertical := joint == jointVerticalThrough || joint == jointVerticalOverlap
+			if vertical == throughIsVertical {
+				break // Pieza pasante: largo exterior
+			}
+			first, second := constants.POSITION_LEFT, constants.POSITION_RIGHT
+			if !throughIsVertical {
+				first, second = constants.POSITION_BOTTOM, constants.POSITION_TOP
+			}
+			for _, p := range []string{first, second} {
+				through, err := adjacent(p)
+				if err != nil {
+					return nil, fmt.Errorf("posición '%s': %w", pos, err)
+				}
+				nominal -= through.W
+				if joint == jointVerticalOverlap || joint == jointHorizontalOverlap {
+					nominal += through.InterlockMM
+				}
+			}
+		case jointCustom:
+			length, ok := custom[pos]
+			if !ok || length <= 0 {
+				return nil, cutErrorf("el corte personalizado requiere un largo positivo para la posición '%s'", pos)
+			}
+			nominal = float64(length)
+			if a, ok := angles[pos]; ok {
+				if a[0] > 0 {
+					angleL = a[0]
+				}
+				if a[1] > 0 {
+					angleR = a[1]
+				}
+			}
+		}
+
+		length := nominal
+		if profile.WeldMargin {
+			for _, angle := range []float64{angleL, angleR} {
+				if angle != 90.0 {
+					length += weldMarginMM
+				}
+			}
+		}
+		length += profile.CutMarginMM
+
+		rounded := int(math.Round(length))
+		if rounded <= 0 {
+			return nil, cutErrorf("la pieza de la posición '%s' resulta con largo no positivo (%d mm)", pos, rounded)
+		}
+		pieces[pos] = CutPiece{Length: rounded, AngleLeft: angleL, AngleRight: angleR}
+	}
+	return pieces, nil
+}
+
+// interlockCut calcula la pieza de traslapo que se monta sobre el montante de encuentro de una
+// hoja corredera: recta en ambos extremos, al alto de la hoja más cut_margin_mm.
+func interlockCut(windHeight int, profile Profile) CutPiece {
+	length := int(math.Round(float64(windHeight) + profile.CutMarginMM))
+	return CutPiece{Length: length, AngleLeft: 90.0, AngleRight: 90.0}
+}
@@ -0,0 +1,58 @@
+package models
+
+import "sort"
+
+// CuttingListGroup son las piezas de un material (ítem de stock + color) de la lista de corte.
+type CuttingListGroup struct {
+	StockKey
+	ProfileSKU string  `json:"profile_sku"`
+	Pieces     []Piece `json:"pieces"`   // De la más larga a la más corta
+	TotalMM    int     `json:"total_mm"` // Suma de los largos de corte
+}
+
+// CuttingList es la lista de corte de taller de un proyecto.
+type CuttingList struct {
+	ProjectID   string             `json:"project_id"`
+	ProjectName string             `json:"project_name"`
+	Groups      []CuttingListGroup `json:"groups"`
+	TotalPieces int                `json:"total_pieces"`
+}
+
+// CuttingList agrupa las piezas de perfil del proyecto por ítem de stock y color, ordenando los
+// grupos por SKU y color y las piezas de mayor a menor largo, para que el operador corte primero
+// las piezas largas y aproveche lo que queda de la barra en las cortas.
+//
+// Los refuerzos no se listan aparte: cada pieza reforzada informa el SKU y el largo de su acero.
+func (p *Project) CuttingList() *CuttingList {
+	list := &CuttingList{ProjectID: p.ID, ProjectName: p.Name, Groups: []CuttingListGroup{}}
+	byKey := make(map[StockKey]int)
+	for _, piece := range p.Pieces() {
+		if piece.Reinforcement {
+			continue
+		}
+		key := piece.StockKey()
+		i, ok := byKey[key]
+		if !ok {
+			i = len(list.Groups)
+			byKey[key] = i
+			list.Groups = append(list.Groups, CuttingListGroup{StockKey: key, ProfileSKU: piece.ProfileSKU})
+		}
+		list.Groups[i].Pieces = append(list.Groups[i].Pieces, piece)
+		list.Groups[i].TotalMM += piece.Length
+		list.TotalPieces++
+	}
+
+	sort.Slice(list.Groups, func(i, j int) bool {
+		a, b := list.Groups[i], list.Groups[j]
+		if a.ItemSKU != b.ItemSKU {
+			return a.ItemSKU < b.ItemSKU
+		}
+		return a.Color < b.Color
+	})
+	for _, group := range list.Groups {
+		sort.SliceStable(group.Pieces, func(i, j int) bool {
+			return group.Pieces[i].Length > group.Pieces[j].Length
+		})
+	}
+	return list
+}
@@ -0,0 +1,367 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"time"
+
+	"github.com/mvialf/windraw/internal/pkg/constants"
+)
+
+// OptimizerSettings son los parámetros del optimizador de corte.
+type OptimizerSettings struct {
+	Mode       string        `json:"mode"`    // constants.OPTIMIZER_MODE_*
+	KerfMM     float64       `json:"kerf_mm"` // Ancho del disco de sierra: material perdido en cada corte
+	TrimMM     float64       `json:"trim_mm"` // Recorte en cada extremo de la barra (puntas dañadas o fuera de escuadra)
+	TimeBudget time.Duration `json:"-"`       // Tiempo máximo de búsqueda en constants.OPTIMIZER_MODE_SEARCH
+}
+
+// Validate comprueba el modo y que los márgenes no sean negativos.
+func (s OptimizerSettings) Validate() error {
+	if !IsValidOption(s.Mode, []string{constants.OPTIMIZER_MODE_FIRST_FIT, constants.OPTIMIZER_MODE_SEARCH}) {
+		return fmt.Errorf("modo de optimización inválido: '%s'. Válidos: %v", s.Mode,
+			[]string{constants.OPTIMIZER_MODE_FIRST_FIT, constants.OPTIMIZER_MODE_SEARCH})
+	}
+	if s.KerfMM < 0 || s.TrimMM < 0 {
+		return errors.New("el ancho de corte y el recorte de barra no pueden ser negativos")
+	}
+	return nil
+}
+
+// Offcut es un retazo de barra disponible para cortar antes de abrir barras nuevas.
+type Offcut struct {
+	ID       string `json:"id"`
+	ItemSKU  string `json:"item_sku"`
+	Color    string `json:"color,omitempty"`
+	LengthMM int    `json:"length_mm"`
+}
+
+// StockKey devuelve la clave de material del retazo.
+func (o Offcut) StockKey() StockKey {
+	return StockKey{ItemSKU: o.ItemSKU, Color: o.Color}
+}
+
+// BarCut es una pieza ubicada en una barra.
+type BarCut struct {
+	Piece    Piece `json:"piece"`
+	OffsetMM int   `json:"offset_mm"`         // Distancia desde el inicio de la barra hasta la punta de la pieza
+	Flipped  bool  `json:"flipped,omitempty"` // La pieza se corta girada (extremo derecho primero)
+}
+
+// CutBar es una barra (nueva o retazo) con las piezas que se cortan de ella.
+type CutBar struct {
+	Source   string   `json:"source"`              // constants.BAR_SOURCE_*
+	OffcutID string   `json:"offcut_id,omitempty"` // ID del retazo usado
+	LengthMM int      `json:"length_mm"`
+	Cuts     []BarCut `json:"cuts"`
+	UsedMM   int      `json:"used_mm"`   // Suma de los largos de las piezas
+	OffcutMM int      `json:"offcut_mm"` // Sobrante al final de la barra, con su recorte de punta
+}
+
+// CuttingGroup es el plan de corte de un material (ítem de stock + color).
+type CuttingGroup struct {
+	StockKey
+	ProfileSKU  string   `json:"profile_sku"`
+	BarLengthMM int      `json:"bar_length_mm"`
+	Bars        []CutBar `json:"bars"`
+	NewBars     int      `json:"new_bars"`
+	OffcutsUsed int      `json:"offcuts_used"`
+	PiecesMM    int      `json:"pieces_mm"`          // Suma de los largos de las piezas ubicadas
+	StockMM     int      `json:"stock_mm"`           // Suma de los largos de las barras y retazos usados
+	Yield       float64  `json:"yield"`              // Aprovechamiento en %: PiecesMM / StockMM
+	Unplaced    []Piece  `json:"unplaced,omitempty"` // Piezas más largas que la barra
+}
+
+// CuttingPlan es el plan de corte de todas las piezas, barra por barra.
+type CuttingPlan struct {
+	ID          string            `json:"id"` // Identifica el plan al registrar sus cortes en el inventario
+	Settings    OptimizerSettings `json:"settings"`
+	Groups      []CuttingGroup    `json:"groups"`
+	NewBars     int               `json:"new_bars"`
+	OffcutsUsed int               `json:"offcuts_used"`
+	Yield       float64           `json:"yield"` // Aprovechamiento global en %
+}
+
+// OptimizeCuts reparte las piezas en barras por material (Piece.StockKey), usando primero los
+// retazos del mismo material (de menor a mayor) y después barras nuevas de barLengths[key] mm.
+// profileWidths asocia cada SKU de perfil a su ancho W, necesario para encajar cortes en ángulo.
+//
+// Consumo de cada pieza en la barra:
+//   - Se descuenta TrimMM en cada extremo de la barra (y de cada retazo).
+//   - Cada pieza consume su largo más KerfMM del corte que la separa de la siguiente.
+//   - Dos piezas seguidas con el mismo ángulo (distinto de 90°) en los extremos que se tocan
+//     comparten el corte inclinado: la segunda se solapa W / tan(ángulo) con la primera.
+//     Las piezas se pueden girar para aprovecharlo.
+//
+// Con OPTIMIZER_MODE_FIRST_FIT cada pieza, de la más larga a la más corta, va a la primera barra
+// en que cabe. Con OPTIMIZER_MODE_SEARCH se prueban además mejor ajuste y variaciones del orden
+// hasta agotar TimeBudget, y se queda el plan con menos barras nuevas y sobrantes más concentrados.
+func OptimizeCuts(pieces []Piece, barLengths map[StockKey]int, profileWidths map[string]float64,
+	offcuts []Offcut, settings OptimizerSettings) (*CuttingPlan, error) {
+
+	if err := settings.Validate(); err != nil {
+		return nil, err
+	}
+
+	byKey := make(map[StockKey][]Piece)
+	var keys []StockKey
+	for _, piece := range pieces {
+		key := piece.StockKey()
+		if _, ok := byKey[key]; !ok {
+			keys = append(keys, key)
+		}
+		byKey[key] = append(byKey[key], piece)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].ItemSKU != keys[j].ItemSKU {
+			return keys[i].ItemSKU < keys[j].ItemSKU
+		}
+		return keys[i].Color < keys[j].Color
+	})
+	offcutsByKey := make(map[StockKey][]Offcut)
+	for _, offcut := range offcuts {
+		if offcut.LengthMM > 0 {
+			offcutsByKey[offcut.StockKey()] = append(offcutsByKey[offcut.StockKey()], offcut)
+		}
+	}
+
+	plan := &CuttingPlan{ID: generateID(), Settings: settings, Groups: make([]CuttingGroup, 0, len(keys))}
+	piecesMM, stockMM := 0, 0
+	for _, key := range keys {
+		barLength := barLengths[key]
+		if float64(barLength) <= 2*settings.TrimMM {
+			return nil, fmt.Errorf("el material %s no tiene un largo de barra válido (%d mm)", key, barLength)
+		}
+		groupPieces := byKey[key]
+		packer := &barPacker{
+			barLength: barLength,
+			width:     profileWidths[groupPieces[0].ProfileSKU],
+			settings:  settings,
+			offcuts:   offcutsByKey[key],
+		}
+		group := packer.optimize(groupPieces)
+		group.StockKey = key
+		group.ProfileSKU = groupPieces[0].ProfileSKU
+		group.BarLengthMM = barLength
+
+		plan.Groups = append(plan.Groups, group)
+		plan.NewBars += group.NewBars
+		plan.OffcutsUsed += group.OffcutsUsed
+		piecesMM += group.PiecesMM
+		stockMM += group.StockMM
+	}
+	plan.Yield = yieldPercent(piecesMM, stockMM)
+	return plan, nil
+}
+
+// yieldPercent devuelve used / total en % con dos decimales.
+func yieldPercent(used, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return math.Round(float64(used)/float64(total)*10000) / 100
+}
+
+// barPacker reparte las piezas de un material en barras.
+type barPacker struct {
+	barLength int
+	width     float64
+	settings  OptimizerSettings
+	offcuts   []Offcut
+}
+
+// packedBar es una barra en uso durante el reparto.
+type packedBar struct {
+	CutBar
+	consumed float64 // Largo consumido desde el recorte inicial, con los cortes de sierra
+	trail    float64 // Ángulo del extremo libre de la última pieza (90 si está vacía)
+}
+
+// capacity devuelve el largo aprovechable de la barra. Se suma un corte de sierra porque la última
+// pieza no necesita separarse de la siguiente si agota la barra.
+func (b *packedBar) capacity(trim, kerf float64) float64 {
+	return float64(b.LengthMM) - 2*trim + kerf
+}
+
+// packResult es un reparto completo de las piezas de un material.
+type packResult struct {
+	bars     []*packedBar
+	unplaced []Piece
+}
+
+// optimize busca el mejor reparto según el modo y lo convierte en un CuttingGroup.
+func (p *barPacker) optimize(pieces []Piece) CuttingGroup {
+	sorted := append([]Piece(nil), pieces...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Length > sorted[j].Length })
+
+	best := p.pack(sorted, false)
+	if p.settings.Mode == constants.OPTIMIZER_MODE_SEARCH {
+		if candidate := p.pack(sorted, true); p.better(candidate, best) {
+			best = candidate
+		}
+		deadline := time.Now().Add(p.settings.TimeBudget)
+		random := rand.New(rand.NewSource(1)) // Semilla fija: el mismo proyecto da el mismo plan
+		order := make([]Piece, len(sorted))
+		for iteration := 0; len(sorted) > 1 && time.Now().Before(deadline); iteration++ {
+			copy(order, sorted)
+			swaps := 1 + random.Intn(3)
+			for s := 0; s < swaps; s++ {
+				i, j := random.Intn(len(order)), random.Intn(len(order))
+				order[i], order[j] = order[j], order[i]
+			}
+			if candidate := p.pack(order, iteration%2 == 1); p.better(candidate, best) {
+				best = candidate
+			}
+		}
+	}
+	return p.group(best)
+}
+
+// pack ubica las piezas en el orden dado: en la primera barra en que caben o, con bestFit,
+// en la que deja menos sobrante. Los retazos se ofrecen antes que las barras nuevas.
+func (p *barPacker) pack(order []Piece, bestFit bool) packResult {
+	trim, kerf := p.settings.TrimMM, p.settings.KerfMM
+	offcuts := append([]Offcut(nil), p.offcuts...)
+	sort.SliceStable(offcuts, func(i, j int) bool { return offcuts[i].LengthMM < offcuts[j].LengthMM })
+
+	var result packResult
+	var available []*packedBar
+	for _, offcut := range offcuts {
+		available = append(available, &packedBar{
+			CutBar: CutBar{Source: constants.BAR_SOURCE_OFFCUT, OffcutID: offcut.ID, LengthMM: offcut.LengthMM},
+			trail:  90,
+		})
+	}
+
+	for _, piece := range order {
+		var target *packedBar
+		var targetCost float64
+		var targetFlip bool
+		for _, bar := range available {
+			cost, flip := p.cost(bar, piece)
+			if bar.consumed+cost > bar.capacity(trim, kerf) {
+				continue
+			}
+			if target == nil || (bestFit && bar.capacity(trim, kerf)-bar.consumed-cost < target.capacity(trim, kerf)-target.consumed-targetCost) {
+				target, targetCost, targetFlip = bar, cost, flip
+				if !bestFit {
+					break
+				}
+			}
+		}
+		if target == nil {
+			bar := &packedBar{CutBar: CutBar{Source: constants.BAR_SOURCE_NEW, LengthMM: p.barLength}, trail: 90}
+			cost, flip := p.cost(bar, piece)
+			if cost > bar.capacity(trim, kerf) {
+				result.unplaced = append(result.unplaced, piece)
+				continue
+			}
+			available = append(available, bar)
+			target, targetCost, targetFlip = bar, cost, flip
+		}
+		p.place(target, piece, targetCost, targetFlip)
+	}
+
+	for _, bar := range available {
+		if len(bar.Cuts) > 0 {
+			result.bars = append(result.bars, bar)
+		}
+	}
+	return result
+}
+
+// cost devuelve el largo que consume la pieza en la barra y si conviene girarla para encajar
+// su corte inclinado con el de la pieza anterior.
+func (p *barPacker) cost(bar *packedBar, piece Piece) (float64, bool) {
+	normal := p.nesting(bar.trail, piece.AngleLeft)
+	flipped := p.nesting(bar.trail, piece.AngleRight)
+	base := float64(piece.Length) + p.settings.KerfMM
+	if flipped > normal {
+		return base - flipped, true
+	}
+	return base - normal, false
+}
+
+// nesting devuelve cuánto se solapan dos extremos que se tocan con los ángulos dados.
+func (p *barPacker) nesting(trail, lead float64) float64 {
+	if p.width <= 0 || trail != lead || lead <= 0 || lead >= 90 {
+		return 0
+	}
+	return p.width / math.Tan(lead*math.Pi/180)
+}
+
+// place agrega la pieza al final de la barra.
+func (p *barPacker) place(bar *packedBar, piece Piece, cost float64, flip bool) {
+	start := p.settings.TrimMM + bar.consumed - (float64(piece.Length) + p.settings.KerfMM - cost)
+	bar.Cuts = append(bar.Cuts, BarCut{Piece: piece, OffsetMM: int(math.Round(start)), Flipped: flip})
+	bar.consumed += cost
+	bar.UsedMM += piece.Length
+	bar.trail = piece.AngleRight
+	if flip {
+		bar.trail = piece.AngleLeft
+	}
+}
+
+// remnant devuelve el sobrante físico al final de la barra.
+func (p *barPacker) remnant(bar *packedBar) int {
+	return int(math.Max(0, math.Round(float64(bar.LengthMM)-p.settings.TrimMM-bar.consumed)))
+}
+
+// better indica si el reparto a mejora al b: menos piezas sin ubicar, menos barras nuevas y,
+// a igualdad, sobrantes más concentrados (mayor suma de cuadrados), que dejan retazos útiles.
+func (p *barPacker) better(a, b packResult) bool {
+	if len(a.unplaced) != len(b.unplaced) {
+		return len(a.unplaced) < len(b.unplaced)
+	}
+	newA, newB := countNewBars(a.bars), countNewBars(b.bars)
+	if newA != newB {
+		return newA < newB
+	}
+	return p.remnantScore(a) > p.remnantScore(b)
+}
+
+// remnantScore suma los cuadrados de los sobrantes.
+func (p *barPacker) remnantScore(result packResult) float64 {
+	score := 0.0
+	for _, bar := range result.bars {
+		r := float64(p.remnant(bar))
+		score += r * r
+	}
+	return score
+}
+
+// countNewBars cuenta las barras nuevas de un reparto.
+func countNewBars(bars []*packedBar) int {
+	n := 0
+	for _, bar := range bars {
+		if bar.Source == constants.BAR_SOURCE_NEW {
+			n++
+		}
+	}
+	return n
+}
+
+// group convierte un reparto en el CuttingGroup informado: retazos primero y luego barras nuevas.
+func (p *barPacker) group(result packResult) CuttingGroup {
+	group := CuttingGroup{Unplaced: result.unplaced}
+	bars := result.bars
+	sort.SliceStable(bars, func(i, j int) bool {
+		return bars[i].Source == constants.BAR_SOURCE_OFFCUT && bars[j].Source != constants.BAR_SOURCE_OFFCUT
+	})
+	for _, bar := range bars {
+		bar.OffcutMM = p.remnant(bar)
+		group.Bars = append(group.Bars, bar.CutBar)
+		if bar.Source == constants.BAR_SOURCE_NEW {
+			group.NewBars++
+		} else {
+			group.OffcutsUsed++
+		}
+		group.PiecesMM += bar.UsedMM
+		group.StockMM += bar.LengthMM
+	}
+	group.Yield = yieldPercent(group.PiecesMM, group.StockMM)
+	return group
+}
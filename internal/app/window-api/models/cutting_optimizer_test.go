@@ -0,0 +1,297 @@
+package models
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/mvialf/windraw/internal/pkg/constants"
+)
+
+// testKey es el material de las piezas de prueba.
+var testKey = StockKey{ItemSKU: "PF-60-BL", Color: "Blanco"}
+
+// testPiece crea una pieza del material de prueba con los ángulos de sus extremos.
+func testPiece(code string, length int, left, right float64) Piece {
+	return Piece{Code: code, ProfileSKU: "PF-60", ItemSKU: testKey.ItemSKU, Color: testKey.Color,
+		Length: length, AngleLeft: left, AngleRight: right}
+}
+
+// squarePieces crea piezas de corte recto con los largos dados.
+func squarePieces(lengths ...int) []Piece {
+	pieces := make([]Piece, len(lengths))
+	for i, length := range lengths {
+		pieces[i] = testPiece(fmt.Sprintf("P%02d", i+1), length, 90, 90)
+	}
+	return pieces
+}
+
+// wantBar es el resultado esperado de una barra del plan.
+type wantBar struct {
+	source   string
+	offcutID string
+	offsets  []int
+	flipped  []bool // nil = ninguna pieza girada
+	offcutMM int
+}
+
+func TestOptimizeCuts(t *testing.T) {
+	tests := []struct {
+		name        string
+		pieces      []Piece
+		barLength   int
+		width       float64
+		offcuts     []Offcut
+		settings    OptimizerSettings
+		bars        []wantBar
+		newBars     int
+		offcutsUsed int
+		yield       float64
+		unplaced    []string
+	}{
+		{
+			// Capacidad 6000 - 2×10 + 4 = 5984: tres piezas de 1990 + 4 consumen 5982.
+			name:      "recorte y corte de sierra, la última pieza no necesita corte",
+			pieces:    squarePieces(1990, 1990, 1990),
+			barLength: 6000,
+			settings:  OptimizerSettings{Mode: constants.OPTIMIZER_MODE_FIRST_FIT, KerfMM: 4, TrimMM: 10},
+			bars: []wantBar{
+				{source: constants.BAR_SOURCE_NEW, offsets: []int{10, 2004, 3998}, offcutMM: 8},
+			},
+			newBars: 1,
+			yield:   99.5,
+		},
+		{
+			// Tres piezas de 1991 + 4 consumen 5985 > 5984: la tercera abre otra barra.
+			name:      "un milímetro más no cabe",
+			pieces:    squarePieces(1991, 1991, 1991),
+			barLength: 6000,
+			settings:  OptimizerSettings{Mode: constants.OPTIMIZER_MODE_FIRST_FIT, KerfMM: 4, TrimMM: 10},
+			bars: []wantBar{
+				{source: constants.BAR_SOURCE_NEW, offsets: []int{10, 2005}, offcutMM: 2000},
+				{source: constants.BAR_SOURCE_NEW, offsets: []int{10}, offcutMM: 3995},
+			},
+			newBars: 2,
+			yield:   49.78,
+		},
+		{
+			name:      "pieza más larga que la barra",
+			pieces:    squarePieces(6100, 1000),
+			barLength: 6000,
+			settings:  OptimizerSettings{Mode: constants.OPTIMIZER_MODE_FIRST_FIT},
+			bars: []wantBar{
+				{source: constants.BAR_SOURCE_NEW, offsets: []int{0}, offcutMM: 5000},
+			},
+			newBars:  1,
+			yield:    16.67,
+			unplaced: []string{"P01"},
+		},
+		{
+			// Ambas piezas a 45° en los extremos que se tocan: la segunda se solapa W / tan(45°) = 60 mm.
+			name:      "par a inglete que encaja",
+			pieces:    []Piece{testPiece("P01", 1000, 45, 45), testPiece("P02", 900, 45, 45)},
+			barLength: 3000,
+			width:     60,
+			settings:  OptimizerSettings{Mode: constants.OPTIMIZER_MODE_FIRST_FIT, KerfMM: 4},
+			bars: []wantBar{
+				{source: constants.BAR_SOURCE_NEW, offsets: []int{0, 944}, offcutMM: 1152},
+			},
+			newBars: 1,
+			yield:   63.33,
+		},
+		{
+			// La segunda pieza solo encaja con su extremo derecho (45°) contra el de la primera: se gira.
+			name:      "pieza girada para encajar el inglete",
+			pieces:    []Piece{testPiece("P01", 1000, 90, 45), testPiece("P02", 800, 90, 45)},
+			barLength: 3000,
+			width:     60,
+			settings:  OptimizerSettings{Mode: constants.OPTIMIZER_MODE_FIRST_FIT, KerfMM: 4},
+			bars: []wantBar{
+				{source: constants.BAR_SOURCE_NEW, offsets: []int{0, 944}, flipped: []bool{false, true}, offcutMM: 1252},
+			},
+			newBars: 1,
+			yield:   60,
+		},
+		{
+			name:      "sin ancho de perfil no hay encaje",
+			pieces:    []Piece{testPiece("P01", 1000, 45, 45), testPiece("P02", 900, 45, 45)},
+			barLength: 3000,
+			settings:  OptimizerSettings{Mode: constants.OPTIMIZER_MODE_FIRST_FIT, KerfMM: 4},
+			bars: []wantBar{
+				{source: constants.BAR_SOURCE_NEW, offsets: []int{0, 1004}, offcutMM: 1092},
+			},
+			newBars: 1,
+			yield:   63.33,
+		},
+		{
+			// Los retazos se prueban de menor a mayor y antes que las barras nuevas; el de otro
+			// material no se usa.
+			name:      "retazos antes que barras nuevas",
+			pieces:    squarePieces(1200, 700, 500),
+			barLength: 6000,
+			offcuts: []Offcut{
+				{ID: "R2", ItemSKU: testKey.ItemSKU, Color: testKey.Color, LengthMM: 1500},
+				{ID: "R1", ItemSKU: testKey.ItemSKU, Color: testKey.Color, LengthMM: 800},
+				{ID: "R3", ItemSKU: "OTRO", LengthMM: 3000},
+			},
+			settings: OptimizerSettings{Mode: constants.OPTIMIZER_MODE_FIRST_FIT},
+			bars: []wantBar{
+				{source: constants.BAR_SOURCE_OFFCUT, offcutID: "R1", offsets: []int{0}, offcutMM: 100},
+				{source: constants.BAR_SOURCE_OFFCUT, offcutID: "R2", offsets: []int{0}, offcutMM: 300},
+				{source: constants.BAR_SOURCE_NEW, offsets: []int{0}, offcutMM: 5500},
+			},
+			newBars:     1,
+			offcutsUsed: 2,
+			yield:       28.92,
+		},
+		{
+			// De mayor a menor, el primer ajuste deja 500+400 | 300+300+300 | 200 (tres barras).
+			name:      "primer ajuste",
+			pieces:    squarePieces(500, 400, 300, 300, 300, 200),
+			barLength: 1000,
+			settings:  OptimizerSettings{Mode: constants.OPTIMIZER_MODE_FIRST_FIT},
+			bars: []wantBar{
+				{source: constants.BAR_SOURCE_NEW, offsets: []int{0, 500}, offcutMM: 100},
+				{source: constants.BAR_SOURCE_NEW, offsets: []int{0, 300, 600}, offcutMM: 100},
+				{source: constants.BAR_SOURCE_NEW, offsets: []int{0}, offcutMM: 800},
+			},
+			newBars: 3,
+			yield:   66.67,
+		},
+		{
+			// La búsqueda encuentra 500+300+200 | 400+300+300, sin sobrantes.
+			name:      "búsqueda mejora el primer ajuste",
+			pieces:    squarePieces(500, 400, 300, 300, 300, 200),
+			barLength: 1000,
+			settings:  OptimizerSettings{Mode: constants.OPTIMIZER_MODE_SEARCH, TimeBudget: 200 * time.Millisecond},
+			newBars:   2,
+			yield:     100,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plan, err := OptimizeCuts(tt.pieces, map[StockKey]int{testKey: tt.barLength},
+				map[string]float64{"PF-60": tt.width}, tt.offcuts, tt.settings)
+			if err != nil {
+				t.Fatalf("OptimizeCuts: %v", err)
+			}
+			if len(plan.Groups) != 1 {
+				t.Fatalf("grupos = %d, se esperaba 1", len(plan.Groups))
+			}
+			group := plan.Groups[0]
+			if group.NewBars != tt.newBars || group.OffcutsUsed != tt.offcutsUsed {
+				t.Errorf("barras nuevas = %d, retazos = %d; se esperaba %d y %d", group.NewBars, group.OffcutsUsed, tt.newBars, tt.offcutsUsed)
+			}
+			if group.Yield != tt.yield || plan.Yield != tt.yield {
+				t.Errorf("aprovechamiento = %v (plan %v), se esperaba %v", group.Yield, plan.Yield, tt.yield)
+			}
+			var unplaced []string
+			for _, piece := range group.Unplaced {
+				unplaced = append(unplaced, piece.Code)
+			}
+			if fmt.Sprint(unplaced) != fmt.Sprint(tt.unplaced) {
+				t.Errorf("sin ubicar = %v, se esperaba %v", unplaced, tt.unplaced)
+			}
+			if tt.bars == nil {
+				return
+			}
+			if len(group.Bars) != len(tt.bars) {
+				t.Fatalf("barras = %d, se esperaba %d", len(group.Bars), len(tt.bars))
+			}
+			for i, want := range tt.bars {
+				bar := group.Bars[i]
+				var offsets []int
+				var flipped []bool
+				anyFlipped := false
+				for _, cut := range bar.Cuts {
+					offsets = append(offsets, cut.OffsetMM)
+					flipped = append(flipped, cut.Flipped)
+					anyFlipped = anyFlipped || cut.Flipped
+				}
+				if !anyFlipped {
+					flipped = nil
+				}
+				if bar.Source != want.source || bar.OffcutID != want.offcutID {
+					t.Errorf("barra %d: origen %s/%s, se esperaba %s/%s", i+1, bar.Source, bar.OffcutID, want.source, want.offcutID)
+				}
+				if fmt.Sprint(offsets) != fmt.Sprint(want.offsets) {
+					t.Errorf("barra %d: offsets %v, se esperaba %v", i+1, offsets, want.offsets)
+				}
+				if fmt.Sprint(flipped) != fmt.Sprint(want.flipped) {
+					t.Errorf("barra %d: girada %v, se esperaba %v", i+1, flipped, want.flipped)
+				}
+				if bar.OffcutMM != want.offcutMM {
+					t.Errorf("barra %d: sobrante %d mm, se esperaba %d", i+1, bar.OffcutMM, want.offcutMM)
+				}
+			}
+		})
+	}
+}
+
+func TestOptimizeCutsInvalid(t *testing.T) {
+	tests := []struct {
+		name      string
+		barLength int
+		settings  OptimizerSettings
+	}{
+		{"modo desconocido", 6000, OptimizerSettings{Mode: "otro"}},
+		{"corte negativo", 6000, OptimizerSettings{Mode: constants.OPTIMIZER_MODE_FIRST_FIT, KerfMM: -1}},
+		{"recorte mayor que la barra", 100, OptimizerSettings{Mode: constants.OPTIMIZER_MODE_FIRST_FIT, TrimMM: 50}},
+		{"sin largo de barra", 0, OptimizerSettings{Mode: constants.OPTIMIZER_MODE_FIRST_FIT}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := OptimizeCuts(squarePieces(50), map[StockKey]int{testKey: tt.barLength}, nil, nil, tt.settings)
+			if err == nil {
+				t.Fatal("se esperaba un error")
+			}
+		})
+	}
+}
+
+// TestBarPackerBetter comprueba el orden de preferencia entre repartos.
+func TestBarPackerBetter(t *testing.T) {
+	bar := func(source string, consumed float64) *packedBar {
+		return &packedBar{CutBar: CutBar{Source: source, LengthMM: 1000}, consumed: consumed}
+	}
+	p := &barPacker{barLength: 1000}
+	tests := []struct {
+		name string
+		a, b packResult
+		want bool
+	}{
+		{
+			name: "menos piezas sin ubicar",
+			a:    packResult{bars: []*packedBar{bar(constants.BAR_SOURCE_NEW, 500), bar(constants.BAR_SOURCE_NEW, 500)}},
+			b:    packResult{bars: []*packedBar{bar(constants.BAR_SOURCE_NEW, 500)}, unplaced: squarePieces(2000)},
+			want: true,
+		},
+		{
+			name: "menos barras nuevas",
+			a:    packResult{bars: []*packedBar{bar(constants.BAR_SOURCE_OFFCUT, 500), bar(constants.BAR_SOURCE_NEW, 500)}},
+			b:    packResult{bars: []*packedBar{bar(constants.BAR_SOURCE_NEW, 500), bar(constants.BAR_SOURCE_NEW, 500)}},
+			want: true,
+		},
+		{
+			// Sobrantes 1000 y 0 dejan un retazo útil; 500 y 500, dos cortos.
+			name: "sobrantes concentrados",
+			a:    packResult{bars: []*packedBar{bar(constants.BAR_SOURCE_NEW, 1000), bar(constants.BAR_SOURCE_NEW, 0)}},
+			b:    packResult{bars: []*packedBar{bar(constants.BAR_SOURCE_NEW, 500), bar(constants.BAR_SOURCE_NEW, 500)}},
+			want: true,
+		},
+		{
+			name: "repartos equivalentes",
+			a:    packResult{bars: []*packedBar{bar(constants.BAR_SOURCE_NEW, 500)}},
+			b:    packResult{bars: []*packedBar{bar(constants.BAR_SOURCE_NEW, 500)}},
+			want: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := p.better(tt.a, tt.b); got != tt.want {
+				t.Errorf("better = %v, se esperaba %v", got, tt.want)
+			}
+		})
+	}
+}
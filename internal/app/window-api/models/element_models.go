@@ -3,7 +3,8 @@ package models
 import (
 	"errors"
 	"fmt"
-	// "github.com/mvialf/windraw/internal/pkg/constants" // Descomentar cuando constants.go exista y sea necesario
+
+	"github.com/mvialf/windraw/internal/pkg/constants"
 )
 
 // FrameDetail describe una pieza individual de perfil para un marco.
@@ -16,6 +17,13 @@ type FrameDetail struct {
 	AngleRight     float64 `json:"angle_right"`              // Ángulo de corte derecho en grados
 	ReinforcedUsed bool    `json:"reinforced_used"`          // Indica si se utiliza refuerzo
 	ReinforcedSKU  string  `json:"reinforced_sku,omitempty"` // SKU del refuerzo, si se utiliza
+	ProfileID      int64   `json:"profile_id,omitempty"`     // profile_id del perfil en el catálogo
+	ItemSKU        string  `json:"item_sku,omitempty"`       // SKU del ítem de stock (perfil + color)
+	ColorID        int64   `json:"color_id,omitempty"`       // color_id del color en el catálogo
+
+	ReinforcedProfileID int64 `json:"reinforced_profile_id,omitempty"` // profile_id del refuerzo
+	ReinforcedLength    int   `json:"reinforced_length,omitempty"`     // Largo de corte del refuerzo en mm
+	BendRadius          int   `json:"bend_radius,omitempty"`           // Radio exterior de curvado en mm (piezas de arco)
 }
 
 // WindDetail describe una pieza individual de perfil para una hoja.
@@ -28,19 +36,26 @@ type WindDetail struct {
 	AngleRight     float64 `json:"angle_right"`              // Ángulo de corte derecho
 	ReinforcedUsed bool    `json:"reinforced_used"`          // Si usa refuerzo
 	ReinforcedSKU  string  `json:"reinforced_sku,omitempty"` // SKU del refuerzo
+	ProfileID      int64   `json:"profile_id,omitempty"`     // profile_id del perfil en el catálogo
+	ItemSKU        string  `json:"item_sku,omitempty"`       // SKU del ítem de stock (perfil + color)
+	ColorID        int64   `json:"color_id,omitempty"`       // color_id del color en el catálogo
+
+	ReinforcedProfileID int64 `json:"reinforced_profile_id,omitempty"` // profile_id del refuerzo
+	ReinforcedLength    int   `json:"reinforced_length,omitempty"`     // Largo de corte del refuerzo en mm
 }
 
 // Frame representa el marco perimetral de un Element.
 type Frame struct {
-	Name      string                 `json:"name"`      // Nombre del marco (ej. "Marco Principal")
-	Inverted  bool                   `json:"inverted"`  // Si el marco está invertido (puede afectar cálculos de descuento)
-	Geometry  string                 `json:"geometry"`  // Geometría del marco (ej. "Rectangular", "Trapezoidal")
-	Width     int                    `json:"width"`     // Ancho exterior del marco en mm
-	Height    int                    `json:"height"`    // Alto exterior del marco en mm
-	Area      float64                `json:"area"`      // Área calculada del marco en m²
-	Perimeter float64                `json:"perimeter"` // Perímetro calculado del marco en m
-	CutType   string                 `json:"cut_type"`  // Tipo de corte para los perfiles del marco (ej. "Ángulo", "Cuadrado")
-	Details   map[string]FrameDetail `json:"details"`   // Mapa de detalles de perfiles por posición
+	Name      string                 `json:"name"`            // Nombre del marco (ej. "Marco Principal")
+	Inverted  bool                   `json:"inverted"`        // Si el marco está invertido (puede afectar cálculos de descuento)
+	Geometry  string                 `json:"geometry"`        // Geometría del marco (ej. "Rectangular", "Trapezoidal")
+	Shape     *FrameShape            `json:"shape,omitempty"` // Medidas adicionales de las geometrías no rectangulares
+	Width     int                    `json:"width"`           // Ancho exterior del marco en mm
+	Height    int                    `json:"height"`          // Alto exterior del marco en mm
+	Area      float64                `json:"area"`            // Área calculada del marco en m²
+	Perimeter float64                `json:"perimeter"`       // Perímetro calculado del marco en m
+	CutType   string                 `json:"cut_type"`        // Tipo de corte para los perfiles del marco (ej. "Ángulo", "Cuadrado")
+	Details   map[string]FrameDetail `json:"details"`         // Mapa de detalles de perfiles por posición
 }
 
 // Wind representa una hoja (panel móvil o fijo) dentro de un Element.
@@ -56,6 +71,8 @@ type Wind struct {
 	Area             float64               `json:"area"`                        // Área calculada de la hoja en m²
 	Perimeter        float64               `json:"perimeter"`                   // Perímetro calculado de la hoja en m
 	CutType          string                `json:"cut_type"`                    // Tipo de corte para los perfiles de la hoja
+	CustomLengths    map[string]int        `json:"custom_lengths,omitempty"`    // Largos nominales por posición con constants.CUT_CUSTOM_WIND
+	Track            int                   `json:"track,omitempty"`             // Riel de una hoja corredera (1 = exterior)
 	Details          map[string]WindDetail `json:"details"`                     // Mapa de detalles de perfiles por posición
 }
 
@@ -71,6 +88,8 @@ type Element struct {
 	Perimeter  float64                `json:"perimeter"`            // Perímetro calculado del elemento en m
 	Frame      Frame                  `json:"frame"`                // El marco del elemento
 	Winds      []Wind                 `json:"winds,omitempty"`      // Lista de hojas dentro del elemento
+	Layout     *Field                 `json:"layout,omitempty"`     // División de la luz en campos (montantes y travesaños)
+	Glasses    []GlassPane            `json:"glasses,omitempty"`    // Paños de vidrio calculados con CalculateGlass
 	Properties map[string]interface{} `json:"properties,omitempty"` // Propiedades adicionales (ej. color vidrio, tipo manilla)
 }
 
@@ -120,6 +139,13 @@ func NewFrame(width, height int, geometry, cutType string, positions []string, d
 		Details:   details,
 		Inverted:  false,
 	}
+	// Las geometrías no rectangulares reemplazan las posiciones por los lados de su contorno.
+	// Las que necesitan medidas adicionales (ej. trapecio) deben aplicarse después con SetShape.
+	if !frame.IsRectangular() {
+		if err := frame.SetShape(geometry, FrameShape{}); err != nil {
+			return nil, err
+		}
+	}
 	return frame, nil
 }
 
@@ -193,8 +219,9 @@ func NewElement(width int, height int, material string, elementType string, stru
 		return nil, fmt.Errorf("error al crear el marco interno para el elemento: %w", err)
 	}
 
-	area := float64(width) * float64(height) / 1000000.0
-	perimeter := 2.0 * (float64(width) + float64(height)) / 1000.0
+	// El área y el perímetro siguen el contorno del marco (w*h solo en los rectangulares)
+	area := frame.Area
+	perimeter := frame.Perimeter
 
 	// Asumiendo que generateID() está en el mismo paquete 'models'
 	elementID := generateID()
@@ -242,54 +269,67 @@ func (f *Frame) SetFrameProfile(position string, profileSKU string, color string
 	return nil
 }
 
-// CalculateFrameDetails calcula las dimensiones y ángulos de corte para los perfiles del marco.
-func (f *Frame) CalculateFrameDetails(anchoPerfilEjemplo float64,
-	positionLeft, positionRight, positionTop, positionBottom,
-	cutTypeAngle, cutTypeSquare string) error { // Estos strings deberían ser constantes
-
-	calculatedDetails := make(map[string]FrameDetail)
-	for pos, detail := range f.Details {
-		if detail.ProfileSKU == "" {
-			calculatedDetails[pos] = detail
-			continue
-		}
+// SetFrameStockItem asigna a una posición del marco el perfil del catálogo y el ítem de stock
+// (perfil + color) que se cortará. colorName es el nombre legible del color.
+func (f *Frame) SetFrameStockItem(position string, profile Profile, item StockItem, colorName string) error {
+	if err := f.SetFrameProfile(position, profile.SKU, colorName); err != nil {
+		return err
+	}
+	detail := f.Details[position]
+	detail.ProfileID = profile.ID
+	detail.ItemSKU = item.ItemSKU
+	detail.ColorID = item.ColorID
+	f.Details[position] = detail
+	return nil
+}
 
-		calculatedDim := 0
-		calculatedAngleL := 0.0
-		calculatedAngleR := 0.0
-		needsReinforcement := false
-		reinforcementSKU := ""
-
-		if f.CutType == cutTypeAngle {
-			descuento := int(anchoPerfilEjemplo)
-			if pos == positionLeft || pos == positionRight {
-				calculatedDim = f.Height - descuento
-			} else if pos == positionTop || pos == positionBottom {
-				calculatedDim = f.Width - descuento
-			}
-			calculatedAngleL = 45.0
-			calculatedAngleR = 45.0
-		} else if f.CutType == cutTypeSquare {
-			if pos == positionLeft || pos == positionRight {
-				calculatedDim = f.Height
-			} else if pos == positionTop || pos == positionBottom {
-				calculatedDim = f.Width
-			}
-			calculatedAngleL = 90.0
-			calculatedAngleR = 90.0
-		}
+// CalculateFrameDetails calcula el largo y los ángulos de corte de cada perfil del marco con el motor de corte
+// (rectangular o, si la geometría no lo es, siguiendo el contorno del marco).
+// profiles asocia cada SKU asignado a un perfil del catálogo (geometría, cut_margin_mm y weld_margin);
+// weldMarginMM es el margen de soldadura por extremo (ej. constants.WELD_MARGIN_MM).
+// Las posiciones sin perfil asignado se dejan sin calcular. Los datos de refuerzo se limpian:
+// se vuelven a decidir con ApplyReinforcement.
+func (f *Frame) CalculateFrameDetails(profiles map[string]Profile, weldMarginMM float64) error {
+	byPosition, err := profilesByPosition(frameDetailSKUs(f.Details), profiles)
+	if err != nil {
+		return fmt.Errorf("marco: %w", err)
+	}
+	pieces, err := f.cuts(byPosition, weldMarginMM)
+	if err != nil {
+		return fmt.Errorf("marco: %w", err)
+	}
 
-		detail.Dimension = calculatedDim
-		detail.AngleLeft = calculatedAngleL
-		detail.AngleRight = calculatedAngleR
-		detail.ReinforcedUsed = needsReinforcement
-		detail.ReinforcedSKU = reinforcementSKU
-		calculatedDetails[pos] = detail
+	for pos, piece := range pieces {
+		detail := f.Details[pos]
+		detail.Dimension = piece.Length
+		detail.AngleLeft = piece.AngleLeft
+		detail.AngleRight = piece.AngleRight
+		detail.BendRadius = piece.BendRadius
+		detail.ReinforcedUsed = false
+		detail.ReinforcedSKU = ""
+		detail.ReinforcedProfileID = 0
+		detail.ReinforcedLength = 0
+		f.Details[pos] = detail
 	}
-	f.Details = calculatedDetails
 	return nil
 }
 
+// cuts elige el motor de corte según la geometría: los marcos no rectangulares se cortan a inglete
+// siguiendo su contorno (ver FrameOutline.outlineCuts) y solo admiten constants.CUT_ANGLE.
+func (f *Frame) cuts(byPosition map[string]Profile, weldMarginMM float64) (map[string]CutPiece, error) {
+	if f.IsRectangular() {
+		return rectangularCuts(f.Width, f.Height, f.CutType, byPosition, nil, nil, weldMarginMM)
+	}
+	if f.CutType != constants.CUT_ANGLE {
+		return nil, cutErrorf("el marco '%s' solo admite corte '%s', se indicó '%s'", f.Geometry, constants.CUT_ANGLE, f.CutType)
+	}
+	outline, err := f.Outline()
+	if err != nil {
+		return nil, cutErrorf("%v", err)
+	}
+	return outline.outlineCuts(byPosition, weldMarginMM)
+}
+
 // SetWindProfile establece el SKU del perfil y el color para una posición específica de la hoja.
 func (w *Wind) SetWindProfile(position string, profileSKU string, color string) error {
 	detail, ok := w.Details[position]
@@ -305,61 +345,85 @@ func (w *Wind) SetWindProfile(position string, profileSKU string, color string)
 	return nil
 }
 
-// CalculateWindDetails calcula las dimensiones y ángulos de corte para los perfiles de la hoja.
-func (w *Wind) CalculateWindDetails(anchoPerfilHojaEjemplo float64,
-	positionLeft, positionRight, positionTop, positionBottom,
-	cutAngleWind, cutSquareWind, cutVerticalOverlapWind string) error { // Estos strings deberían ser constantes
+// SetWindStockItem asigna a una posición de la hoja el perfil del catálogo y el ítem de stock
+// (perfil + color) que se cortará. colorName es el nombre legible del color.
+func (w *Wind) SetWindStockItem(position string, profile Profile, item StockItem, colorName string) error {
+	if err := w.SetWindProfile(position, profile.SKU, colorName); err != nil {
+		return err
+	}
+	detail := w.Details[position]
+	detail.ProfileID = profile.ID
+	detail.ItemSKU = item.ItemSKU
+	detail.ColorID = item.ColorID
+	w.Details[position] = detail
+	return nil
+}
 
-	calculatedDetails := make(map[string]WindDetail)
+// CalculateWindDetails calcula el largo y los ángulos de corte de cada perfil de la hoja con el motor de corte.
+// Igual que CalculateFrameDetails; con CUT_CUSTOM_WIND los largos nominales salen de CustomLengths
+// y los ángulos de los que ya tenga cada detalle (90° si están en cero). Las piezas de traslapo
+// (constants.POSITION_OVERLAP_*) se cortan rectas al alto de la hoja.
+func (w *Wind) CalculateWindDetails(profiles map[string]Profile, weldMarginMM float64) error {
+	skus := make(map[string]string, len(w.Details))
+	angles := make(map[string][2]float64, len(w.Details))
 	for pos, detail := range w.Details {
-		if detail.ProfileSKU == "" {
-			calculatedDetails[pos] = detail
-			continue
-		}
-
-		calculatedDim := 0
-		calculatedAngleL := 0.0
-		calculatedAngleR := 0.0
-		needsReinforcement := false
-		reinforcementSKU := ""
-
-		if w.CutType == cutAngleWind {
-			descuento := int(anchoPerfilHojaEjemplo)
-			if pos == positionLeft || pos == positionRight {
-				calculatedDim = w.Height - descuento
-			} else if pos == positionTop || pos == positionBottom {
-				calculatedDim = w.Width - descuento
-			}
-			calculatedAngleL = 45.0
-			calculatedAngleR = 45.0
-		} else if w.CutType == cutSquareWind {
-			if pos == positionLeft || pos == positionRight {
-				calculatedDim = w.Height
-			} else if pos == positionTop || pos == positionBottom {
-				calculatedDim = w.Width
-			}
-			calculatedAngleL = 90.0
-			calculatedAngleR = 90.0
-		} else if w.CutType == cutVerticalOverlapWind {
-			if pos == positionLeft || pos == positionRight {
-				calculatedDim = w.Height
-				calculatedAngleL = 90.0
-				calculatedAngleR = 90.0
-			} else if pos == positionTop || pos == positionBottom {
-				descuento := int(anchoPerfilHojaEjemplo)
-				calculatedDim = w.Width - descuento
-				calculatedAngleL = 45.0
-				calculatedAngleR = 45.0
-			}
+		skus[pos] = detail.ProfileSKU
+		angles[pos] = [2]float64{detail.AngleLeft, detail.AngleRight}
+	}
+	byPosition, err := profilesByPosition(skus, profiles)
+	if err != nil {
+		return fmt.Errorf("hoja '%s': %w", w.Name, err)
+	}
+	interlocks := make(map[string]Profile)
+	for _, pos := range []string{constants.POSITION_OVERLAP_LEFT, constants.POSITION_OVERLAP_RIGHT} {
+		if profile, ok := byPosition[pos]; ok {
+			interlocks[pos] = profile
+			delete(byPosition, pos)
 		}
+	}
+	pieces, err := rectangularCuts(w.Width, w.Height, w.CutType, byPosition, w.CustomLengths, angles, weldMarginMM)
+	if err != nil {
+		return fmt.Errorf("hoja '%s': %w", w.Name, err)
+	}
+	for pos, profile := range interlocks {
+		pieces[pos] = interlockCut(w.Height, profile)
+	}
 
-		detail.Dimension = calculatedDim
-		detail.AngleLeft = calculatedAngleL
-		detail.AngleRight = calculatedAngleR
-		detail.ReinforcedUsed = needsReinforcement
-		detail.ReinforcedSKU = reinforcementSKU
-		calculatedDetails[pos] = detail
+	for pos, piece := range pieces {
+		detail := w.Details[pos]
+		detail.Dimension = piece.Length
+		detail.AngleLeft = piece.AngleLeft
+		detail.AngleRight = piece.AngleRight
+		detail.ReinforcedUsed = false
+		detail.ReinforcedSKU = ""
+		detail.ReinforcedProfileID = 0
+		detail.ReinforcedLength = 0
+		w.Details[pos] = detail
 	}
-	w.Details = calculatedDetails
 	return nil
 }
+
+// frameDetailSKUs devuelve el SKU asignado a cada posición del marco.
+func frameDetailSKUs(details map[string]FrameDetail) map[string]string {
+	skus := make(map[string]string, len(details))
+	for pos, detail := range details {
+		skus[pos] = detail.ProfileSKU
+	}
+	return skus
+}
+
+// profilesByPosition resuelve el perfil del catálogo de cada posición con SKU asignado.
+func profilesByPosition(skus map[string]string, profiles map[string]Profile) (map[string]Profile, error) {
+	byPosition := make(map[string]Profile, len(skus))
+	for pos, sku := range skus {
+		if sku == "" {
+			continue
+		}
+		profile, ok := profiles[sku]
+		if !ok {
+			return nil, cutErrorf("perfil '%s' de la posición '%s' no encontrado en el catálogo", sku, pos)
+		}
+		byPosition[pos] = profile
+	}
+	return byPosition, nil
+}
@@ -0,0 +1,341 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/mvialf/windraw/internal/pkg/constants"
+)
+
+// Field es un campo de la luz de un Element. Un campo se divide con Split en campos hijos
+// o, si es final, se rellena con vidrio o con una hoja (Fill).
+//
+// Las coordenadas (X, Y, Width, Height) se calculan con SetLayout: son la luz libre del campo
+// en mm, con origen en la esquina inferior izquierda exterior del marco, X hacia la derecha e Y hacia arriba.
+type Field struct {
+	ID     string        `json:"id"`
+	X      int           `json:"x"`
+	Y      int           `json:"y"`
+	Width  int           `json:"width"`
+	Height int           `json:"height"`
+	Split  *FieldSplit   `json:"split,omitempty"`
+	Fill   string        `json:"fill,omitempty"`    // constants.FIELD_FILL_*; solo en campos finales
+	Sash   *CasementSash `json:"sash,omitempty"`    // Hoja pedida cuando Fill es constants.FIELD_FILL_SASH
+	WindID string        `json:"wind_id,omitempty"` // ID de la hoja generada en Element.Winds
+	Glass  *GlassSpec    `json:"glass,omitempty"`   // Vidrio del campo y sus descendientes; reemplaza al del elemento
+}
+
+// FieldSplit divide un campo en hijos con montantes (SPLIT_VERTICAL, hijos de izquierda a derecha)
+// o travesaños (SPLIT_HORIZONTAL, hijos de abajo hacia arriba).
+// Las posiciones se dan con Offsets (distancia en mm desde el borde izquierdo o inferior del campo
+// al eje de cada montante, uno menos que hijos) o con Proportions (tamaño relativo de la luz de
+// cada hijo, descontado el ancho de los montantes).
+// Sin ninguna de las dos, los hijos se reparten a partes iguales.
+type FieldSplit struct {
+	Orientation string    `json:"orientation"`
+	Offsets     []int     `json:"offsets,omitempty"`
+	Proportions []float64 `json:"proportions,omitempty"`
+	Children    []Field   `json:"children"`
+
+	// Calculados por SetLayout
+	Axes     []int         `json:"axes,omitempty"`     // Coordenada absoluta del eje de cada montante
+	Mullions []FrameDetail `json:"mullions,omitempty"` // Pieza de cada montante o travesaño
+}
+
+// LayoutProfiles son los perfiles necesarios para dividir un elemento en campos.
+type LayoutProfiles struct {
+	Casement CasementProfiles // Marco y hojas de abatir (para los campos con hoja)
+	Mullion  *Profile         // constants.PROFILE_TYPE_MULLION; obligatorio si hay divisiones
+}
+
+// SetLayout divide la luz del elemento según root, calcula la posición de cada campo, corta los
+// montantes y genera una hoja por cada campo con hoja, reemplazando Element.Winds.
+//
+// La luz del marco es su medida exterior menos el ancho W del perfil de marco en cada lado.
+// Cada montante se centra en su eje y los campos contiguos descuentan W/2 del montante.
+// El largo de corte del montante es la luz del campo que atraviesa (que ya descuenta el ancho
+// del marco o del montante contra el que topa) más el acople CouplingMM en cada extremo y
+// cut_margin_mm, recto en ambos extremos.
+func (e *Element) SetLayout(root Field, profiles LayoutProfiles) error {
+	if err := e.Frame.requireRectangular("dividir en campos"); err != nil {
+		return err
+	}
+	frameW := profiles.Casement.Frame.W
+	lightWidth := float64(e.Frame.Width) - 2*frameW
+	lightHeight := float64(e.Frame.Height) - 2*frameW
+	if lightWidth <= 0 || lightHeight <= 0 {
+		return fmt.Errorf("el marco de %dx%d mm no deja luz con el perfil %s (W=%.1f mm)",
+			e.Frame.Width, e.Frame.Height, profiles.Casement.Frame.SKU, frameW)
+	}
+
+	builder := &layoutBuilder{element: e, profiles: profiles}
+	if err := builder.place(&root, frameW, frameW, lightWidth, lightHeight); err != nil {
+		return err
+	}
+	if err := e.Frame.assignMissingProfile(profiles.Casement.Frame); err != nil {
+		return err
+	}
+	e.Layout = &root
+	e.Winds = builder.winds
+	return nil
+}
+
+// CalculateMullionDetails vuelve a medir la división del elemento con los perfiles actuales del
+// catálogo (indexados por SKU), como SetLayout: recalcula la posición de los campos y el largo de
+// corte de cada montante y travesaño. A diferencia de SetLayout no genera hojas: cada montante
+// conserva su perfil, su ítem de stock y su color, y cada campo su hoja. Los datos de refuerzo se
+// limpian como en CalculateFrameDetails.
+func (e *Element) CalculateMullionDetails(profiles map[string]Profile) error {
+	if e.Layout == nil || e.Layout.Split == nil {
+		return nil
+	}
+	sides, err := e.Frame.sideProfiles(profiles)
+	if err != nil {
+		return err
+	}
+	left, bottom := sides[constants.POSITION_LEFT].W, sides[constants.POSITION_BOTTOM].W
+	lightWidth := float64(e.Frame.Width) - left - sides[constants.POSITION_RIGHT].W
+	lightHeight := float64(e.Frame.Height) - bottom - sides[constants.POSITION_TOP].W
+	if lightWidth <= 0 || lightHeight <= 0 {
+		return cutErrorf("el marco de %dx%d mm no deja luz para la división", e.Frame.Width, e.Frame.Height)
+	}
+	builder := &layoutBuilder{element: e, bySKU: profiles, remeasure: true}
+	return builder.place(e.Layout, left, bottom, lightWidth, lightHeight)
+}
+
+// layoutBuilder recorre el árbol de campos acumulando las hojas generadas. Con remeasure solo
+// vuelve a medir una división ya aplicada, con los perfiles de bySKU.
+type layoutBuilder struct {
+	element   *Element
+	profiles  LayoutProfiles
+	winds     []Wind
+	mullions  int
+	remeasure bool
+	bySKU     map[string]Profile
+}
+
+// place ubica el campo en la región (x, y, width, height) y continúa con sus hijos.
+func (b *layoutBuilder) place(field *Field, x, y, width, height float64) error {
+	if field.ID == "" {
+		field.ID = generateID()
+	}
+	field.X, field.Y = int(math.Round(x)), int(math.Round(y))
+	field.Width, field.Height = int(math.Round(width)), int(math.Round(height))
+	if b.remeasure {
+		if field.Split == nil {
+			return nil
+		}
+		return b.split(field, x, y, width, height)
+	}
+	field.WindID = ""
+
+	if field.Split == nil {
+		return b.fill(field, width, height)
+	}
+	if field.Fill != "" || field.Sash != nil {
+		return fmt.Errorf("el campo %s está dividido y no puede tener relleno", field.ID)
+	}
+	if b.profiles.Mullion == nil {
+		return fmt.Errorf("el sistema no tiene perfil '%s' para dividir campos", constants.PROFILE_TYPE_MULLION)
+	}
+	return b.split(field, x, y, width, height)
+}
+
+// fill valida el relleno de un campo final y genera su hoja si corresponde.
+func (b *layoutBuilder) fill(field *Field, width, height float64) error {
+	switch field.Fill {
+	case constants.FIELD_FILL_GLASS:
+		if field.Sash != nil {
+			return fmt.Errorf("el campo %s es de vidrio y no puede llevar hoja", field.ID)
+		}
+		return nil
+	case constants.FIELD_FILL_SASH:
+		if field.Sash == nil {
+			return fmt.Errorf("el campo %s es de hoja pero no indica tipo y apertura", field.ID)
+		}
+		if b.element.Type != constants.TYPE_CASEMENT {
+			return fmt.Errorf("los campos con hoja solo se admiten en elementos '%s'", constants.TYPE_CASEMENT)
+		}
+		winds, err := casementWindsForLight(width, height, []CasementSash{*field.Sash}, b.profiles.Casement, len(b.winds)+1)
+		if err != nil {
+			return fmt.Errorf("campo %s: %w", field.ID, err)
+		}
+		field.WindID = winds[0].ID
+		b.winds = append(b.winds, winds...)
+		return nil
+	default:
+		return fmt.Errorf("el campo %s debe dividirse o tener relleno '%s' o '%s'", field.ID, constants.FIELD_FILL_GLASS, constants.FIELD_FILL_SASH)
+	}
+}
+
+// split reparte la región entre los hijos y corta un montante por cada separación.
+func (b *layoutBuilder) split(field *Field, x, y, width, height float64) error {
+	split := field.Split
+	vertical := split.Orientation == constants.SPLIT_VERTICAL
+	if !vertical && split.Orientation != constants.SPLIT_HORIZONTAL {
+		return fmt.Errorf("orientación de división inválida en el campo %s: '%s'", field.ID, split.Orientation)
+	}
+	count := len(split.Children)
+	if count < 2 {
+		return fmt.Errorf("la división del campo %s necesita al menos 2 campos hijos", field.ID)
+	}
+
+	span, start, crossSpan := height, y, width // Travesaño: reparte el alto y atraviesa el ancho
+	if vertical {
+		span, start, crossSpan = width, x, height
+	}
+	previous := split.Mullions
+	var mullion Profile
+	if b.remeasure {
+		if len(previous) != count-1 {
+			return cutErrorf("el campo %s no tiene calculados sus montantes; vuelva a aplicar la división", field.ID)
+		}
+		profile, ok := b.bySKU[previous[0].ProfileSKU]
+		if !ok {
+			return cutErrorf("falta el perfil '%s' del montante", previous[0].ProfileSKU)
+		}
+		mullion = profile
+	} else {
+		mullion = *b.profiles.Mullion
+	}
+	half := mullion.W / 2
+	offsets, err := splitOffsets(split, span, mullion.W)
+	if err != nil {
+		return fmt.Errorf("campo %s: %w", field.ID, err)
+	}
+
+	split.Axes = make([]int, 0, count-1)
+	split.Mullions = make([]FrameDetail, 0, count-1)
+	for i, offset := range offsets {
+		b.mullions++
+		split.Axes = append(split.Axes, int(math.Round(start+offset)))
+		length := int(math.Round(crossSpan + 2*mullion.CouplingMM + mullion.CutMarginMM))
+		if length <= 0 {
+			return cutErrorf("el montante %d del campo %s resulta con largo no positivo (%d mm)", i+1, field.ID, length)
+		}
+		detail := FrameDetail{
+			Position:   fmt.Sprintf("%s %d", constants.PROFILE_TYPE_MULLION, b.mullions),
+			ProfileSKU: mullion.SKU,
+			ProfileID:  mullion.ID,
+		}
+		if b.remeasure {
+			detail = previous[i]
+			detail.ReinforcedUsed, detail.ReinforcedSKU = false, ""
+			detail.ReinforcedProfileID, detail.ReinforcedLength = 0, 0
+		}
+		detail.Dimension, detail.AngleLeft, detail.AngleRight = length, 90.0, 90.0
+		split.Mullions = append(split.Mullions, detail)
+	}
+
+	for i := range split.Children {
+		from, to := 0.0, span
+		if i > 0 {
+			from = offsets[i-1] + half
+		}
+		if i < count-1 {
+			to = offsets[i] - half
+		}
+		if to-from <= 0 {
+			return fmt.Errorf("el campo %d de la división del campo %s queda sin luz (%.1f mm)", i+1, field.ID, to-from)
+		}
+		child := &split.Children[i]
+		var err error
+		if vertical {
+			err = b.place(child, x+from, y, to-from, height)
+		} else {
+			err = b.place(child, x, y+from, width, to-from)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// splitOffsets devuelve la distancia desde el inicio del campo al eje de cada montante.
+// mullionW es el ancho del montante, necesario para repartir la luz por proporciones.
+func splitOffsets(split *FieldSplit, span, mullionW float64) ([]float64, error) {
+	count := len(split.Children)
+	if len(split.Offsets) > 0 && len(split.Proportions) > 0 {
+		return nil, errors.New("la división debe indicar offsets o proporciones, no ambos")
+	}
+
+	offsets := make([]float64, 0, count-1)
+	if len(split.Offsets) > 0 {
+		if len(split.Offsets) != count-1 {
+			return nil, fmt.Errorf("se esperaban %d offsets para %d campos, se recibieron %d", count-1, count, len(split.Offsets))
+		}
+		previous := 0.0
+		for _, offset := range split.Offsets {
+			value := float64(offset)
+			if value <= previous || value >= span {
+				return nil, fmt.Errorf("offset %d mm fuera de orden o fuera del campo de %.0f mm", offset, span)
+			}
+			offsets = append(offsets, value)
+			previous = value
+		}
+		return offsets, nil
+	}
+
+	proportions := split.Proportions
+	if len(proportions) == 0 {
+		proportions = make([]float64, count)
+		for i := range proportions {
+			proportions[i] = 1
+		}
+	}
+	if len(proportions) != count {
+		return nil, fmt.Errorf("se esperaban %d proporciones, se recibieron %d", count, len(proportions))
+	}
+	total := 0.0
+	for _, p := range proportions {
+		if p <= 0 {
+			return nil, fmt.Errorf("las proporciones deben ser positivas, se recibió %v", p)
+		}
+		total += p
+	}
+	available := span - float64(count-1)*mullionW
+	if available <= 0 {
+		return nil, fmt.Errorf("el campo de %.0f mm no admite %d montantes de %.1f mm", span, count-1, mullionW)
+	}
+	position := 0.0
+	for _, p := range proportions[:count-1] {
+		position += available * p / total
+		offsets = append(offsets, position+mullionW/2)
+		position += mullionW
+	}
+	return offsets, nil
+}
+
+// MullionDetails devuelve las piezas de todos los montantes y travesaños del campo y sus descendientes.
+func (f *Field) MullionDetails() []*FrameDetail {
+	if f == nil || f.Split == nil {
+		return nil
+	}
+	var details []*FrameDetail
+	for i := range f.Split.Mullions {
+		details = append(details, &f.Split.Mullions[i])
+	}
+	for i := range f.Split.Children {
+		details = append(details, f.Split.Children[i].MullionDetails()...)
+	}
+	return details
+}
+
+// SetMullionStockItem asigna al montante o travesaño de la posición indicada el perfil del
+// catálogo y el ítem de stock (perfil + color) que se cortará, como Frame.SetFrameStockItem.
+func (f *Field) SetMullionStockItem(position string, profile Profile, item StockItem, colorName string) error {
+	for _, detail := range f.MullionDetails() {
+		if detail.Position != position {
+			continue
+		}
+		if profile.SKU == "" {
+			return fmt.Errorf("el SKU del perfil (profileSKU) no puede estar vacío para la posición '%s'", position)
+		}
+		detail.ProfileSKU, detail.ProfileID = profile.SKU, profile.ID
+		detail.Color, detail.ItemSKU, detail.ColorID = colorName, item.ItemSKU, item.ColorID
+		return nil
+	}
+	return fmt.Errorf("montante inválido o no calculado: '%s'", position)
+}
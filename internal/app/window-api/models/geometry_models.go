@@ -0,0 +1,313 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/mvialf/windraw/internal/pkg/constants"
+)
+
+// FrameShape son las medidas adicionales de un marco no rectangular (mm). Width y Height del
+// marco son siempre el rectángulo que lo contiene; el origen es la esquina inferior izquierda.
+type FrameShape struct {
+	ApexOffset  *int `json:"apex_offset,omitempty"`  // Triangular y pentagonal: distancia del borde izquierdo al vértice superior (nil = centro)
+	LeftHeight  int  `json:"left_height,omitempty"`  // Trapezoidal, pentagonal y arco rebajado: alto del lado izquierdo
+	RightHeight int  `json:"right_height,omitempty"` // Trapezoidal y pentagonal: alto del lado derecho (por defecto, LeftHeight)
+}
+
+// Point es un punto del contorno en mm.
+type Point struct {
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+}
+
+// FrameEdge es un lado del contorno exterior del marco, recorrido en sentido antihorario.
+type FrameEdge struct {
+	Position   string  `json:"position"`         // constants.POSITION_*
+	Start      Point   `json:"start"`            // Vértice inicial
+	End        Point   `json:"end"`              // Vértice final
+	Length     float64 `json:"length"`           // Largo exterior en mm (desarrollo exterior si es curvo)
+	AngleStart float64 `json:"angle_start"`      // Ángulo de corte en el vértice inicial: la mitad del ángulo interior
+	AngleEnd   float64 `json:"angle_end"`        // Ángulo de corte en el vértice final
+	Radius     float64 `json:"radius,omitempty"` // Radio exterior en mm si el lado es un arco
+	Sweep      float64 `json:"sweep,omitempty"`  // Ángulo abarcado por el arco en radianes
+}
+
+// Curved indica si el lado es un arco.
+func (e FrameEdge) Curved() bool {
+	return e.Radius > 0
+}
+
+// FrameOutline es el contorno exterior de un marco con sus lados, área y perímetro.
+type FrameOutline struct {
+	Geometry  string      `json:"geometry"`
+	Edges     []FrameEdge `json:"edges"`
+	Area      float64     `json:"area"`      // m²
+	Perimeter float64     `json:"perimeter"` // m
+}
+
+// NewFrameOutline construye el contorno exterior de un marco de width x height mm con la geometría
+// indicada (constants.GEOMETRY_*). Las geometrías no rectangulares usan las medidas de shape:
+//   - Triangular: base abajo y vértice superior a ApexOffset del borde izquierdo.
+//   - Trapezoidal: lados verticales de LeftHeight y RightHeight; el mayor debe ser height.
+//   - Pentagonal: lados verticales de LeftHeight y RightHeight y vértice a height en ApexOffset.
+//   - Arco rebajado: lados verticales de LeftHeight y arco superior con flecha height − LeftHeight,
+//     que no puede superar medio ancho.
+//   - Arco de medio punto: arco de radio width/2 sobre lados verticales de height − width/2.
+func NewFrameOutline(geometry string, width, height int, shape FrameShape) (*FrameOutline, error) {
+	if width <= 0 || height <= 0 {
+		return nil, errors.New("las dimensiones (width, height) del marco deben ser mayores a 0")
+	}
+	w, h := float64(width), float64(height)
+	apex := w / 2
+	if shape.ApexOffset != nil {
+		apex = float64(*shape.ApexOffset)
+	}
+	left, right := float64(shape.LeftHeight), float64(shape.RightHeight)
+	if right == 0 {
+		right = left
+	}
+
+	var edges []FrameEdge
+	switch geometry {
+	case constants.GEOMETRY_RECTANGULAR, "":
+		geometry = constants.GEOMETRY_RECTANGULAR
+		edges = polygonEdges([]Point{{0, 0}, {w, 0}, {w, h}, {0, h}},
+			[]string{constants.POSITION_BOTTOM, constants.POSITION_RIGHT, constants.POSITION_TOP, constants.POSITION_LEFT})
+
+	case constants.GEOMETRY_TRIANGULAR:
+		if apex < 0 || apex > w {
+			return nil, fmt.Errorf("el vértice del triángulo (%.0f mm) debe estar entre 0 y el ancho (%d mm)", apex, width)
+		}
+		edges = polygonEdges([]Point{{0, 0}, {w, 0}, {apex, h}},
+			[]string{constants.POSITION_BOTTOM, constants.POSITION_RIGHT, constants.POSITION_LEFT})
+
+	case constants.GEOMETRY_TRAPEZOIDAL:
+		if left == 0 {
+			left = h
+		}
+		if shape.RightHeight == 0 {
+			right = h
+		}
+		if left <= 0 || right <= 0 || math.Max(left, right) != h || left == right {
+			return nil, fmt.Errorf("el trapecio requiere left_height y right_height positivos y distintos, con el mayor igual al alto (%d mm)", height)
+		}
+		edges = polygonEdges([]Point{{0, 0}, {w, 0}, {w, right}, {0, left}},
+			[]string{constants.POSITION_BOTTOM, constants.POSITION_RIGHT, constants.POSITION_TOP, constants.POSITION_LEFT})
+
+	case constants.GEOMETRY_PENTAGONAL:
+		if left <= 0 || right <= 0 || left >= h || right >= h {
+			return nil, fmt.Errorf("el pentágono requiere left_height (y right_height) positivos y menores que el alto (%d mm)", height)
+		}
+		if apex <= 0 || apex >= w {
+			return nil, fmt.Errorf("el vértice del pentágono (%.0f mm) debe estar dentro del ancho (%d mm)", apex, width)
+		}
+		edges = polygonEdges([]Point{{0, 0}, {w, 0}, {w, right}, {apex, h}, {0, left}},
+			[]string{constants.POSITION_BOTTOM, constants.POSITION_RIGHT, constants.POSITION_TOP_RIGHT, constants.POSITION_TOP_LEFT, constants.POSITION_LEFT})
+
+	case constants.GEOMETRY_SEGMENTAL_ARCH, constants.GEOMETRY_HALF_ROUND_ARCH:
+		shoulder := left
+		if geometry == constants.GEOMETRY_HALF_ROUND_ARCH {
+			shoulder = h - w/2
+		}
+		rise := h - shoulder
+		if shoulder <= 0 || rise <= 0 || rise > w/2 {
+			return nil, fmt.Errorf("el arco requiere lados verticales positivos y una flecha entre 0 y medio ancho (%d mm de ancho, %d mm de alto, %.0f mm de lados)",
+				width, height, shoulder)
+		}
+		edges = polygonEdges([]Point{{0, 0}, {w, 0}, {w, shoulder}, {0, shoulder}},
+			[]string{constants.POSITION_BOTTOM, constants.POSITION_RIGHT, constants.POSITION_TOP, constants.POSITION_LEFT})
+		top := &edges[2]
+		top.Radius = (w*w/4 + rise*rise) / (2 * rise)
+		top.Sweep = 2 * math.Asin(math.Min(1, w/(2*top.Radius)))
+		top.Length = top.Radius * top.Sweep
+
+	default:
+		return nil, fmt.Errorf("geometría de marco no soportada: '%s'", geometry)
+	}
+
+	outline := &FrameOutline{Geometry: geometry, Edges: edges}
+	outline.computeCutAngles()
+	outline.computeAreaAndPerimeter()
+	return outline, nil
+}
+
+// Positions devuelve las posiciones de los lados en orden antihorario desde la base.
+func (o *FrameOutline) Positions() []string {
+	positions := make([]string, 0, len(o.Edges))
+	for _, edge := range o.Edges {
+		positions = append(positions, edge.Position)
+	}
+	return positions
+}
+
+// polygonEdges crea los lados rectos que unen los vértices en orden.
+func polygonEdges(vertices []Point, positions []string) []FrameEdge {
+	edges := make([]FrameEdge, 0, len(vertices))
+	for i, start := range vertices {
+		end := vertices[(i+1)%len(vertices)]
+		edges = append(edges, FrameEdge{
+			Position: positions[i],
+			Start:    start,
+			End:      end,
+			Length:   math.Hypot(end.X-start.X, end.Y-start.Y),
+		})
+	}
+	return edges
+}
+
+// tangents devuelve la dirección del lado en su vértice inicial y en el final.
+// En un arco recorrido en sentido antihorario la dirección gira Sweep entre ambos extremos.
+func (e FrameEdge) tangents() (float64, float64) {
+	chord := math.Atan2(e.End.Y-e.Start.Y, e.End.X-e.Start.X)
+	if !e.Curved() {
+		return chord, chord
+	}
+	return chord - e.Sweep/2, chord + e.Sweep/2
+}
+
+// computeCutAngles calcula el ángulo interior de cada vértice a partir de las direcciones de los
+// lados que llegan y salen de él (la tangente en los arcos) y corta cada pieza a la mitad (inglete).
+// Un arco tangente a su lado vecino deja un ángulo interior de 180° y se corta recto.
+func (o *FrameOutline) computeCutAngles() {
+	n := len(o.Edges)
+	for i := range o.Edges {
+		next := &o.Edges[(i+1)%n]
+		_, incoming := o.Edges[i].tangents()
+		outgoing, _ := next.tangents()
+		turn := math.Atan2(math.Sin(outgoing-incoming), math.Cos(outgoing-incoming))
+		cut := roundAngle((180 - turn*180/math.Pi) / 2)
+		o.Edges[i].AngleEnd = cut
+		next.AngleStart = cut
+	}
+}
+
+// computeAreaAndPerimeter suma el área del polígono de vértices (fórmula del área de Gauss)
+// más el segmento circular de cada arco.
+func (o *FrameOutline) computeAreaAndPerimeter() {
+	area, perimeter := 0.0, 0.0
+	for _, edge := range o.Edges {
+		area += (edge.Start.X*edge.End.Y - edge.End.X*edge.Start.Y) / 2
+		if edge.Curved() {
+			area += edge.Radius * edge.Radius / 2 * (edge.Sweep - math.Sin(edge.Sweep))
+		}
+		perimeter += edge.Length
+	}
+	o.Area = area / 1000000.0
+	o.Perimeter = perimeter / 1000.0
+}
+
+// roundAngle redondea un ángulo de corte a la décima de grado.
+func roundAngle(angle float64) float64 {
+	return math.Round(angle*10) / 10
+}
+
+// outlineCuts calcula las piezas de un marco no rectangular, todas a inglete.
+//
+// Reglas:
+//   - Lado recto: el largo exterior del lado, cortado en cada extremo a la mitad del ángulo interior.
+//   - Lado curvo: el perfil se curva por su eje neutro, así que la pieza mide el desarrollo del arco
+//     de radio exterior menos W/2 del perfil; BendRadius es el radio exterior de curvado.
+//
+// Como en rectangularCuts, se suma weldMarginMM por cada extremo no recto si el perfil lleva
+// weld_margin y, al final, cut_margin_mm. Los ángulos se expresan como izquierdo (o inferior) y derecho.
+func (o *FrameOutline) outlineCuts(profiles map[string]Profile, weldMarginMM float64) (map[string]CutPiece, error) {
+	pieces := make(map[string]CutPiece, len(o.Edges))
+	for _, edge := range o.Edges {
+		profile, ok := profiles[edge.Position]
+		if !ok {
+			continue
+		}
+		nominal := edge.Length
+		bendRadius := 0
+		if edge.Curved() {
+			nominal = (edge.Radius - profile.W/2) * edge.Sweep
+			bendRadius = int(math.Round(edge.Radius))
+		}
+
+		angleL, angleR := edge.AngleStart, edge.AngleEnd
+		dx := edge.End.X - edge.Start.X
+		if dx < -1e-9 || (math.Abs(dx) <= 1e-9 && edge.End.Y < edge.Start.Y) {
+			angleL, angleR = angleR, angleL // El lado se recorre de derecha a izquierda o de arriba hacia abajo
+		}
+
+		length := nominal
+		if profile.WeldMargin {
+			for _, angle := range []float64{angleL, angleR} {
+				if angle != 90.0 {
+					length += weldMarginMM
+				}
+			}
+		}
+		length += profile.CutMarginMM
+
+		rounded := int(math.Round(length))
+		if rounded <= 0 {
+			return nil, cutErrorf("la pieza de la posición '%s' resulta con largo no positivo (%d mm)", edge.Position, rounded)
+		}
+		pieces[edge.Position] = CutPiece{Length: rounded, AngleLeft: angleL, AngleRight: angleR, BendRadius: bendRadius}
+	}
+	return pieces, nil
+}
+
+// Outline devuelve el contorno exterior del marco según su geometría y Shape.
+func (f *Frame) Outline() (*FrameOutline, error) {
+	shape := FrameShape{}
+	if f.Shape != nil {
+		shape = *f.Shape
+	}
+	return NewFrameOutline(f.Geometry, f.Width, f.Height, shape)
+}
+
+// IsRectangular indica si el marco tiene geometría rectangular (o no indicada).
+func (f *Frame) IsRectangular() bool {
+	return f.Geometry == "" || f.Geometry == constants.GEOMETRY_RECTANGULAR
+}
+
+// SetShape cambia la geometría del marco, recalcula su área y perímetro y deja una posición por
+// cada lado del contorno. Las posiciones que ya existían conservan su perfil y color.
+func (f *Frame) SetShape(geometry string, shape FrameShape) error {
+	outline, err := NewFrameOutline(geometry, f.Width, f.Height, shape)
+	if err != nil {
+		return err
+	}
+	details := make(map[string]FrameDetail, len(outline.Edges))
+	for _, pos := range outline.Positions() {
+		detail, ok := f.Details[pos]
+		if !ok {
+			detail = FrameDetail{Position: pos}
+		}
+		details[pos] = detail
+	}
+
+	f.Geometry = outline.Geometry
+	f.Shape = nil
+	if outline.Geometry != constants.GEOMETRY_RECTANGULAR {
+		f.Shape = &shape
+	}
+	f.Area = outline.Area
+	f.Perimeter = outline.Perimeter
+	f.Details = details
+	return nil
+}
+
+// SetFrameShape cambia la geometría del marco del elemento (ver Frame.SetShape) y actualiza el
+// área y el perímetro del elemento. Las hojas, la división en campos y los vidrios solo se
+// generan para marcos rectangulares.
+func (e *Element) SetFrameShape(geometry string, shape FrameShape) error {
+	if err := e.Frame.SetShape(geometry, shape); err != nil {
+		return err
+	}
+	e.Area = e.Frame.Area
+	e.Perimeter = e.Frame.Perimeter
+	return nil
+}
+
+// requireRectangular devuelve un error si el marco no es rectangular.
+func (f *Frame) requireRectangular(operation string) error {
+	if f.IsRectangular() {
+		return nil
+	}
+	return cutErrorf("%s solo se admite en marcos rectangulares, el marco es '%s'", operation, f.Geometry)
+}
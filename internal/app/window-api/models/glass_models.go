@@ -0,0 +1,378 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/mvialf/windraw/internal/pkg/constants"
+)
+
+// ErrInvalidGlass indica un vidrio mal especificado (tipo desconocido o espesor no positivo).
+var ErrInvalidGlass = errors.New("vidrio inválido")
+
+// GlassSpec describe el vidrio de un paño.
+type GlassSpec struct {
+	Type        string  `json:"type"`                  // constants.GLASS_TYPE_*
+	ThicknessMM float64 `json:"thickness_mm"`          // Espesor total del paño en mm
+	Composition string  `json:"composition,omitempty"` // Composición libre (ej. "4-12-4" en DVH o "3+3" en laminado)
+}
+
+// Validate comprueba que el tipo de vidrio sea conocido y el espesor positivo.
+// Los errores envuelven ErrInvalidGlass.
+func (s GlassSpec) Validate() error {
+	validTypes := []string{constants.GLASS_TYPE_MONOLITHIC, constants.GLASS_TYPE_LAMINATED, constants.GLASS_TYPE_DVH}
+	if !IsValidOption(s.Type, validTypes) {
+		return fmt.Errorf("%w: tipo '%s' no válido (válidos: %v)", ErrInvalidGlass, s.Type, validTypes)
+	}
+	if s.ThicknessMM <= 0 {
+		return fmt.Errorf("%w: el espesor debe ser mayor a 0, se recibió %.1f mm", ErrInvalidGlass, s.ThicknessMM)
+	}
+	return nil
+}
+
+// GlassPane es un paño de vidrio de un campo fijo o de una hoja, con sus junquillos.
+type GlassPane struct {
+	ID          string        `json:"id"`
+	SourceID    string        `json:"source_id"`    // ID del campo fijo o de la hoja que contiene el paño
+	Label       string        `json:"label"`        // ej. "Paño fijo 1" o el nombre de la hoja
+	LightWidth  int           `json:"light_width"`  // Luz visible en mm
+	LightHeight int           `json:"light_height"` // Luz visible en mm
+	Width       int           `json:"width"`        // Medida de corte del vidrio en mm
+	Height      int           `json:"height"`       // Medida de corte del vidrio en mm
+	Area        float64       `json:"area"`         // Área del vidrio en m²
+	Glass       GlassSpec     `json:"glass"`
+	Beads       []FrameDetail `json:"beads,omitempty"` // Junquillos por posición, si el sistema usa junquillo
+}
+
+// GlassProfiles son los datos del sistema que intervienen en el cálculo de vidrios.
+type GlassProfiles struct {
+	System    ProfileSystem      // uses_glass_bead y glass_margin_mm
+	BySKU     map[string]Profile // Perfiles del marco, las hojas y los montantes del elemento, por SKU
+	GlassBead *Profile           // constants.PROFILE_TYPE_GLASSBEAD; obligatorio si el sistema usa junquillo
+}
+
+// glassOpening es la luz de un paño y la profundidad de galce de cada borde.
+type glassOpening struct {
+	sourceID string
+	label    string
+	width    float64
+	height   float64
+	rebates  map[string]float64 // GlassRebateMM del perfil en cada posición
+}
+
+// CalculateGlass reemplaza los paños del elemento: uno por cada campo de vidrio y uno por cada hoja.
+// Sin división en campos, un elemento sin hojas es un paño fijo en la luz del marco.
+// spec es el vidrio por defecto; un campo con Glass lo reemplaza para su paño o el de su hoja.
+//
+// Dimensionado (mm):
+//   - Luz: medida del campo (ver SetLayout) o de la hoja/marco menos el ancho W del perfil de cada lado.
+//   - Vidrio: luz más la profundidad de galce GlassRebateMM del perfil de cada borde (marco,
+//     montante u hoja) menos glass_margin_mm del sistema por borde, que queda de holgura para
+//     los calzos.
+//   - Junquillos: uno por lado, al largo de la luz, con el tipo de corte del perfil de junquillo
+//     (CUT_ANGLE_WIND si no tiene) y su cut_margin_mm.
+//
+// Los junquillos que mantienen su perfil conservan el ítem de stock y el color que ya tenían
+// (ver SetBeadStockItem), de modo que recalcular los vidrios no obliga a reasignar materiales.
+func (e *Element) CalculateGlass(spec GlassSpec, profiles GlassProfiles) error {
+	if err := e.Frame.requireRectangular("calcular vidrios"); err != nil {
+		return err
+	}
+	if profiles.System.UsesGlassBead && profiles.GlassBead == nil {
+		return cutErrorf("el sistema '%s' usa junquillo pero no tiene perfil '%s'", profiles.System.Name, constants.PROFILE_TYPE_GLASSBEAD)
+	}
+
+	var requests []glassRequest
+	if e.Layout != nil {
+		openings, err := e.layoutGlassOpenings(e.Layout, spec, profiles.BySKU)
+		if err != nil {
+			return err
+		}
+		requests = openings
+	} else if len(e.Winds) > 0 {
+		for i := range e.Winds {
+			opening, err := e.Winds[i].glassOpening(profiles.BySKU)
+			if err != nil {
+				return err
+			}
+			requests = append(requests, glassRequest{opening, spec})
+		}
+	} else {
+		opening, err := e.Frame.glassOpening(profiles.BySKU)
+		if err != nil {
+			return err
+		}
+		opening.sourceID, opening.label = e.ID, "Paño fijo 1"
+		requests = append(requests, glassRequest{opening, spec})
+	}
+
+	panes := make([]GlassPane, 0, len(requests))
+	for _, req := range requests {
+		pane, err := newGlassPane(req.opening, req.spec, profiles)
+		if err != nil {
+			return err
+		}
+		panes = append(panes, pane)
+	}
+	assigned := make(map[string]FrameDetail)
+	for _, pane := range e.Glasses {
+		for _, bead := range pane.Beads {
+			if bead.ItemSKU != "" {
+				assigned[bead.ProfileSKU] = bead
+			}
+		}
+	}
+	for i := range panes {
+		for j := range panes[i].Beads {
+			bead := &panes[i].Beads[j]
+			if previous, ok := assigned[bead.ProfileSKU]; ok {
+				bead.Color, bead.ItemSKU, bead.ColorID = previous.Color, previous.ItemSKU, previous.ColorID
+			}
+		}
+	}
+	e.Glasses = panes
+	return nil
+}
+
+// SetBeadStockItem asigna al junquillo de la posición indicada el perfil del catálogo y el ítem de
+// stock (perfil + color) que se cortará, como Frame.SetFrameStockItem.
+func (p *GlassPane) SetBeadStockItem(position string, profile Profile, item StockItem, colorName string) error {
+	for i := range p.Beads {
+		detail := &p.Beads[i]
+		if detail.Position != position {
+			continue
+		}
+		if profile.SKU == "" {
+			return fmt.Errorf("el SKU del perfil (profileSKU) no puede estar vacío para la posición '%s'", position)
+		}
+		detail.ProfileSKU, detail.ProfileID = profile.SKU, profile.ID
+		detail.Color, detail.ItemSKU, detail.ColorID = colorName, item.ItemSKU, item.ColorID
+		return nil
+	}
+	return fmt.Errorf("junquillo inválido o no calculado en el paño '%s': '%s'", p.Label, position)
+}
+
+// glassRequest es una luz con el vidrio que le corresponde.
+type glassRequest struct {
+	opening glassOpening
+	spec    GlassSpec
+}
+
+// layoutGlassOpenings recorre los campos finales de la división en orden.
+// Los bordes de un campo que coinciden con la luz del marco usan el galce del marco;
+// el resto apoya en un montante.
+func (e *Element) layoutGlassOpenings(root *Field, spec GlassSpec, bySKU map[string]Profile) ([]glassRequest, error) {
+	frameSides, err := e.Frame.sideProfiles(bySKU)
+	if err != nil {
+		return nil, err
+	}
+	var mullion *Profile
+	if details := root.MullionDetails(); len(details) > 0 {
+		profile, ok := bySKU[details[0].ProfileSKU]
+		if !ok {
+			return nil, cutErrorf("falta el perfil '%s' del montante", details[0].ProfileSKU)
+		}
+		mullion = &profile
+	}
+	winds := make(map[string]*Wind, len(e.Winds))
+	for i := range e.Winds {
+		winds[e.Winds[i].ID] = &e.Winds[i]
+	}
+
+	minX, minY := frameSides[constants.POSITION_LEFT].W, frameSides[constants.POSITION_BOTTOM].W
+	maxX := float64(e.Frame.Width) - frameSides[constants.POSITION_RIGHT].W
+	maxY := float64(e.Frame.Height) - frameSides[constants.POSITION_TOP].W
+	onFrame := func(value, limit float64) bool { return math.Abs(value-limit) < 1 }
+
+	var openings []glassRequest
+	fixed := 0
+	var walk func(field *Field, inherited GlassSpec) error
+	walk = func(field *Field, inherited GlassSpec) error {
+		if field.Glass != nil {
+			inherited = *field.Glass
+		}
+		if field.Split != nil {
+			for i := range field.Split.Children {
+				if err := walk(&field.Split.Children[i], inherited); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+
+		if field.Fill == constants.FIELD_FILL_SASH {
+			wind, ok := winds[field.WindID]
+			if !ok {
+				return cutErrorf("el campo %s no tiene hoja generada; vuelva a aplicar la división", field.ID)
+			}
+			opening, err := wind.glassOpening(bySKU)
+			if err != nil {
+				return err
+			}
+			openings = append(openings, glassRequest{opening, inherited})
+			return nil
+		}
+
+		x, y := float64(field.X), float64(field.Y)
+		right, top := x+float64(field.Width), y+float64(field.Height)
+		edges := map[string]bool{
+			constants.POSITION_LEFT:   onFrame(x, minX),
+			constants.POSITION_RIGHT:  onFrame(right, maxX),
+			constants.POSITION_BOTTOM: onFrame(y, minY),
+			constants.POSITION_TOP:    onFrame(top, maxY),
+		}
+		rebates := make(map[string]float64, len(edges))
+		for pos, againstFrame := range edges {
+			switch {
+			case againstFrame:
+				rebates[pos] = frameSides[pos].GlassRebateMM
+			case mullion != nil:
+				rebates[pos] = mullion.GlassRebateMM
+			default:
+				return cutErrorf("el borde '%s' del campo %s no apoya en el marco ni en un montante", pos, field.ID)
+			}
+		}
+		fixed++
+		openings = append(openings, glassRequest{glassOpening{
+			sourceID: field.ID,
+			label:    fmt.Sprintf("Paño fijo %d", fixed),
+			width:    float64(field.Width),
+			height:   float64(field.Height),
+			rebates:  rebates,
+		}, inherited})
+		return nil
+	}
+	if err := walk(root, spec); err != nil {
+		return nil, err
+	}
+	return openings, nil
+}
+
+// sideProfiles devuelve el perfil de cada lado del marco según el SKU asignado.
+func (f *Frame) sideProfiles(bySKU map[string]Profile) (map[string]Profile, error) {
+	return sideProfiles("el marco", func(pos string) (string, bool) {
+		detail, ok := f.Details[pos]
+		return detail.ProfileSKU, ok
+	}, bySKU)
+}
+
+// glassOpening devuelve la luz del marco (un paño fijo sin hojas ni división).
+func (f *Frame) glassOpening(bySKU map[string]Profile) (glassOpening, error) {
+	sides, err := f.sideProfiles(bySKU)
+	if err != nil {
+		return glassOpening{}, err
+	}
+	return openingInside(float64(f.Width), float64(f.Height), sides), nil
+}
+
+// glassOpening devuelve la luz de la hoja. Las piezas de traslapo no intervienen.
+func (w *Wind) glassOpening(bySKU map[string]Profile) (glassOpening, error) {
+	sides, err := sideProfiles(fmt.Sprintf("la hoja '%s'", w.Name), func(pos string) (string, bool) {
+		detail, ok := w.Details[pos]
+		return detail.ProfileSKU, ok
+	}, bySKU)
+	if err != nil {
+		return glassOpening{}, err
+	}
+	opening := openingInside(float64(w.Width), float64(w.Height), sides)
+	opening.sourceID, opening.label = w.ID, w.Name
+	return opening, nil
+}
+
+// sideProfiles busca el perfil de las cuatro posiciones de un contorno rectangular.
+func sideProfiles(owner string, skuAt func(pos string) (string, bool), bySKU map[string]Profile) (map[string]Profile, error) {
+	sides := make(map[string]Profile, 4)
+	for _, pos := range []string{constants.POSITION_LEFT, constants.POSITION_RIGHT, constants.POSITION_TOP, constants.POSITION_BOTTOM} {
+		sku, ok := skuAt(pos)
+		if !ok || sku == "" {
+			return nil, cutErrorf("%s no tiene perfil asignado en la posición '%s'", owner, pos)
+		}
+		profile, ok := bySKU[sku]
+		if !ok {
+			return nil, cutErrorf("falta el perfil '%s' de %s en la posición '%s'", sku, owner, pos)
+		}
+		sides[pos] = profile
+	}
+	return sides, nil
+}
+
+// openingInside calcula la luz de un contorno de width x height mm descontando el ancho W de cada lado.
+func openingInside(width, height float64, sides map[string]Profile) glassOpening {
+	rebates := make(map[string]float64, len(sides))
+	for pos, profile := range sides {
+		rebates[pos] = profile.GlassRebateMM
+	}
+	return glassOpening{
+		width:   width - sides[constants.POSITION_LEFT].W - sides[constants.POSITION_RIGHT].W,
+		height:  height - sides[constants.POSITION_BOTTOM].W - sides[constants.POSITION_TOP].W,
+		rebates: rebates,
+	}
+}
+
+// newGlassPane dimensiona el vidrio y los junquillos de una luz.
+func newGlassPane(opening glassOpening, spec GlassSpec, profiles GlassProfiles) (GlassPane, error) {
+	if err := spec.Validate(); err != nil {
+		return GlassPane{}, fmt.Errorf("paño '%s': %w", opening.label, err)
+	}
+	if opening.width <= 0 || opening.height <= 0 {
+		return GlassPane{}, cutErrorf("paño '%s' sin luz (%.0fx%.0f mm)", opening.label, opening.width, opening.height)
+	}
+	margin := profiles.System.GlassMarginMM
+	width := opening.width + opening.rebates[constants.POSITION_LEFT] + opening.rebates[constants.POSITION_RIGHT] - 2*margin
+	height := opening.height + opening.rebates[constants.POSITION_BOTTOM] + opening.rebates[constants.POSITION_TOP] - 2*margin
+	if width <= 0 || height <= 0 {
+		return GlassPane{}, cutErrorf("el vidrio del paño '%s' resulta con medidas no positivas (%.0fx%.0f mm)", opening.label, width, height)
+	}
+
+	pane := GlassPane{
+		ID:          generateID(),
+		SourceID:    opening.sourceID,
+		Label:       opening.label,
+		LightWidth:  int(math.Round(opening.width)),
+		LightHeight: int(math.Round(opening.height)),
+		Width:       int(math.Round(width)),
+		Height:      int(math.Round(height)),
+		Glass:       spec,
+	}
+	pane.Area = math.Round(float64(pane.Width)*float64(pane.Height)/1e3) / 1e3
+
+	if profiles.System.UsesGlassBead {
+		beads, err := glassBeads(pane.LightWidth, pane.LightHeight, *profiles.GlassBead)
+		if err != nil {
+			return GlassPane{}, fmt.Errorf("junquillos del paño '%s': %w", opening.label, err)
+		}
+		pane.Beads = beads
+	}
+	return pane, nil
+}
+
+// glassBeads corta los cuatro junquillos que rodean una luz de width x height mm.
+func glassBeads(width, height int, bead Profile) ([]FrameDetail, error) {
+	cutType := bead.CutType
+	if cutType == "" {
+		cutType = constants.CUT_ANGLE_WIND
+	}
+	positions := []string{constants.POSITION_LEFT, constants.POSITION_RIGHT, constants.POSITION_TOP, constants.POSITION_BOTTOM}
+	byPosition := make(map[string]Profile, len(positions))
+	for _, pos := range positions {
+		byPosition[pos] = bead
+	}
+	pieces, err := rectangularCuts(width, height, cutType, byPosition, nil, nil, 0)
+	if err != nil {
+		return nil, err
+	}
+	beads := make([]FrameDetail, 0, len(positions))
+	for _, pos := range positions {
+		piece := pieces[pos]
+		beads = append(beads, FrameDetail{
+			Position:   pos,
+			ProfileSKU: bead.SKU,
+			ProfileID:  bead.ID,
+			Dimension:  piece.Length,
+			AngleLeft:  piece.AngleLeft,
+			AngleRight: piece.AngleRight,
+		})
+	}
+	return beads, nil
+}
@@ -0,0 +1,182 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/mvialf/windraw/internal/pkg/constants"
+)
+
+var (
+	// ErrInvalidLabel se devuelve cuando un texto escaneado no tiene el formato de etiqueta de pieza.
+	ErrInvalidLabel = errors.New("etiqueta inválida")
+	// ErrPieceNotFound se devuelve cuando el código de una etiqueta no corresponde a ninguna pieza.
+	ErrPieceNotFound = errors.New("pieza no encontrada")
+)
+
+// labelSeparator separa el proyecto, el elemento y la pieza en el texto de la etiqueta.
+const labelSeparator = "/"
+
+// shortIDLength es el largo de los IDs abreviados de las etiquetas, ver ShortID.
+const shortIDLength = 8
+
+// positionKeys abrevia las posiciones de las piezas para su clave (ver Element.Pieces).
+var positionKeys = map[string]string{
+	constants.POSITION_LEFT:          "L",
+	constants.POSITION_BOTTOM:        "B",
+	constants.POSITION_RIGHT:         "R",
+	constants.POSITION_TOP:           "T",
+	constants.POSITION_TOP_LEFT:      "TL",
+	constants.POSITION_TOP_RIGHT:     "TR",
+	constants.POSITION_OVERLAP_LEFT:  "OL",
+	constants.POSITION_OVERLAP_RIGHT: "OR",
+}
+
+// PieceLabel es la etiqueta de una pieza cortada: el texto que lleva el código de barras y los
+// datos legibles que se imprimen al lado.
+type PieceLabel struct {
+	Payload     string `json:"payload"` // Texto del código de barras, ver LabelPayload
+	ProjectID   string `json:"project_id"`
+	ProjectName string `json:"project_name"`
+	Piece       Piece  `json:"piece"`
+}
+
+// PieceLocation es el resultado de resolver una etiqueta: dónde va la pieza dentro del proyecto.
+type PieceLocation struct {
+	ProjectID   string `json:"project_id"`
+	ProjectName string `json:"project_name"`
+	Piece       Piece  `json:"piece"`
+	Component   int    `json:"component"` // Índice desde 1, como en Piece.ElementRef
+	Module      int    `json:"module"`
+	Element     int    `json:"element"`
+	ElementID   string `json:"element_id"`
+	ElementType string `json:"element_type"`
+	Structure   string `json:"structure"`
+	Width       int    `json:"width"` // Medidas del elemento en mm
+	Height      int    `json:"height"`
+	Frame       string `json:"frame"`          // Nombre del marco del elemento
+	Wind        *Wind  `json:"wind,omitempty"` // Hoja a la que pertenece la pieza, si es de hoja
+	Position    string `json:"position"`       // Posición de la pieza en su marco, hoja o paño
+	Assembly    string `json:"assembly"`       // Descripción legible de dónde se monta la pieza
+}
+
+// LabelPayload devuelve el texto que se codifica en la etiqueta de una pieza:
+// "<ID del proyecto>/<ID abreviado del elemento>/<clave de la pieza>". No depende del orden de
+// los elementos ni de las piezas, así que una etiqueta impresa sigue valiendo aunque se agreguen,
+// quiten o reordenen elementos del proyecto.
+func LabelPayload(projectID, elementID, pieceKey string) string {
+	return strings.Join([]string{projectID, ShortID(elementID), pieceKey}, labelSeparator)
+}
+
+// ParseLabelPayload separa el ID del proyecto, el ID abreviado del elemento y la clave de la
+// pieza de un texto escaneado.
+func ParseLabelPayload(payload string) (projectID, elementKey, pieceKey string, err error) {
+	parts := strings.Split(strings.TrimSpace(payload), labelSeparator)
+	if len(parts) != 3 || parts[0] == "" || parts[1] == "" || parts[2] == "" {
+		return "", "", "", fmt.Errorf("%w: se espera '<proyecto>%s<elemento>%s<pieza>', se recibió '%s'",
+			ErrInvalidLabel, labelSeparator, labelSeparator, payload)
+	}
+	return parts[0], strings.ToLower(parts[1]), parts[2], nil
+}
+
+// ShortID abrevia un ID a sus primeros ocho caracteres sin guiones, para que la etiqueta entre
+// en un Code128 legible. Dentro de un proyecto basta para distinguir elementos, hojas y paños.
+func ShortID(id string) string {
+	id = strings.ToLower(strings.ReplaceAll(id, "-", ""))
+	if len(id) > shortIDLength {
+		return id[:shortIDLength]
+	}
+	return id
+}
+
+// positionKey abrevia la posición de una pieza para su clave: la letra de la posición, el número
+// del montante o, para otras posiciones, sus letras y dígitos en mayúscula.
+func positionKey(position string) string {
+	if key, ok := positionKeys[position]; ok {
+		return key
+	}
+	if n, ok := strings.CutPrefix(position, constants.PROFILE_TYPE_MULLION+" "); ok {
+		return n
+	}
+	var b strings.Builder
+	for _, r := range strings.ToUpper(position) {
+		if (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// Labels devuelve una etiqueta por cada pieza de perfil del proyecto, en el orden de Pieces.
+// Los refuerzos se omiten salvo que withReinforcement sea true: van dentro de su pieza y la
+// etiqueta de esta ya informa el acero.
+func (p *Project) Labels(withReinforcement bool) []PieceLabel {
+	labels := []PieceLabel{}
+	for _, piece := range p.Pieces() {
+		if piece.Reinforcement && !withReinforcement {
+			continue
+		}
+		labels = append(labels, PieceLabel{
+			Payload:     LabelPayload(p.ID, piece.ElementID, piece.Key),
+			ProjectID:   p.ID,
+			ProjectName: p.Name,
+			Piece:       piece,
+		})
+	}
+	return labels
+}
+
+// LocatePiece busca la pieza de una etiqueta por el ID abreviado de su elemento y su clave (ver
+// ParseLabelPayload) y devuelve el elemento, la hoja y la posición a la que pertenece. Los índices
+// de componente, módulo y elemento son los actuales, aunque hayan cambiado desde la impresión.
+func (p *Project) LocatePiece(elementKey, pieceKey string) (*PieceLocation, error) {
+	var location *PieceLocation
+	for c, component := range p.Components {
+		for m, module := range component.Modules {
+			for e := range module.Elements {
+				element := &module.Elements[e]
+				if ShortID(element.ID) != elementKey {
+					continue
+				}
+				ref := fmt.Sprintf("C%d-M%d-E%d", c+1, m+1, e+1)
+				for _, piece := range element.Pieces(ref) {
+					if piece.Key != pieceKey {
+						continue
+					}
+					if location != nil {
+						return nil, fmt.Errorf("%w: la etiqueta '%s/%s' corresponde a más de una pieza del proyecto %s",
+							ErrPieceNotFound, elementKey, pieceKey, p.ID)
+					}
+					location = &PieceLocation{
+						ProjectID:   p.ID,
+						ProjectName: p.Name,
+						Piece:       piece,
+						Component:   c + 1,
+						Module:      m + 1,
+						Element:     e + 1,
+						ElementID:   element.ID,
+						ElementType: element.Type,
+						Structure:   element.Structure,
+						Width:       element.Width,
+						Height:      element.Height,
+						Frame:       element.Frame.Name,
+						Position:    piece.Position,
+					}
+					for i := range element.Winds {
+						if piece.WindID != "" && element.Winds[i].ID == piece.WindID {
+							location.Wind = &element.Winds[i]
+						}
+					}
+					location.Assembly = fmt.Sprintf("%s %s (%dx%d mm), %s, posición %s",
+						element.Type, ref, element.Width, element.Height, piece.Owner, piece.Position)
+				}
+			}
+		}
+	}
+	if location == nil {
+		return nil, fmt.Errorf("%w: la pieza '%s' del elemento '%s' no existe en el proyecto %s",
+			ErrPieceNotFound, pieceKey, elementKey, p.ID)
+	}
+	return location, nil
+}
@@ -0,0 +1,190 @@
+package models
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/mvialf/windraw/internal/pkg/constants"
+)
+
+// Piece es una pieza de perfil a cortar de un proyecto: una pieza de marco, hoja, montante o
+// junquillo, o el refuerzo que va dentro de una de ellas.
+type Piece struct {
+	Code             string  `json:"code"` // Código único en el proyecto: "<ElementRef>-NN" ("-NNR" si es refuerzo)
+	Key              string  `json:"key"`  // Identificador estable de la pieza dentro de su elemento, ver Element.Pieces
+	ElementID        string  `json:"element_id"`
+	ElementRef       string  `json:"element_ref"`       // Referencia legible "C<componente>-M<módulo>-E<elemento>"
+	WindID           string  `json:"wind_id,omitempty"` // Hoja a la que pertenece, si es una pieza de hoja
+	Owner            string  `json:"owner"`             // "Marco", nombre de la hoja, "Montante" o paño del junquillo
+	Position         string  `json:"position"`          // Posición dentro del dueño (constants.POSITION_* o "Montante N")
+	ProfileSKU       string  `json:"profile_sku"`
+	ProfileID        int64   `json:"profile_id,omitempty"`
+	ItemSKU          string  `json:"item_sku,omitempty"`
+	Color            string  `json:"color,omitempty"`
+	ColorID          int64   `json:"color_id,omitempty"`
+	Length           int     `json:"length"` // Largo de corte en mm
+	AngleLeft        float64 `json:"angle_left"`
+	AngleRight       float64 `json:"angle_right"`
+	BendRadius       int     `json:"bend_radius,omitempty"`
+	Reinforcement    bool    `json:"reinforcement,omitempty"` // La pieza es el refuerzo de otra
+	Reinforced       bool    `json:"reinforced,omitempty"`    // La pieza lleva refuerzo
+	ReinforcedSKU    string  `json:"reinforced_sku,omitempty"`
+	ReinforcedLength int     `json:"reinforced_length,omitempty"` // Largo del acero de refuerzo que lleva la pieza
+}
+
+// StockKey identifica el material del que se corta una pieza: ítem de stock (o, si aún no se
+// resolvió, SKU de perfil) y color.
+type StockKey struct {
+	ItemSKU string `json:"item_sku"`
+	Color   string `json:"color,omitempty"`
+}
+
+// String devuelve la clave como "SKU" o "SKU (color)".
+func (k StockKey) String() string {
+	if k.Color == "" {
+		return k.ItemSKU
+	}
+	return fmt.Sprintf("%s (%s)", k.ItemSKU, k.Color)
+}
+
+// StockKey devuelve la clave de material de la pieza.
+func (p Piece) StockKey() StockKey {
+	sku := p.ItemSKU
+	if sku == "" {
+		sku = p.ProfileSKU
+	}
+	return StockKey{ItemSKU: sku, Color: p.Color}
+}
+
+// Pieces devuelve las piezas calculadas de todos los elementos del proyecto, en el orden de
+// componentes, módulos y elementos. Las piezas sin SKU o sin largo se omiten.
+func (p *Project) Pieces() []Piece {
+	var pieces []Piece
+	for c, component := range p.Components {
+		for m, module := range component.Modules {
+			for e := range module.Elements {
+				ref := fmt.Sprintf("C%d-M%d-E%d", c+1, m+1, e+1)
+				pieces = append(pieces, module.Elements[e].Pieces(ref)...)
+			}
+		}
+	}
+	return pieces
+}
+
+// Pieces devuelve las piezas calculadas del elemento (marco, hojas, montantes y junquillos, cada
+// una seguida de su refuerzo si lo lleva). ref es la referencia del elemento dentro del proyecto.
+// Las piezas se numeran en ese orden: el código de cada una es "<ref>-NN" y el de su refuerzo
+// "<ref>-NNR", de modo que no cambia mientras no cambie el despiece del elemento.
+//
+// Como ref y el número dependen del orden de los elementos y de las piezas, cada pieza lleva
+// además una clave que no depende de él: "<dueño>-<posición>", donde el dueño es F (marco), M
+// (montante), W<hoja> o G<paño> con el ID abreviado de la hoja o del campo del paño (ver ShortID),
+// y la posición es la abreviatura de constants.POSITION_* o el número del montante. La del
+// refuerzo agrega ".R".
+func (e *Element) Pieces(ref string) []Piece {
+	var pieces []Piece
+	add := func(piece Piece, detail FrameDetail) {
+		if detail.ProfileSKU == "" || detail.Dimension <= 0 {
+			return
+		}
+		piece.Code = fmt.Sprintf("%s-%02d", ref, len(pieces)+1)
+		piece.Key += "-" + positionKey(detail.Position)
+		piece.ElementID, piece.ElementRef = e.ID, ref
+		piece.Position = detail.Position
+		piece.ProfileSKU, piece.ProfileID = detail.ProfileSKU, detail.ProfileID
+		piece.ItemSKU, piece.Color, piece.ColorID = detail.ItemSKU, detail.Color, detail.ColorID
+		piece.Length = detail.Dimension
+		piece.AngleLeft, piece.AngleRight = detail.AngleLeft, detail.AngleRight
+		piece.BendRadius = detail.BendRadius
+		piece.Reinforced, piece.ReinforcedSKU = detail.ReinforcedUsed, detail.ReinforcedSKU
+		piece.ReinforcedLength = detail.ReinforcedLength
+		pieces = append(pieces, piece)
+
+		if detail.ReinforcedUsed && detail.ReinforcedLength > 0 {
+			pieces = append(pieces, Piece{
+				Code:          piece.Code + "R",
+				Key:           piece.Key + ".R",
+				ElementID:     e.ID,
+				ElementRef:    ref,
+				WindID:        piece.WindID,
+				Owner:         piece.Owner,
+				Position:      detail.Position,
+				ProfileSKU:    detail.ReinforcedSKU,
+				ProfileID:     detail.ReinforcedProfileID,
+				Length:        detail.ReinforcedLength,
+				AngleLeft:     90.0,
+				AngleRight:    90.0,
+				Reinforcement: true,
+			})
+		}
+	}
+
+	for _, pos := range sortedPositions(e.Frame.Details) {
+		add(Piece{Owner: e.Frame.Name, Key: "F"}, e.Frame.Details[pos])
+	}
+	for _, detail := range e.Layout.MullionDetails() {
+		add(Piece{Owner: "Montante", Key: "M"}, *detail)
+	}
+	for _, wind := range e.Winds {
+		for _, pos := range sortedPositions(wind.Details) {
+			add(Piece{Owner: wind.Name, WindID: wind.ID, Key: "W" + ShortID(wind.ID)}, windDetailAsFrameDetail(wind.Details[pos]))
+		}
+	}
+	for _, pane := range e.Glasses {
+		for _, bead := range pane.Beads {
+			add(Piece{Owner: "Junquillo " + pane.Label, Key: "G" + ShortID(pane.SourceID)}, bead)
+		}
+	}
+	return pieces
+}
+
+// windDetailAsFrameDetail copia los datos de corte de una pieza de hoja.
+func windDetailAsFrameDetail(d WindDetail) FrameDetail {
+	return FrameDetail{
+		Position:            d.Position,
+		ProfileSKU:          d.ProfileSKU,
+		Color:               d.Color,
+		Dimension:           d.Dimension,
+		AngleLeft:           d.AngleLeft,
+		AngleRight:          d.AngleRight,
+		ReinforcedUsed:      d.ReinforcedUsed,
+		ReinforcedSKU:       d.ReinforcedSKU,
+		ProfileID:           d.ProfileID,
+		ItemSKU:             d.ItemSKU,
+		ColorID:             d.ColorID,
+		ReinforcedProfileID: d.ReinforcedProfileID,
+		ReinforcedLength:    d.ReinforcedLength,
+	}
+}
+
+// positionOrder es el orden en que se listan las posiciones de un marco u hoja.
+var positionOrder = map[string]int{
+	constants.POSITION_BOTTOM:        0,
+	constants.POSITION_RIGHT:         1,
+	constants.POSITION_TOP:           2,
+	constants.POSITION_TOP_RIGHT:     3,
+	constants.POSITION_TOP_LEFT:      4,
+	constants.POSITION_LEFT:          5,
+	constants.POSITION_OVERLAP_LEFT:  6,
+	constants.POSITION_OVERLAP_RIGHT: 7,
+}
+
+// sortedPositions devuelve las posiciones de un mapa de detalles en un orden estable.
+func sortedPositions[T any](details map[string]T) []string {
+	positions := make([]string, 0, len(details))
+	for pos := range details {
+		positions = append(positions, pos)
+	}
+	sort.Slice(positions, func(i, j int) bool {
+		oi, iok := positionOrder[positions[i]]
+		oj, jok := positionOrder[positions[j]]
+		if iok != jok {
+			return iok
+		}
+		if oi != oj {
+			return oi < oj
+		}
+		return positions[i] < positions[j]
+	})
+	return positions
+}
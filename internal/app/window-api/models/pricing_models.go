@@ -0,0 +1,325 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/mvialf/windraw/internal/pkg/constants"
+)
+
+// PriceList son los precios unitarios de un presupuesto que no salen del catálogo de stock.
+type PriceList struct {
+	ProfilePricing   string             `json:"profile_pricing"`             // constants.PROFILE_PRICING_*
+	GlassPerM2       map[string]float64 `json:"glass_per_m2,omitempty"`      // Precio por m² según GlassSpec.Type
+	HardwarePerWind  map[string]float64 `json:"hardware_per_wind,omitempty"` // Precio del herraje de una hoja según Wind.Kind
+	LabourPerElement float64            `json:"labour_per_element"`          // Monto fijo por elemento
+	LabourPerM2      float64            `json:"labour_per_m2"`               // Monto por m² de elemento
+}
+
+// Validate comprueba la forma de valorizar los perfiles y que no haya precios negativos.
+func (p PriceList) Validate() error {
+	modes := []string{constants.PROFILE_PRICING_BARS, constants.PROFILE_PRICING_METERS}
+	if !IsValidOption(p.ProfilePricing, modes) {
+		return fmt.Errorf("valorización de perfiles inválida: '%s'. Válidas: %v", p.ProfilePricing, modes)
+	}
+	if p.LabourPerElement < 0 || p.LabourPerM2 < 0 {
+		return errors.New("los precios de mano de obra no pueden ser negativos")
+	}
+	for glassType, price := range p.GlassPerM2 {
+		if price < 0 {
+			return fmt.Errorf("el precio del vidrio '%s' no puede ser negativo", glassType)
+		}
+	}
+	for kind, price := range p.HardwarePerWind {
+		if price < 0 {
+			return fmt.Errorf("el precio del herraje de '%s' no puede ser negativo", kind)
+		}
+	}
+	return nil
+}
+
+// ProfilePrice es el precio de un material de perfil según su ítem de stock.
+type ProfilePrice struct {
+	BarPrice    float64 `json:"bar_price"`     // profile_price
+	BarLengthMM int     `json:"bar_length_mm"` // profile_length
+}
+
+// PerMeter devuelve el precio por metro de barra, o 0 si no se conoce el largo.
+func (p ProfilePrice) PerMeter() float64 {
+	if p.BarLengthMM <= 0 {
+		return 0
+	}
+	return p.BarPrice * 1000 / float64(p.BarLengthMM)
+}
+
+// QuoteLine es una línea del presupuesto de un elemento.
+type QuoteLine struct {
+	Category    string  `json:"category"` // constants.QUOTE_CATEGORY_*
+	Description string  `json:"description"`
+	SKU         string  `json:"sku,omitempty"`
+	Quantity    float64 `json:"quantity"`
+	Unit        string  `json:"unit"` // "barra", "m", "m²" o "u"
+	UnitPrice   float64 `json:"unit_price"`
+	Amount      float64 `json:"amount"`
+}
+
+// ElementQuote es el presupuesto de un elemento, línea por línea.
+type ElementQuote struct {
+	ElementID string      `json:"element_id"`
+	Ref       string      `json:"ref"` // Referencia "C<componente>-M<módulo>-E<elemento>"
+	Lines     []QuoteLine `json:"lines"`
+	Subtotal  float64     `json:"subtotal"`
+	Drawing   string      `json:"drawing,omitempty"` // Elevación en SVG; se omite si el presupuesto se pidió sin dibujos
+}
+
+// ModuleQuote es el presupuesto de un módulo.
+type ModuleQuote struct {
+	ModuleID string         `json:"module_id"`
+	Elements []ElementQuote `json:"elements"`
+	Subtotal float64        `json:"subtotal"`
+}
+
+// ComponentQuote es el presupuesto de un componente.
+type ComponentQuote struct {
+	ComponentID string        `json:"component_id"`
+	Modules     []ModuleQuote `json:"modules"`
+	Subtotal    float64       `json:"subtotal"`
+}
+
+// QuoteCost es un costo del proyecto aplicado sobre el total acumulado.
+type QuoteCost struct {
+	ProjectCost
+	Amount       float64 `json:"amount"`
+	RunningTotal float64 `json:"running_total"` // Total neto acumulado después de aplicar este costo
+}
+
+// ProjectQuote es el presupuesto completo de un proyecto.
+type ProjectQuote struct {
+	ProjectID  string           `json:"project_id"`
+	Prices     PriceList        `json:"prices"`
+	Components []ComponentQuote `json:"components"`
+	Subtotal   float64          `json:"subtotal"` // Suma de los elementos
+	Costs      []QuoteCost      `json:"costs"`
+	NetTotal   float64          `json:"net_total"` // Subtotal más los costos del proyecto
+	IvaRate    float64          `json:"iva_rate"`  // Tasa normalizada como fracción (0.19)
+	Iva        float64          `json:"iva"`
+	Total      float64          `json:"total"`
+	Warnings   []string         `json:"warnings,omitempty"` // Ítems sin precio, que se valorizan en 0
+}
+
+// NormalizeRate devuelve una tasa como fracción: los valores mayores o iguales a 1 se interpretan
+// como porcentaje (19 → 0.19) y los menores como fracción (0.19 → 0.19).
+func NormalizeRate(rate float64) float64 {
+	if rate >= 1 {
+		return rate / 100
+	}
+	return rate
+}
+
+// Quote calcula el presupuesto del proyecto con las piezas, vidrios y hojas ya calculados de
+// cada elemento.
+//
+// Los perfiles (y sus refuerzos) se valorizan por material con profilePrices:
+//   - constants.PROFILE_PRICING_BARS: las barras nuevas de cada material en plan, por el precio de
+//     barra. El costo de un material se reparte entre los elementos según el largo de sus piezas.
+//   - constants.PROFILE_PRICING_METERS: los metros de pieza por el precio por metro de la barra.
+//
+// El vidrio se cobra por m² de paño, el herraje por hoja según su tipo y la mano de obra por
+// elemento y por m² de elemento. Al subtotal se le aplican los Costs del proyecto en orden (los
+// porcentajes sobre el total acumulado) y después el IVA, normalizado con NormalizeRate.
+func (p *Project) Quote(prices PriceList, profilePrices map[StockKey]ProfilePrice, plan *CuttingPlan) (*ProjectQuote, error) {
+	if err := prices.Validate(); err != nil {
+		return nil, err
+	}
+	if prices.ProfilePricing == constants.PROFILE_PRICING_BARS && plan == nil {
+		return nil, errors.New("la valorización por barras necesita un plan de corte")
+	}
+
+	q := &quoteBuilder{prices: prices, profilePrices: profilePrices, warned: make(map[string]bool)}
+	if prices.ProfilePricing == constants.PROFILE_PRICING_BARS {
+		q.barShares = q.shareBars(plan)
+	}
+
+	quote := &ProjectQuote{ProjectID: p.ID, Prices: prices, Components: []ComponentQuote{}, Costs: []QuoteCost{}}
+	for c, component := range p.Components {
+		componentQuote := ComponentQuote{ComponentID: component.ID, Modules: []ModuleQuote{}}
+		for m, module := range component.Modules {
+			moduleQuote := ModuleQuote{ModuleID: module.ID, Elements: []ElementQuote{}}
+			for e := range module.Elements {
+				ref := fmt.Sprintf("C%d-M%d-E%d", c+1, m+1, e+1)
+				elementQuote := q.element(&module.Elements[e], ref)
+				moduleQuote.Elements = append(moduleQuote.Elements, elementQuote)
+				moduleQuote.Subtotal += elementQuote.Subtotal
+			}
+			moduleQuote.Subtotal = roundMoney(moduleQuote.Subtotal)
+			componentQuote.Modules = append(componentQuote.Modules, moduleQuote)
+			componentQuote.Subtotal += moduleQuote.Subtotal
+		}
+		componentQuote.Subtotal = roundMoney(componentQuote.Subtotal)
+		quote.Components = append(quote.Components, componentQuote)
+		quote.Subtotal += componentQuote.Subtotal
+	}
+	quote.Subtotal = roundMoney(quote.Subtotal)
+
+	running := quote.Subtotal
+	for _, cost := range p.Costs {
+		amount := cost.Value
+		if cost.IsPercentage {
+			amount = running * cost.Value / 100
+		}
+		running = roundMoney(running + roundMoney(amount))
+		quote.Costs = append(quote.Costs, QuoteCost{ProjectCost: cost, Amount: roundMoney(amount), RunningTotal: running})
+	}
+	quote.NetTotal = running
+	quote.IvaRate = NormalizeRate(p.IvaRate)
+	quote.Iva = roundMoney(quote.NetTotal * quote.IvaRate)
+	quote.Total = roundMoney(quote.NetTotal + quote.Iva)
+	quote.Warnings = q.warnings
+	return quote, nil
+}
+
+// quoteBuilder acumula los datos compartidos entre los elementos de un presupuesto.
+type quoteBuilder struct {
+	prices        PriceList
+	profilePrices map[StockKey]ProfilePrice
+	barShares     map[string]map[StockKey]float64 // Barras nuevas asignadas a cada elemento por material
+	warnings      []string
+	warned        map[string]bool
+}
+
+// warn registra un aviso una sola vez.
+func (q *quoteBuilder) warn(format string, args ...interface{}) {
+	message := fmt.Sprintf(format, args...)
+	if !q.warned[message] {
+		q.warned[message] = true
+		q.warnings = append(q.warnings, message)
+	}
+}
+
+// shareBars reparte las barras nuevas de cada material entre los elementos según el largo de sus piezas.
+func (q *quoteBuilder) shareBars(plan *CuttingPlan) map[string]map[StockKey]float64 {
+	shares := make(map[string]map[StockKey]float64)
+	for _, group := range plan.Groups {
+		for _, piece := range group.Unplaced {
+			q.warn("La pieza %s %s de %s (%d mm) no cabe en la barra de %s y no se valoriza",
+				piece.ProfileSKU, piece.Position, piece.ElementRef, piece.Length, group.StockKey)
+		}
+		if group.PiecesMM <= 0 {
+			continue
+		}
+		for _, bar := range group.Bars {
+			for _, cut := range bar.Cuts {
+				if shares[cut.Piece.ElementID] == nil {
+					shares[cut.Piece.ElementID] = make(map[StockKey]float64)
+				}
+				shares[cut.Piece.ElementID][group.StockKey] +=
+					float64(group.NewBars) * float64(cut.Piece.Length) / float64(group.PiecesMM)
+			}
+		}
+	}
+	return shares
+}
+
+// element arma las líneas de un elemento.
+func (q *quoteBuilder) element(e *Element, ref string) ElementQuote {
+	quote := ElementQuote{ElementID: e.ID, Ref: ref, Lines: []QuoteLine{}}
+	add := func(line QuoteLine) {
+		line.Amount = roundMoney(line.Quantity * line.UnitPrice)
+		quote.Lines = append(quote.Lines, line)
+		quote.Subtotal += line.Amount
+	}
+
+	for _, line := range q.profileLines(e, ref) {
+		add(line)
+	}
+	for _, pane := range e.Glasses {
+		price, ok := q.prices.GlassPerM2[pane.Glass.Type]
+		if !ok {
+			q.warn("Sin precio por m² para el vidrio '%s'", pane.Glass.Type)
+		}
+		add(QuoteLine{
+			Category:    constants.QUOTE_CATEGORY_GLASS,
+			Description: fmt.Sprintf("Vidrio %s %.0f mm %dx%d - %s", pane.Glass.Type, pane.Glass.ThicknessMM, pane.Width, pane.Height, pane.Label),
+			Quantity:    pane.Area,
+			Unit:        "m²",
+			UnitPrice:   price,
+		})
+	}
+	for _, wind := range e.Winds {
+		price, ok := q.prices.HardwarePerWind[wind.Kind]
+		if !ok {
+			q.warn("Sin precio de herraje para '%s'", wind.Kind)
+		}
+		add(QuoteLine{
+			Category:    constants.QUOTE_CATEGORY_HARDWARE,
+			Description: fmt.Sprintf("Herraje %s - %s", wind.Kind, wind.Name),
+			Quantity:    1,
+			Unit:        "u",
+			UnitPrice:   price,
+		})
+	}
+	if q.prices.LabourPerElement > 0 {
+		add(QuoteLine{Category: constants.QUOTE_CATEGORY_LABOUR, Description: "Fabricación e instalación",
+			Quantity: 1, Unit: "u", UnitPrice: q.prices.LabourPerElement})
+	}
+	if q.prices.LabourPerM2 > 0 {
+		add(QuoteLine{Category: constants.QUOTE_CATEGORY_LABOUR, Description: "Mano de obra por superficie",
+			Quantity: e.Area, Unit: "m²", UnitPrice: q.prices.LabourPerM2})
+	}
+	quote.Subtotal = roundMoney(quote.Subtotal)
+	return quote
+}
+
+// profileLines agrupa las piezas del elemento por material y las valoriza según PriceList.ProfilePricing.
+func (q *quoteBuilder) profileLines(e *Element, ref string) []QuoteLine {
+	type material struct {
+		key        StockKey
+		profileSKU string
+		lengthMM   int
+	}
+	var materials []*material
+	byKey := make(map[StockKey]*material)
+	for _, piece := range e.Pieces(ref) {
+		key := piece.StockKey()
+		m, ok := byKey[key]
+		if !ok {
+			m = &material{key: key, profileSKU: piece.ProfileSKU}
+			byKey[key] = m
+			materials = append(materials, m)
+		}
+		m.lengthMM += piece.Length
+	}
+
+	lines := make([]QuoteLine, 0, len(materials))
+	for _, m := range materials {
+		price, ok := q.profilePrices[m.key]
+		if !ok || price.BarPrice <= 0 {
+			q.warn("Sin precio de barra para %s", m.key)
+		}
+		description := "Perfil " + m.profileSKU
+		if m.key.Color != "" {
+			description += " " + m.key.Color
+		}
+		line := QuoteLine{Category: constants.QUOTE_CATEGORY_PROFILE, Description: description, SKU: m.key.ItemSKU}
+		if q.barShares != nil {
+			line.Quantity = math.Round(q.barShares[e.ID][m.key]*1000) / 1000
+			line.Unit = "barra"
+			line.UnitPrice = price.BarPrice
+		} else {
+			line.Quantity = float64(m.lengthMM) / 1000
+			line.Unit = "m"
+			line.UnitPrice = roundMoney(price.PerMeter())
+			if ok && price.BarPrice > 0 && price.BarLengthMM <= 0 {
+				q.warn("Sin largo de barra para %s: no se puede calcular el precio por metro", m.key)
+			}
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// roundMoney redondea un monto a dos decimales.
+func roundMoney(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
@@ -71,7 +71,7 @@ type Project struct {
 	Contact    Contact       `json:"contact"`              // Información de contacto del cliente
 	Costs      []ProjectCost `json:"costs"`                // Lista de costos adicionales asociados al proyecto
 	Components []Component   `json:"components,omitempty"` // Lista de componentes del proyecto (SUGERENCIA: añadido omitempty)
-	IvaRate    float64       `json:"iva_rate"`             // Tasa de IVA aplicable al proyecto (ej: 0.19 para 19%)
+	IvaRate    float64       `json:"iva_rate"`             // Tasa de IVA: fracción (0.19) o porcentaje (19), ver NormalizeRate
 }
 
 // validateContact valida los campos requeridos de la estructura Contact.
@@ -0,0 +1,120 @@
+package models
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// ReinforcementPolicy decide, por material del elemento, qué piezas llevan refuerzo.
+// MinLengthByMaterial asocia cada material a un largo mínimo de pieza en mm:
+// 0 significa que el refuerzo es obligatorio siempre y un valor positivo que solo lo llevan
+// las piezas más largas que ese valor. Los materiales ausentes del mapa nunca se refuerzan.
+type ReinforcementPolicy struct {
+	MinLengthByMaterial map[string]int `json:"min_length_by_material"`
+}
+
+// Requires indica si una pieza de lengthMM mm de un elemento del material indicado debe reforzarse.
+// El material se compara sin distinguir mayúsculas.
+func (p ReinforcementPolicy) Requires(material string, lengthMM int) bool {
+	if lengthMM <= 0 {
+		return false
+	}
+	for name, minLength := range p.MinLengthByMaterial {
+		if strings.EqualFold(name, material) {
+			return lengthMM > minLength
+		}
+	}
+	return false
+}
+
+// ReinforcementLength devuelve el largo de corte del refuerzo para una pieza:
+// el largo de la pieza menos la holgura de reinforcement_gap_mm en cada extremo.
+func ReinforcementLength(pieceLength int, gapMM float64) (int, error) {
+	length := int(math.Round(float64(pieceLength) - 2*gapMM))
+	if length <= 0 {
+		return 0, cutErrorf("la pieza de %d mm es demasiado corta para un refuerzo con holgura de %.1f mm por extremo", pieceLength, gapMM)
+	}
+	return length, nil
+}
+
+// ApplyReinforcement marca el refuerzo de cada pieza del elemento (marco, hojas y montantes) según la política
+// y las filas de profile_reinforcements, indexadas por main_profile_id.
+// Debe llamarse después de calcular las dimensiones, ya que CalculateFrameDetails y
+// CalculateWindDetails limpian los datos de refuerzo. Las piezas cuyo perfil no tiene refuerzo
+// en el catálogo (ej. junquillos) quedan sin reforzar aunque la política lo pida.
+func (e *Element) ApplyReinforcement(policy ReinforcementPolicy, reinforcements map[int64]ProfileReinforcement) error {
+	if err := e.Frame.ApplyReinforcement(e.Material, policy, reinforcements); err != nil {
+		return err
+	}
+	for i := range e.Winds {
+		if err := e.Winds[i].ApplyReinforcement(e.Material, policy, reinforcements); err != nil {
+			return err
+		}
+	}
+	for _, detail := range e.Layout.MullionDetails() {
+		used, sku, profileID, length, err := resolveReinforcement(e.Material, detail.ProfileID, detail.Dimension, policy, reinforcements)
+		if err != nil {
+			return fmt.Errorf("refuerzo de '%s': %w", detail.Position, err)
+		}
+		detail.ReinforcedUsed = used
+		detail.ReinforcedSKU = sku
+		detail.ReinforcedProfileID = profileID
+		detail.ReinforcedLength = length
+	}
+	return nil
+}
+
+// ApplyReinforcement marca el refuerzo de cada pieza del marco. Ver Element.ApplyReinforcement.
+func (f *Frame) ApplyReinforcement(material string, policy ReinforcementPolicy, reinforcements map[int64]ProfileReinforcement) error {
+	for pos, detail := range f.Details {
+		used, sku, profileID, length, err := resolveReinforcement(material, detail.ProfileID, detail.Dimension, policy, reinforcements)
+		if err != nil {
+			return fmt.Errorf("refuerzo del marco en posición '%s': %w", pos, err)
+		}
+		detail.ReinforcedUsed = used
+		detail.ReinforcedSKU = sku
+		detail.ReinforcedProfileID = profileID
+		detail.ReinforcedLength = length
+		f.Details[pos] = detail
+	}
+	return nil
+}
+
+// ApplyReinforcement marca el refuerzo de cada pieza de la hoja. Ver Element.ApplyReinforcement.
+func (w *Wind) ApplyReinforcement(material string, policy ReinforcementPolicy, reinforcements map[int64]ProfileReinforcement) error {
+	for pos, detail := range w.Details {
+		used, sku, profileID, length, err := resolveReinforcement(material, detail.ProfileID, detail.Dimension, policy, reinforcements)
+		if err != nil {
+			return fmt.Errorf("refuerzo de la hoja '%s' en posición '%s': %w", w.Name, pos, err)
+		}
+		detail.ReinforcedUsed = used
+		detail.ReinforcedSKU = sku
+		detail.ReinforcedProfileID = profileID
+		detail.ReinforcedLength = length
+		w.Details[pos] = detail
+	}
+	return nil
+}
+
+// resolveReinforcement decide el refuerzo de una pieza y devuelve (usado, SKU, profile_id, largo).
+func resolveReinforcement(material string, profileID int64, dimension int, policy ReinforcementPolicy,
+	reinforcements map[int64]ProfileReinforcement) (bool, string, int64, int, error) {
+
+	if profileID == 0 || !policy.Requires(material, dimension) {
+		return false, "", 0, 0, nil
+	}
+	reinforcement, ok := reinforcements[profileID]
+	if !ok {
+		return false, "", 0, 0, nil
+	}
+	length, err := ReinforcementLength(dimension, reinforcement.GapMM)
+	if err != nil {
+		return false, "", 0, 0, err
+	}
+	sku := ""
+	if reinforcement.Reinforcement != nil {
+		sku = reinforcement.Reinforcement.SKU
+	}
+	return true, sku, reinforcement.ReinforcementProfileID, length, nil
+}
@@ -0,0 +1,74 @@
+package models
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+// Remnant es un retazo de barra guardado en el taller para cortarlo en proyectos posteriores.
+type Remnant struct {
+	ID        string    `json:"id"`
+	ItemSKU   string    `json:"item_sku"`
+	Color     string    `json:"color"`
+	LengthMM  int       `json:"length_mm"`
+	Location  string    `json:"location"`             // Ubicación física (estante, carro, rack...)
+	ProjectID string    `json:"project_id,omitempty"` // Proyecto cuyo corte lo generó
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// NewRemnant crea un retazo validando SKU y largo. El color y la ubicación pueden ir vacíos.
+func NewRemnant(itemSKU, color string, lengthMM int, location, projectID string) (*Remnant, error) {
+	itemSKU = strings.TrimSpace(itemSKU)
+	if itemSKU == "" {
+		return nil, errors.New("el SKU del retazo no puede estar vacío")
+	}
+	if lengthMM <= 0 {
+		return nil, errors.New("el largo del retazo debe ser positivo")
+	}
+	return &Remnant{
+		ID:        generateID(),
+		ItemSKU:   itemSKU,
+		Color:     strings.TrimSpace(color),
+		LengthMM:  lengthMM,
+		Location:  strings.TrimSpace(location),
+		ProjectID: projectID,
+		CreatedAt: time.Now(),
+	}, nil
+}
+
+// StockKey devuelve la clave de material del retazo.
+func (r Remnant) StockKey() StockKey {
+	return StockKey{ItemSKU: r.ItemSKU, Color: r.Color}
+}
+
+// Offcut devuelve el retazo en el formato que usa el optimizador de corte.
+func (r Remnant) Offcut() Offcut {
+	return Offcut{ID: r.ID, ItemSKU: r.ItemSKU, Color: r.Color, LengthMM: r.LengthMM}
+}
+
+// RemnantFilter selecciona retazos por material y largo. Los campos vacíos no filtran.
+type RemnantFilter struct {
+	ItemSKU     string
+	Color       string
+	MinLengthMM int
+}
+
+// Matches indica si el retazo cumple el filtro.
+func (f RemnantFilter) Matches(r Remnant) bool {
+	if f.ItemSKU != "" && r.ItemSKU != f.ItemSKU {
+		return false
+	}
+	if f.Color != "" && r.Color != f.Color {
+		return false
+	}
+	return r.LengthMM >= f.MinLengthMM
+}
+
+// RecordedPlan identifica un plan de corte ya registrado contra el inventario de retazos, para no
+// registrarlo dos veces.
+type RecordedPlan struct {
+	PlanID     string    `json:"plan_id"`
+	ProjectID  string    `json:"project_id,omitempty"`
+	RecordedAt time.Time `json:"recorded_at"`
+}
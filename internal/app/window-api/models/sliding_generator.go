@@ -0,0 +1,228 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/mvialf/windraw/internal/pkg/constants"
+)
+
+// slidingLayoutPattern reconoce "N hojas", "N hojas en R rieles" y "N hojas K centrales móviles".
+var slidingLayoutPattern = regexp.MustCompile(`^(\d+)\s+hojas?(?:\s+en\s+(\d+)\s+rieles?)?(?:\s+(\d+)\s+central(?:es)?\s+m[oó]vil(?:es)?)?$`)
+
+// SlidingLayout describe cómo se reparten las hojas de una corredera.
+type SlidingLayout struct {
+	Name           string `json:"name"`
+	Panels         int    `json:"panels"`          // Número de hojas
+	Tracks         int    `json:"tracks"`          // Número de rieles usados
+	CentralMovable int    `json:"central_movable"` // Si es > 0, solo las hojas centrales son móviles y el resto fijas
+}
+
+// ParseSlidingLayout interpreta una disposición como constants.SLIDING_LAYOUT_2_PANELS.
+// Sin "en R rieles" se usan 2 rieles.
+func ParseSlidingLayout(name string) (SlidingLayout, error) {
+	normalized := strings.ToLower(strings.Join(strings.Fields(name), " "))
+	m := slidingLayoutPattern.FindStringSubmatch(normalized)
+	if m == nil {
+		return SlidingLayout{}, fmt.Errorf("disposición de corredera no reconocida: '%s'. Ejemplos: '%s', '%s', '%s'", name,
+			constants.SLIDING_LAYOUT_2_PANELS, constants.SLIDING_LAYOUT_3_PANELS_3_TRACKS, constants.SLIDING_LAYOUT_4_PANELS_2_CENTER)
+	}
+	layout := SlidingLayout{Name: name, Tracks: 2}
+	layout.Panels, _ = strconv.Atoi(m[1])
+	if m[2] != "" {
+		layout.Tracks, _ = strconv.Atoi(m[2])
+	}
+	if m[3] != "" {
+		layout.CentralMovable, _ = strconv.Atoi(m[3])
+	}
+
+	switch {
+	case layout.Panels < 2:
+		return SlidingLayout{}, fmt.Errorf("una corredera necesita al menos 2 hojas, se pidieron %d", layout.Panels)
+	case layout.Tracks < 2 || layout.Tracks > layout.Panels:
+		return SlidingLayout{}, fmt.Errorf("número de rieles inválido (%d) para %d hojas", layout.Tracks, layout.Panels)
+	case layout.CentralMovable > 0 && layout.Tracks != 2:
+		return SlidingLayout{}, errors.New("las hojas centrales móviles requieren una disposición en 2 rieles")
+	case layout.CentralMovable > 0 && (layout.CentralMovable >= layout.Panels || (layout.Panels-layout.CentralMovable)%2 != 0):
+		return SlidingLayout{}, fmt.Errorf("no se pueden centrar %d hojas móviles entre %d hojas", layout.CentralMovable, layout.Panels)
+	}
+	return layout, nil
+}
+
+// trackOf devuelve el riel (base 0, 0 = exterior) de la hoja i.
+// Con hojas centrales móviles, estas van en el riel interior y las fijas en el exterior;
+// en el resto de casos las hojas se reparten alternando rieles para que las contiguas se traslapen.
+func (l SlidingLayout) trackOf(i int) int {
+	if l.CentralMovable > 0 {
+		if l.isCentral(i) {
+			return 1
+		}
+		return 0
+	}
+	return i % l.Tracks
+}
+
+// isCentral indica si la hoja i está entre las CentralMovable hojas centrales.
+func (l SlidingLayout) isCentral(i int) bool {
+	first := (l.Panels - l.CentralMovable) / 2
+	return i >= first && i < first+l.CentralMovable
+}
+
+// SlidingProfiles son los perfiles del sistema que intervienen en el dimensionado de una corredera.
+type SlidingProfiles struct {
+	Frame   Profile  // constants.PROFILE_TYPE_SLIDING_FRAME
+	Wind    Profile  // constants.PROFILE_TYPE_SLIDING_WIND
+	Overlap *Profile // constants.PROFILE_TYPE_SLIDING_OVERLAP; opcional
+}
+
+// GenerateSlidingWinds deriva las hojas de una corredera a partir del marco, la disposición y el sistema.
+//
+// Dimensionado (mm):
+//   - Luz del marco: ancho/alto exterior menos dos veces el ancho W del perfil de marco.
+//   - Alto de hoja: luz vertical + top_overlap_mm + bottom_overlap_mm del sistema.
+//   - Ancho de hoja: (luz horizontal + 2·side_overlap_mm + traslapos) / hojas, donde cada encuentro
+//     entre hojas de rieles distintos suma el encaje InterlockMM del traslapo (o del perfil de
+//     hoja si no hay perfil de traslapo). Las hojas contiguas en el mismo riel topan sin traslapo.
+//
+// Las hojas con algún encuentro traslapado usan CUT_VERTICAL_OVERLAP_WIND si el perfil de hoja
+// tiene uses_overlap; el resto usa el tipo de corte del perfil (CUT_ANGLE_WIND si no tiene).
+// Cada encuentro traslapado añade una pieza de traslapo (POSITION_OVERLAP_*) a cada hoja.
+func GenerateSlidingWinds(frame Frame, layout SlidingLayout, system ProfileSystem, profiles SlidingProfiles) ([]Wind, error) {
+	if profiles.Frame.TrackCount > 0 && layout.Tracks > profiles.Frame.TrackCount {
+		return nil, fmt.Errorf("la disposición '%s' usa %d rieles y el marco %s solo tiene %d",
+			layout.Name, layout.Tracks, profiles.Frame.SKU, profiles.Frame.TrackCount)
+	}
+
+	lightWidth := float64(frame.Width) - 2*profiles.Frame.W
+	lightHeight := float64(frame.Height) - 2*profiles.Frame.W
+	if lightWidth <= 0 || lightHeight <= 0 {
+		return nil, fmt.Errorf("el marco de %dx%d mm no deja luz con el perfil %s (W=%.1f mm)",
+			frame.Width, frame.Height, profiles.Frame.SKU, profiles.Frame.W)
+	}
+
+	meetingOverlap := profiles.Wind.InterlockMM
+	if profiles.Overlap != nil {
+		meetingOverlap = profiles.Overlap.InterlockMM
+	}
+	overlapped := make([]bool, layout.Panels-1) // overlapped[i]: encuentro entre la hoja i y la i+1
+	totalWidth := lightWidth + 2*system.SideOverlapMM
+	for i := range overlapped {
+		overlapped[i] = layout.trackOf(i) != layout.trackOf(i+1)
+		if overlapped[i] {
+			totalWidth += meetingOverlap
+		}
+	}
+
+	height := int(math.Round(lightHeight + system.TopOverlapMM + system.BottomOverlapMM))
+	baseWidth := int(math.Floor(totalWidth / float64(layout.Panels)))
+	remainder := int(math.Round(totalWidth)) - baseWidth*layout.Panels
+
+	defaultCut := profiles.Wind.CutType
+	if defaultCut == "" {
+		defaultCut = constants.CUT_ANGLE_WIND
+	}
+	validKinds := []string{constants.WIND_KIND_SLIDING_MOVIL, constants.WIND_KIND_SLIDING_FIXED}
+
+	winds := make([]Wind, 0, layout.Panels)
+	for i := 0; i < layout.Panels; i++ {
+		width := baseWidth
+		if i < remainder {
+			width++ // Los mm sobrantes del redondeo se reparten desde la primera hoja
+		}
+
+		overlapLeft := i > 0 && overlapped[i-1]
+		overlapRight := i < layout.Panels-1 && overlapped[i]
+		cutType := defaultCut
+		if profiles.Wind.UsesOverlap && (overlapLeft || overlapRight) {
+			cutType = constants.CUT_VERTICAL_OVERLAP_WIND
+		}
+
+		kind := constants.WIND_KIND_SLIDING_MOVIL
+		if layout.CentralMovable > 0 && !layout.isCentral(i) {
+			kind = constants.WIND_KIND_SLIDING_FIXED
+		}
+
+		positions := []string{constants.POSITION_LEFT, constants.POSITION_RIGHT, constants.POSITION_TOP, constants.POSITION_BOTTOM}
+		if profiles.Overlap != nil && overlapLeft {
+			positions = append(positions, constants.POSITION_OVERLAP_LEFT)
+		}
+		if profiles.Overlap != nil && overlapRight {
+			positions = append(positions, constants.POSITION_OVERLAP_RIGHT)
+		}
+
+		name := fmt.Sprintf("Hoja %d %s", i+1, strings.TrimPrefix(kind, "Hoja corredera "))
+		wind, err := NewWind(name, kind, width, height, cutType, constants.WIND_STATUS_ACTIVE, positions,
+			validKinds, []string{cutType})
+		if err != nil {
+			return nil, fmt.Errorf("error creando la hoja %d: %w", i+1, err)
+		}
+		wind.Track = layout.trackOf(i) + 1
+
+		for _, pos := range positions {
+			profile := profiles.Wind
+			if pos == constants.POSITION_OVERLAP_LEFT || pos == constants.POSITION_OVERLAP_RIGHT {
+				profile = *profiles.Overlap
+			}
+			if err := wind.assignProfile(pos, profile); err != nil {
+				return nil, err
+			}
+		}
+		winds = append(winds, *wind)
+	}
+	return winds, nil
+}
+
+// SetSlidingWinds reemplaza las hojas del elemento por las generadas con GenerateSlidingWinds
+// y asigna el perfil de marco de corredera a las posiciones del marco que aún no tienen perfil.
+func (e *Element) SetSlidingWinds(layout SlidingLayout, system ProfileSystem, profiles SlidingProfiles) error {
+	if e.Type != constants.TYPE_SLIDING {
+		return fmt.Errorf("el elemento %s es de tipo '%s', no '%s'", e.ID, e.Type, constants.TYPE_SLIDING)
+	}
+	if err := e.Frame.requireRectangular("generar hojas correderas"); err != nil {
+		return err
+	}
+	winds, err := GenerateSlidingWinds(e.Frame, layout, system, profiles)
+	if err != nil {
+		return err
+	}
+	if err := e.Frame.assignMissingProfile(profiles.Frame); err != nil {
+		return err
+	}
+	e.Winds = winds
+	return nil
+}
+
+// assignMissingProfile asigna el perfil a las posiciones del marco sin SKU, sin color.
+func (f *Frame) assignMissingProfile(profile Profile) error {
+	for pos, detail := range f.Details {
+		if detail.ProfileSKU != "" || profile.SKU == "" {
+			continue
+		}
+		if err := f.SetFrameProfile(pos, profile.SKU, ""); err != nil {
+			return err
+		}
+		detail = f.Details[pos]
+		detail.ProfileID = profile.ID
+		f.Details[pos] = detail
+	}
+	return nil
+}
+
+// assignProfile asigna SKU y profile_id del perfil a una posición de la hoja, sin color.
+// Los perfiles sin SKU se ignoran.
+func (w *Wind) assignProfile(position string, profile Profile) error {
+	if profile.SKU == "" {
+		return nil
+	}
+	if err := w.SetWindProfile(position, profile.SKU, ""); err != nil {
+		return err
+	}
+	detail := w.Details[position]
+	detail.ProfileID = profile.ID
+	w.Details[position] = detail
+	return nil
+}
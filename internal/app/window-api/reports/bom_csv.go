@@ -0,0 +1,68 @@
+// Package reports genera los documentos de taller y compras de un proyecto (listas de materiales,
+// listas de corte) en formatos para planilla o impresión.
+package reports
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+
+	"github.com/mvialf/windraw/internal/app/window-api/models"
+)
+
+// bomHeader son las columnas del CSV de la lista de materiales.
+var bomHeader = []string{
+	"SKU ítem", "Color", "SKU perfil", "Perfil", "Refuerzo", "Piezas", "Metros",
+	"Largo barra (mm)", "Barras", "Retazos", "kg/m", "Peso (kg)",
+}
+
+// WriteBOMCSV escribe la lista de materiales como CSV (separado por comas, punto decimal),
+// con una fila por material y una fila final de totales.
+func WriteBOMCSV(w io.Writer, bom *models.BillOfMaterials) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(bomHeader); err != nil {
+		return err
+	}
+	for _, line := range bom.Lines {
+		reinforcement := "No"
+		if line.Reinforcement {
+			reinforcement = "Sí"
+		}
+		record := []string{
+			line.ItemSKU,
+			line.Color,
+			line.ProfileSKU,
+			line.ProfileName,
+			reinforcement,
+			strconv.Itoa(line.Pieces),
+			formatDecimal(line.Meters, 3),
+			strconv.Itoa(line.BarLengthMM),
+			strconv.Itoa(line.Bars),
+			strconv.Itoa(line.OffcutsUsed),
+			formatDecimal(line.WeightPerMeter, 3),
+			formatDecimal(line.WeightKg, 2),
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+	total := []string{
+		"Total", "", "", "", "",
+		strconv.Itoa(bom.TotalPieces),
+		formatDecimal(bom.TotalMeters, 3),
+		"",
+		strconv.Itoa(bom.TotalBars),
+		"", "",
+		formatDecimal(bom.TotalWeightKg, 2),
+	}
+	if err := writer.Write(total); err != nil {
+		return err
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// formatDecimal formatea un número con punto decimal y la cantidad de decimales indicada.
+func formatDecimal(value float64, decimals int) string {
+	return strconv.FormatFloat(value, 'f', decimals, 64)
+}
@@ -0,0 +1,223 @@
+package reports
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+
+	"github.com/mvialf/windraw/internal/app/window-api/models"
+	"github.com/mvialf/windraw/internal/pkg/pdf"
+)
+
+// cuttingListHeader son las columnas del CSV de la lista de corte.
+var cuttingListHeader = []string{
+	"Código", "SKU ítem", "Color", "SKU perfil", "Elemento", "Pieza", "Posición", "Largo (mm)",
+	"Ángulo izq.", "Ángulo der.", "Radio (mm)", "SKU refuerzo", "Largo refuerzo (mm)",
+}
+
+// WriteCuttingListCSV escribe la lista de corte como CSV, una fila por pieza en el orden de la lista.
+func WriteCuttingListCSV(w io.Writer, list *models.CuttingList) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(cuttingListHeader); err != nil {
+		return err
+	}
+	for _, group := range list.Groups {
+		for _, piece := range group.Pieces {
+			record := []string{
+				piece.Code,
+				group.ItemSKU,
+				group.Color,
+				piece.ProfileSKU,
+				piece.ElementRef,
+				piece.Owner,
+				piece.Position,
+				strconv.Itoa(piece.Length),
+				formatAngle(piece.AngleLeft),
+				formatAngle(piece.AngleRight),
+				optionalInt(piece.BendRadius),
+				piece.ReinforcedSKU,
+				optionalInt(piece.ReinforcedLength),
+			}
+			if err := writer.Write(record); err != nil {
+				return err
+			}
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// Diseño de la lista de corte en PDF: A4 apaisado, tabla en Courier para alinear columnas.
+const (
+	listMargin     = 36.0
+	listFontSize   = 9.0
+	listRowHeight  = 13.0
+	listTitleSize  = 14.0
+	listHeaderTop  = 48.0 // Alto reservado para el título
+	listFooterSize = 8.0
+)
+
+// listColumn es una columna de la tabla de la lista de corte, con su ancho en caracteres.
+type listColumn struct {
+	title string
+	chars int
+	right bool // Alineada a la derecha (números)
+}
+
+var listColumns = []listColumn{
+	{"Código", 16, false},
+	{"Elemento", 10, false},
+	{"Pieza", 20, false},
+	{"Posición", 18, false},
+	{"Largo", 7, true},
+	{"Á.izq", 6, true},
+	{"Á.der", 6, true},
+	{"Radio", 6, true},
+	{"Refuerzo", 14, false},
+	{"L.acero", 7, true},
+	{"OK", 4, false},
+}
+
+// listRow es una fila de la tabla: el encabezado de un grupo o una pieza.
+type listRow struct {
+	group *models.CuttingListGroup
+	piece *models.Piece
+}
+
+// WriteCuttingListPDF escribe la lista de corte como PDF A4 apaisado paginado. Cada página repite
+// el título y los encabezados de columna; un grupo que sigue en la página siguiente repite su
+// encabezado. La última columna queda libre para que el operador marque las piezas cortadas.
+func WriteCuttingListPDF(w io.Writer, list *models.CuttingList) error {
+	doc := pdf.New(pdf.A4Height, pdf.A4Width)
+	charWidth := pdf.MonoWidth(listFontSize, "M")
+	top := doc.Height() - listMargin - listHeaderTop
+	rowsPerPage := int((top - listMargin - 2*listFooterSize) / listRowHeight)
+
+	// Reparte las filas en páginas: la primera fila de cada página es el encabezado de columnas
+	// y un encabezado de grupo nunca queda solo al final de una página.
+	var pages [][]listRow
+	var current []listRow
+	newPage := func(continued *models.CuttingListGroup) {
+		if current != nil {
+			pages = append(pages, current)
+		}
+		current = []listRow{}
+		if continued != nil {
+			current = append(current, listRow{group: continued})
+		}
+	}
+	newPage(nil)
+	for gi := range list.Groups {
+		group := &list.Groups[gi]
+		if len(current)+2 > rowsPerPage-1 {
+			newPage(nil)
+		}
+		current = append(current, listRow{group: group})
+		for pi := range group.Pieces {
+			if len(current) >= rowsPerPage-1 {
+				newPage(group)
+			}
+			current = append(current, listRow{piece: &group.Pieces[pi]})
+		}
+	}
+	pages = append(pages, current)
+
+	for pageIndex, rows := range pages {
+		page := doc.AddPage()
+		title := fmt.Sprintf("Lista de corte - %s", list.ProjectName)
+		page.Text(listMargin, doc.Height()-listMargin-listTitleSize, pdf.HelveticaBold, listTitleSize, title)
+		page.Text(listMargin, doc.Height()-listMargin-listTitleSize-14, pdf.Helvetica, listFooterSize+1,
+			fmt.Sprintf("Proyecto %s · %d piezas en %d materiales · ordenadas de mayor a menor largo",
+				list.ProjectID, list.TotalPieces, len(list.Groups)))
+
+		y := top
+		writeRow := func(font pdf.Font, cells []string) {
+			x := listMargin
+			for i, column := range listColumns {
+				text := fitText(cells[i], column.chars)
+				cellX := x
+				if column.right {
+					cellX = x + float64(column.chars)*charWidth - pdf.MonoWidth(listFontSize, text)
+				}
+				page.Text(cellX, y, font, listFontSize, text)
+				x += float64(column.chars+1) * charWidth
+			}
+		}
+		tableWidth := 0.0
+		for _, column := range listColumns {
+			tableWidth += float64(column.chars+1) * charWidth
+		}
+
+		titles := make([]string, len(listColumns))
+		for i, column := range listColumns {
+			titles[i] = column.title
+		}
+		writeRow(pdf.CourierBold, titles)
+		page.Line(listMargin, y-3, listMargin+tableWidth, y-3, 0.8)
+		y -= listRowHeight
+
+		for _, row := range rows {
+			if row.piece == nil {
+				page.SetGray(0.88)
+				page.Rect(listMargin, y-3.5, tableWidth, listRowHeight, true)
+				page.SetGray(0)
+				label := fmt.Sprintf("%s  ·  perfil %s  ·  %d piezas, %.2f m",
+					row.group.StockKey, row.group.ProfileSKU, len(row.group.Pieces), float64(row.group.TotalMM)/1000)
+				page.Text(listMargin+2, y, pdf.CourierBold, listFontSize, fitText(label, int(tableWidth/charWidth)-1))
+				y -= listRowHeight
+				continue
+			}
+			piece := row.piece
+			writeRow(pdf.Courier, []string{
+				piece.Code,
+				piece.ElementRef,
+				piece.Owner,
+				piece.Position,
+				strconv.Itoa(piece.Length),
+				formatAngle(piece.AngleLeft),
+				formatAngle(piece.AngleRight),
+				optionalInt(piece.BendRadius),
+				piece.ReinforcedSKU,
+				optionalInt(piece.ReinforcedLength),
+				"[ ]",
+			})
+			page.SetGray(0.75)
+			page.Line(listMargin, y-3.5, listMargin+tableWidth, y-3.5, 0.3)
+			page.SetGray(0)
+			y -= listRowHeight
+		}
+
+		footer := fmt.Sprintf("Página %d de %d", pageIndex+1, len(pages))
+		page.Text(doc.Width()-listMargin-pdf.MonoWidth(listFooterSize, footer), listMargin, pdf.Courier, listFooterSize, footer)
+	}
+
+	_, err := doc.WriteTo(w)
+	return err
+}
+
+// fitText recorta text a chars caracteres, marcando el recorte con "…".
+func fitText(text string, chars int) string {
+	runes := []rune(text)
+	if len(runes) <= chars {
+		return text
+	}
+	if chars <= 1 {
+		return string(runes[:chars])
+	}
+	return string(runes[:chars-1]) + "…"
+}
+
+// formatAngle formatea un ángulo en grados con hasta un decimal ("45", "22.5").
+func formatAngle(angle float64) string {
+	return strconv.FormatFloat(math.Round(angle*10)/10, 'f', -1, 64)
+}
+
+// optionalInt formatea un entero, o lo deja vacío si es cero.
+func optionalInt(value int) string {
+	if value == 0 {
+		return ""
+	}
+	return strconv.Itoa(value)
+}
@@ -0,0 +1,500 @@
+package reports
+
+import (
+	"math"
+	"sort"
+	"strconv"
+
+	"github.com/mvialf/windraw/internal/app/window-api/models"
+	"github.com/mvialf/windraw/internal/pkg/constants"
+)
+
+// ElevationOptions son las opciones del dibujo de elevación de un elemento.
+type ElevationOptions struct {
+	Scale         int                // Escala de impresión 1:Scale; 20 por defecto
+	ProfileWidths map[string]float64 // Ancho visto W (mm) de cada SKU de perfil
+	Title         string             // Texto bajo el dibujo, p. ej. la referencia del elemento
+}
+
+// Medidas del dibujo en mm de papel; se multiplican por la escala para llevarlas al modelo.
+const (
+	defaultElevationScale   = 20
+	defaultProfileBandMM    = 60.0 // Ancho visto de los perfiles que no están en ProfileWidths
+	elevationTextSize       = 2.5
+	elevationDimensionGap   = 8.0 // Distancia entre el elemento y cada fila de cotas
+	elevationTickSize       = 1.2
+	elevationMargin         = 6.0
+	elevationArcSegments    = 48
+	elevationDashMM         = 2.0 // Trazo y espacio de las líneas discontinuas
+	elevationDashGapMM      = 1.0
+	elevationTitleRows      = 2 // Alto reservado para el título, en tamaños de texto
+	elevationSymbolTextSize = 2 * elevationTextSize
+)
+
+// Capas del dibujo. En DXF son las capas del archivo; en SVG definen el relleno de cada parte.
+const (
+	layerFrame      = "frame"      // Marco, montantes y travesaños
+	layerSash       = "sash"       // Hojas y sus símbolos de apertura
+	layerGlass      = "glass"      // Vidrios
+	layerDimensions = "dimensions" // Cotas
+	layerText       = "text"       // Título y marcas de hoja fija
+)
+
+// modelRect es un rectángulo en mm del modelo, con origen en la esquina inferior izquierda.
+type modelRect struct {
+	x, y, w, h float64
+}
+
+func (r modelRect) inset(d float64) modelRect {
+	return modelRect{r.x + d, r.y + d, r.w - 2*d, r.h - 2*d}
+}
+
+// elevationCanvas recibe el dibujo en mm del modelo: X hacia la derecha desde el borde izquierdo
+// del marco e Y hacia arriba desde su base. Cada formato de salida lo implementa.
+type elevationCanvas interface {
+	line(layer string, a, b models.Point, dashed bool)
+	rect(layer string, r modelRect)
+	polygon(layer string, points []models.Point)
+	// ring dibuja la banda entre dos contornos cerrados, como la del marco.
+	ring(layer string, outer, inner []models.Point)
+	// text escribe value centrado sobre at, que queda en su línea base; vertical lo gira 90°
+	// para leerlo desde la derecha.
+	text(layer string, at models.Point, size float64, vertical bool, value string)
+}
+
+// elevation dibuja un elemento en un elevationCanvas.
+type elevation struct {
+	canvas  elevationCanvas
+	element *models.Element
+	opts    ElevationOptions
+	scale   float64
+}
+
+// elevationExtents devuelve cuánto ocupan las cotas y el título alrededor del elemento, en mm del
+// modelo: dos filas de cotas abajo y a la izquierda, una a la derecha y el título abajo.
+func elevationExtents(scale float64) (left, right, top, bottom float64) {
+	margin := elevationMargin * scale
+	left = 2*elevationDimensionGap*scale + margin
+	right = elevationDimensionGap*scale + margin
+	top = margin
+	bottom = 2*elevationDimensionGap*scale + margin + elevationTitleRows*elevationTextSize*scale
+	return left, right, top, bottom
+}
+
+// drawElevation dibuja la elevación del elemento, vista desde el interior: marco, montantes y
+// travesaños, hojas con su símbolo de apertura, vidrios y cotas de ancho y alto en mm.
+//
+// Símbolos de apertura (norma habitual de planos de carpintería):
+//   - Abatir, proyectante y oscilante: triángulo con el vértice en el centro del lado de las
+//     bisagras (Wind.OpeningSide). Línea continua si abre hacia el interior, discontinua si abre
+//     hacia el exterior.
+//   - Oscilobatiente: el triángulo de abatir más el de oscilar, con el vértice abajo.
+//   - Corredera móvil: flecha hacia donde desliza la hoja; doble si puede deslizar a ambos lados.
+//   - Hojas fijas: una "F" en el centro.
+func drawElevation(canvas elevationCanvas, element *models.Element, opts ElevationOptions) error {
+	if opts.Scale <= 0 {
+		opts.Scale = defaultElevationScale
+	}
+	outline, err := element.Frame.Outline()
+	if err != nil {
+		return err
+	}
+	e := &elevation{canvas: canvas, element: element, opts: opts, scale: float64(opts.Scale)}
+	width, height := float64(element.Frame.Width), float64(element.Frame.Height)
+
+	outer, vertices := flattenOutline(outline)
+	frameBand := e.band(frameSKU(element.Frame))
+	inner := insetPolygon(outer, frameBand)
+
+	canvas.polygon(layerGlass, inner)
+	e.mullions(element.Layout)
+	e.winds(modelRect{frameBand, frameBand, width - 2*frameBand, height - 2*frameBand})
+	e.frame(outer, inner, vertices)
+	e.dimensions(outline)
+
+	if opts.Title != "" {
+		_, _, _, bottom := elevationExtents(e.scale)
+		canvas.text(layerText, models.Point{X: width / 2, Y: -(bottom - elevationMargin*e.scale)},
+			elevationTextSize*e.scale, false, opts.Title)
+	}
+	return nil
+}
+
+// band devuelve el ancho visto del perfil, o el valor por defecto si no se conoce.
+func (e *elevation) band(sku string) float64 {
+	if width, ok := e.opts.ProfileWidths[sku]; ok && width > 0 {
+		return width
+	}
+	return defaultProfileBandMM
+}
+
+// frameSKU devuelve el perfil del marco, empezando por el lado de abajo.
+func frameSKU(frame models.Frame) string {
+	if detail, ok := frame.Details[constants.POSITION_BOTTOM]; ok && detail.ProfileSKU != "" {
+		return detail.ProfileSKU
+	}
+	positions := make([]string, 0, len(frame.Details))
+	for pos := range frame.Details {
+		positions = append(positions, pos)
+	}
+	sort.Strings(positions)
+	for _, pos := range positions {
+		if sku := frame.Details[pos].ProfileSKU; sku != "" {
+			return sku
+		}
+	}
+	return ""
+}
+
+// frame dibuja la banda del marco y la unión de los perfiles en cada vértice.
+func (e *elevation) frame(outer, inner []models.Point, vertices []int) {
+	e.canvas.ring(layerFrame, outer, inner)
+	for _, i := range vertices {
+		e.canvas.line(layerFrame, outer[i], inner[i], false)
+	}
+}
+
+// mullions dibuja los montantes y travesaños del campo y de sus descendientes.
+func (e *elevation) mullions(field *models.Field) {
+	if field == nil || field.Split == nil {
+		return
+	}
+	split := field.Split
+	for i, axis := range split.Axes {
+		band := defaultProfileBandMM
+		if i < len(split.Mullions) {
+			band = e.band(split.Mullions[i].ProfileSKU)
+		}
+		r := modelRect{float64(axis) - band/2, float64(field.Y), band, float64(field.Height)}
+		if split.Orientation == constants.SPLIT_HORIZONTAL {
+			r = modelRect{float64(field.X), float64(axis) - band/2, float64(field.Width), band}
+		}
+		e.canvas.rect(layerFrame, r)
+	}
+	for i := range split.Children {
+		e.mullions(&split.Children[i])
+	}
+}
+
+// winds ubica y dibuja las hojas. light es la luz del marco, usada cuando no hay campos.
+func (e *elevation) winds(light modelRect) {
+	element := e.element
+	if len(element.Winds) == 0 {
+		return
+	}
+	rects := make([]modelRect, len(element.Winds))
+	placed := make([]bool, len(element.Winds))
+	sliding := false
+	for _, wind := range element.Winds {
+		if wind.Kind == constants.WIND_KIND_SLIDING_MOVIL || wind.Kind == constants.WIND_KIND_SLIDING_FIXED {
+			sliding = true
+		}
+	}
+
+	switch {
+	case element.Layout != nil:
+		fields := windFields(element.Layout, nil)
+		for i, wind := range element.Winds {
+			field, ok := fields[wind.ID]
+			if !ok {
+				continue
+			}
+			dx, dy := float64(wind.Width-field.Width)/2, float64(wind.Height-field.Height)/2
+			rects[i] = modelRect{float64(field.X) - dx, float64(field.Y) - dy, float64(wind.Width), float64(wind.Height)}
+			placed[i] = true
+		}
+	case sliding:
+		// Las hojas suman más que la luz por los solapes laterales y los traslapos; el exceso se
+		// reparte por igual entre los bordes y los encuentros para centrar el conjunto.
+		total := 0.0
+		for _, wind := range element.Winds {
+			total += float64(wind.Width)
+		}
+		overlap := (total - light.w) / float64(len(element.Winds)+1)
+		x := light.x - overlap
+		for i, wind := range element.Winds {
+			rects[i] = modelRect{x, light.y - (float64(wind.Height)-light.h)/2, float64(wind.Width), float64(wind.Height)}
+			placed[i] = true
+			x += float64(wind.Width) - overlap
+		}
+	default:
+		// Hojas de abatir lado a lado: las de los extremos solapan el marco lo mismo que arriba y abajo.
+		x := light.x - (float64(element.Winds[0].Height)-light.h)/2
+		for i, wind := range element.Winds {
+			rects[i] = modelRect{x, light.y - (float64(wind.Height)-light.h)/2, float64(wind.Width), float64(wind.Height)}
+			placed[i] = true
+			x += float64(wind.Width)
+		}
+	}
+
+	// Se dibujan primero los rieles exteriores para que las hojas interiores queden delante.
+	order := make([]int, len(element.Winds))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool { return element.Winds[order[a]].Track < element.Winds[order[b]].Track })
+	for _, i := range order {
+		if placed[i] {
+			e.wind(i, rects[i])
+		}
+	}
+}
+
+// windFields asocia el ID de cada hoja generada por la división en campos con su campo.
+func windFields(field *models.Field, fields map[string]*models.Field) map[string]*models.Field {
+	if fields == nil {
+		fields = make(map[string]*models.Field)
+	}
+	if field.WindID != "" {
+		fields[field.WindID] = field
+	}
+	if field.Split != nil {
+		for i := range field.Split.Children {
+			windFields(&field.Split.Children[i], fields)
+		}
+	}
+	return fields
+}
+
+// wind dibuja la hoja i en r con su vidrio y su símbolo de apertura.
+func (e *elevation) wind(i int, r modelRect) {
+	wind := e.element.Winds[i]
+	band := defaultProfileBandMM
+	for _, pos := range sortedKeys(wind.Details) {
+		if sku := wind.Details[pos].ProfileSKU; sku != "" {
+			band = e.band(sku)
+			break
+		}
+	}
+	band = math.Min(band, math.Min(r.w, r.h)/4)
+	e.canvas.rect(layerSash, r)
+	glass := r.inset(band)
+	e.canvas.rect(layerGlass, glass)
+
+	dashed := wind.OpeningDirection == constants.OPENING_EXT
+	switch wind.Kind {
+	case constants.WIND_KIND_SLIDING_MOVIL:
+		e.slidingArrow(i, glass)
+	case constants.WIND_KIND_SLIDING_FIXED, constants.WIND_KIND_FIXED:
+		size := elevationSymbolTextSize * e.scale
+		e.canvas.text(layerText, models.Point{X: glass.x + glass.w/2, Y: glass.y + glass.h/2 - 0.35*size}, size, false, "F")
+	case constants.WIND_KIND_TILT_TURN:
+		e.openingTriangle(glass, wind.OpeningSide, dashed)
+		e.openingTriangle(glass, constants.OPENING_SIDE_BOTTOM, dashed)
+	case constants.WIND_KIND_PROJECTING:
+		e.openingTriangle(glass, firstNonEmpty(wind.OpeningSide, constants.OPENING_SIDE_TOP), dashed)
+	case constants.WIND_KIND_TILT_ONLY:
+		e.openingTriangle(glass, firstNonEmpty(wind.OpeningSide, constants.OPENING_SIDE_BOTTOM), dashed)
+	default:
+		if wind.OpeningSide != "" {
+			e.openingTriangle(glass, wind.OpeningSide, dashed)
+		}
+	}
+}
+
+// openingTriangle dibuja las dos líneas desde las esquinas opuestas al lado de las bisagras hasta
+// el centro de ese lado.
+func (e *elevation) openingTriangle(r modelRect, hingeSide string, dashed bool) {
+	var apex, a, b models.Point
+	switch hingeSide {
+	case constants.OPENING_SIDE_LEFT:
+		apex, a, b = models.Point{X: r.x, Y: r.y + r.h/2}, models.Point{X: r.x + r.w, Y: r.y}, models.Point{X: r.x + r.w, Y: r.y + r.h}
+	case constants.OPENING_SIDE_RIGHT:
+		apex, a, b = models.Point{X: r.x + r.w, Y: r.y + r.h/2}, models.Point{X: r.x, Y: r.y}, models.Point{X: r.x, Y: r.y + r.h}
+	case constants.OPENING_SIDE_TOP:
+		apex, a, b = models.Point{X: r.x + r.w/2, Y: r.y + r.h}, models.Point{X: r.x, Y: r.y}, models.Point{X: r.x + r.w, Y: r.y}
+	case constants.OPENING_SIDE_BOTTOM:
+		apex, a, b = models.Point{X: r.x + r.w/2, Y: r.y}, models.Point{X: r.x, Y: r.y + r.h}, models.Point{X: r.x + r.w, Y: r.y + r.h}
+	default:
+		return
+	}
+	e.canvas.line(layerSash, a, apex, dashed)
+	e.canvas.line(layerSash, apex, b, dashed)
+}
+
+// slidingArrow dibuja la flecha de una hoja corredera móvil: apunta hacia cada hoja vecina que va
+// en otro riel, que es hacia donde la hoja puede deslizar.
+func (e *elevation) slidingArrow(i int, r modelRect) {
+	winds := e.element.Winds
+	toLeft := i > 0 && winds[i-1].Track != winds[i].Track
+	toRight := i < len(winds)-1 && winds[i+1].Track != winds[i].Track
+	if !toLeft && !toRight {
+		toLeft, toRight = true, true
+	}
+	y := r.y + r.h/2
+	length := r.w * 0.5
+	from, to := models.Point{X: r.x + (r.w-length)/2, Y: y}, models.Point{X: r.x + (r.w+length)/2, Y: y}
+	e.canvas.line(layerSash, from, to, false)
+	head := math.Min(length/4, 3*e.scale)
+	if toRight {
+		e.canvas.line(layerSash, models.Point{X: to.X - head, Y: y + head/2}, to, false)
+		e.canvas.line(layerSash, models.Point{X: to.X - head, Y: y - head/2}, to, false)
+	}
+	if toLeft {
+		e.canvas.line(layerSash, models.Point{X: from.X + head, Y: y + head/2}, from, false)
+		e.canvas.line(layerSash, models.Point{X: from.X + head, Y: y - head/2}, from, false)
+	}
+}
+
+// dimensions dibuja las cotas totales de ancho (abajo) y alto (izquierda) y, si el elemento está
+// dividido, la cadena de cotas de la primera división: abajo si es en montantes, a la derecha si
+// es en travesaños.
+func (e *elevation) dimensions(outline *models.FrameOutline) {
+	width, height := float64(e.element.Frame.Width), float64(e.element.Frame.Height)
+	gap := elevationDimensionGap * e.scale
+	e.horizontalDimension([]float64{0, width}, -2*gap, 0)
+	e.verticalDimension([]float64{0, height}, -2*gap, 0)
+
+	// Los marcos no rectangulares acotan también el alto de sus lados.
+	if !e.element.Frame.IsRectangular() {
+		leftHeight := outline.Edges[len(outline.Edges)-1].Start.Y
+		if leftHeight > 0 && leftHeight < height {
+			e.verticalDimension([]float64{0, leftHeight}, -gap, 0)
+		}
+		rightHeight := outline.Edges[1].End.Y
+		if rightHeight > 0 && rightHeight < height {
+			e.verticalDimension([]float64{0, rightHeight}, width+gap, width)
+		}
+	}
+
+	layout := e.element.Layout
+	if layout == nil || layout.Split == nil || len(layout.Split.Axes) == 0 {
+		return
+	}
+	if layout.Split.Orientation == constants.SPLIT_VERTICAL {
+		stops := []float64{0}
+		for _, axis := range layout.Split.Axes {
+			stops = append(stops, float64(axis))
+		}
+		e.horizontalDimension(append(stops, width), -gap, 0)
+		return
+	}
+	stops := []float64{0}
+	for _, axis := range layout.Split.Axes {
+		stops = append(stops, float64(axis))
+	}
+	e.verticalDimension(append(stops, height), width+gap, width)
+}
+
+// horizontalDimension dibuja una cadena de cotas horizontales a la altura y, con líneas de
+// referencia desde from.
+func (e *elevation) horizontalDimension(stops []float64, y, from float64) {
+	tick := elevationTickSize * e.scale
+	e.dimensionLine(models.Point{X: stops[0], Y: y}, models.Point{X: stops[len(stops)-1], Y: y})
+	for i, x := range stops {
+		e.dimensionLine(models.Point{X: x, Y: from - tick}, models.Point{X: x, Y: y - tick})
+		e.dimensionLine(models.Point{X: x - tick/2, Y: y - tick/2}, models.Point{X: x + tick/2, Y: y + tick/2})
+		if i == 0 {
+			continue
+		}
+		e.canvas.text(layerDimensions, models.Point{X: (stops[i-1] + x) / 2, Y: y + tick/2},
+			elevationTextSize*e.scale, false, dimensionText(x-stops[i-1]))
+	}
+}
+
+// verticalDimension dibuja una cadena de cotas verticales en la abscisa x, con líneas de
+// referencia desde from.
+func (e *elevation) verticalDimension(stops []float64, x, from float64) {
+	tick := elevationTickSize * e.scale
+	side := math.Copysign(tick, x-from)
+	e.dimensionLine(models.Point{X: x, Y: stops[0]}, models.Point{X: x, Y: stops[len(stops)-1]})
+	for i, y := range stops {
+		e.dimensionLine(models.Point{X: from - side, Y: y}, models.Point{X: x + side, Y: y})
+		e.dimensionLine(models.Point{X: x - tick/2, Y: y - tick/2}, models.Point{X: x + tick/2, Y: y + tick/2})
+		if i == 0 {
+			continue
+		}
+		e.canvas.text(layerDimensions, models.Point{X: x - tick/2, Y: (stops[i-1] + y) / 2},
+			elevationTextSize*e.scale, true, dimensionText(y-stops[i-1]))
+	}
+}
+
+func (e *elevation) dimensionLine(a, b models.Point) {
+	e.canvas.line(layerDimensions, a, b, false)
+}
+
+// dimensionText devuelve el texto de una cota: la medida en mm redondeada.
+func dimensionText(length float64) string {
+	return strconv.Itoa(int(math.Round(length)))
+}
+
+// flattenOutline convierte el contorno en un polígono antihorario, aproximando los arcos con
+// segmentos. Devuelve también los índices de los vértices donde dos perfiles se unen en ángulo;
+// donde un arco es tangente a su vecino el corte es recto y no se marca.
+func flattenOutline(outline *models.FrameOutline) ([]models.Point, []int) {
+	var points []models.Point
+	var vertices []int
+	for _, edge := range outline.Edges {
+		if math.Abs(edge.AngleStart-90) > 0.5 {
+			vertices = append(vertices, len(points))
+		}
+		points = append(points, edge.Start)
+		if !edge.Curved() {
+			continue
+		}
+		center := arcCenter(edge)
+		start := math.Atan2(edge.Start.Y-center.Y, edge.Start.X-center.X)
+		for k := 1; k < elevationArcSegments; k++ {
+			angle := start + edge.Sweep*float64(k)/elevationArcSegments
+			points = append(points, models.Point{X: center.X + edge.Radius*math.Cos(angle), Y: center.Y + edge.Radius*math.Sin(angle)})
+		}
+	}
+	return points, vertices
+}
+
+// arcCenter devuelve el centro de un lado curvo recorrido en sentido antihorario.
+func arcCenter(edge models.FrameEdge) models.Point {
+	dx, dy := edge.End.X-edge.Start.X, edge.End.Y-edge.Start.Y
+	chord := math.Hypot(dx, dy)
+	distance := math.Sqrt(math.Max(0, edge.Radius*edge.Radius-chord*chord/4))
+	if edge.Sweep > math.Pi {
+		distance = -distance
+	}
+	// El centro queda a la izquierda de la cuerda, del lado interior del contorno.
+	return models.Point{X: (edge.Start.X+edge.End.X)/2 - dy/chord*distance, Y: (edge.Start.Y+edge.End.Y)/2 + dx/chord*distance}
+}
+
+// insetPolygon desplaza hacia adentro cada lado de un polígono convexo antihorario y devuelve los
+// vértices donde se cortan los lados desplazados.
+func insetPolygon(points []models.Point, distance float64) []models.Point {
+	n := len(points)
+	type offsetLine struct{ p, d models.Point }
+	lines := make([]offsetLine, n)
+	for i := range points {
+		a, b := points[i], points[(i+1)%n]
+		dx, dy := b.X-a.X, b.Y-a.Y
+		length := math.Hypot(dx, dy)
+		normal := models.Point{X: -dy / length * distance, Y: dx / length * distance}
+		lines[i] = offsetLine{models.Point{X: a.X + normal.X, Y: a.Y + normal.Y}, models.Point{X: dx, Y: dy}}
+	}
+	inset := make([]models.Point, n)
+	for i := range points {
+		prev, next := lines[(i+n-1)%n], lines[i]
+		cross := prev.d.X*next.d.Y - prev.d.Y*next.d.X
+		if math.Abs(cross) < 1e-9 {
+			inset[i] = next.p
+			continue
+		}
+		t := ((next.p.X-prev.p.X)*next.d.Y - (next.p.Y-prev.p.Y)*next.d.X) / cross
+		inset[i] = models.Point{X: prev.p.X + t*prev.d.X, Y: prev.p.Y + t*prev.d.Y}
+	}
+	return inset
+}
+
+func sortedKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
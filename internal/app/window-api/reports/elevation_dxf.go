@@ -0,0 +1,289 @@
+package reports
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/mvialf/windraw/internal/app/window-api/models"
+)
+
+// dxfLayers son las capas del DXF con su color ACI, en el orden en que se declaran.
+var dxfLayers = []struct {
+	name  string
+	color int
+}{
+	{layerFrame, 7},      // Blanco o negro según el fondo
+	{layerSash, 3},       // Verde
+	{layerGlass, 5},      // Azul
+	{layerDimensions, 1}, // Rojo
+	{layerText, 7},
+}
+
+// dxfCanvas escribe las entidades del dibujo en DXF R12 (AC1009), en mm y con el eje Y hacia
+// arriba como el modelo. origin desplaza el elemento que se está dibujando dentro del archivo.
+type dxfCanvas struct {
+	b        strings.Builder
+	origin   models.Point
+	min, max models.Point
+	empty    bool
+}
+
+func newDXFCanvas() *dxfCanvas {
+	return &dxfCanvas{empty: true}
+}
+
+// WriteElementDXF dibuja la elevación del elemento en DXF R12 ASCII, a tamaño real en mm, con el
+// marco, las hojas, los vidrios, las cotas y los textos en las capas frame, sash, glass,
+// dimensions y text. La escala de opts solo fija el tamaño de textos y cotas, pensados para
+// imprimir a 1:Scale. Ver drawElevation.
+func WriteElementDXF(w io.Writer, element *models.Element, opts ElevationOptions) error {
+	c := newDXFCanvas()
+	if err := drawElevation(c, element, opts); err != nil {
+		return err
+	}
+	return c.writeTo(w, opts.Scale)
+}
+
+// WriteComponentDXF dibuja en un solo DXF los elementos de todos los módulos del componente, lado a
+// lado y apoyados sobre la misma base, con espacio entre ellos para las cotas. Bajo cada elemento
+// va su referencia (ref seguido de "-M<módulo>-E<elemento>", como en Piece.ElementRef), el tipo y
+// las medidas; opts.Title, si se indica, va bajo el conjunto.
+func WriteComponentDXF(w io.Writer, component *models.Component, ref string, opts ElevationOptions) error {
+	if opts.Scale <= 0 {
+		opts.Scale = defaultElevationScale
+	}
+	scale := float64(opts.Scale)
+	left, right, _, bottom := elevationExtents(scale)
+	c := newDXFCanvas()
+	x := 0.0
+	for m, module := range component.Modules {
+		for e := range module.Elements {
+			element := &module.Elements[e]
+			elementOpts := opts
+			elementOpts.Title = fmt.Sprintf("%s-M%d-E%d · %s · %dx%d mm", ref, m+1, e+1, element.Type, element.Width, element.Height)
+			if x > 0 {
+				x += left
+			}
+			c.origin = models.Point{X: x}
+			if err := drawElevation(c, element, elementOpts); err != nil {
+				return fmt.Errorf("reports: elemento %s-M%d-E%d: %w", ref, m+1, e+1, err)
+			}
+			x += float64(element.Frame.Width) + right
+		}
+	}
+	if opts.Title != "" && x > 0 {
+		c.origin = models.Point{}
+		c.text(layerText, models.Point{X: (x - right) / 2, Y: -bottom - elevationTextSize*scale}, 1.5*elevationTextSize*scale, false, opts.Title)
+	}
+	return c.writeTo(w, opts.Scale)
+}
+
+func (c *dxfCanvas) line(layer string, a, b models.Point, dashed bool) {
+	c.entity("LINE", layer)
+	if dashed {
+		c.group(6, "DASHED")
+	}
+	c.point(10, a)
+	c.point(11, b)
+}
+
+func (c *dxfCanvas) rect(layer string, r modelRect) {
+	if r.w <= 0 || r.h <= 0 {
+		return
+	}
+	c.polygon(layer, []models.Point{{X: r.x, Y: r.y}, {X: r.x + r.w, Y: r.y}, {X: r.x + r.w, Y: r.y + r.h}, {X: r.x, Y: r.y + r.h}})
+}
+
+// polygon escribe una polilínea cerrada; R12 no tiene LWPOLYLINE y cada vértice es una entidad.
+func (c *dxfCanvas) polygon(layer string, points []models.Point) {
+	c.entity("POLYLINE", layer)
+	c.group(66, "1")
+	c.coords(10, models.Point{}) // La polilínea no usa su propio punto
+	c.group(70, "1")
+	for _, p := range points {
+		c.entity("VERTEX", layer)
+		c.point(10, p)
+	}
+	c.entity("SEQEND", layer)
+}
+
+func (c *dxfCanvas) ring(layer string, outer, inner []models.Point) {
+	c.polygon(layer, outer)
+	c.polygon(layer, inner)
+}
+
+// text escribe un TEXT centrado (72 = 1) sobre el punto de alineación 11. El alto de DXF es el de
+// las mayúsculas, cerca del 70 % del tamaño de letra del SVG.
+func (c *dxfCanvas) text(layer string, at models.Point, size float64, vertical bool, value string) {
+	c.entity("TEXT", layer)
+	c.point(10, at)
+	c.group(40, dxfNum(0.7*size))
+	c.group(1, dxfText(value))
+	if vertical {
+		c.group(50, "90")
+	}
+	c.group(72, "1")
+	c.point(11, at)
+	// El texto sobresale de su punto; se estima su ancho para que entre en los límites del dibujo.
+	half := 0.4 * size * float64(len([]rune(value)))
+	if vertical {
+		c.extend(models.Point{X: at.X - size, Y: at.Y - half})
+		c.extend(models.Point{X: at.X, Y: at.Y + half})
+	} else {
+		c.extend(models.Point{X: at.X - half, Y: at.Y})
+		c.extend(models.Point{X: at.X + half, Y: at.Y + size})
+	}
+}
+
+func (c *dxfCanvas) entity(kind, layer string) {
+	c.group(0, kind)
+	c.group(8, layer)
+}
+
+// point escribe un punto del elemento actual con el código de grupo de su X (10, 11...),
+// desplazado a su origen, y amplía con él los límites del dibujo.
+func (c *dxfCanvas) point(code int, p models.Point) {
+	c.extend(p)
+	c.coords(code, models.Point{X: p.X + c.origin.X, Y: p.Y + c.origin.Y})
+}
+
+// coords escribe las coordenadas de un punto absoluto del archivo.
+func (c *dxfCanvas) coords(code int, p models.Point) {
+	c.group(code, dxfNum(p.X))
+	c.group(code+10, dxfNum(p.Y))
+	c.group(code+20, "0")
+}
+
+// extend amplía los límites del dibujo con un punto en coordenadas del elemento actual.
+func (c *dxfCanvas) extend(p models.Point) {
+	p = models.Point{X: p.X + c.origin.X, Y: p.Y + c.origin.Y}
+	if c.empty {
+		c.min, c.max, c.empty = p, p, false
+		return
+	}
+	c.min = models.Point{X: math.Min(c.min.X, p.X), Y: math.Min(c.min.Y, p.Y)}
+	c.max = models.Point{X: math.Max(c.max.X, p.X), Y: math.Max(c.max.Y, p.Y)}
+}
+
+func (c *dxfCanvas) group(code int, value string) {
+	fmt.Fprintf(&c.b, "%3d\n%s\n", code, value)
+}
+
+// writeTo escribe el archivo completo: cabecera, tablas de tipos de línea, capas y estilos de
+// texto, y las entidades dibujadas.
+func (c *dxfCanvas) writeTo(w io.Writer, scale int) error {
+	if scale <= 0 {
+		scale = defaultElevationScale
+	}
+	var d dxfCanvas
+	d.group(0, "SECTION")
+	d.group(2, "HEADER")
+	d.group(9, "$ACADVER")
+	d.group(1, "AC1009")
+	d.group(9, "$DWGCODEPAGE")
+	d.group(3, "ANSI_1252")
+	// $INSUNITS y $MEASUREMENT son posteriores a R12: AutoCAD R12 los ignora, pero LibreCAD y las
+	// versiones actuales los usan para abrir el archivo en milímetros.
+	d.group(9, "$INSUNITS")
+	d.group(70, "4")
+	d.group(9, "$MEASUREMENT")
+	d.group(70, "1")
+	d.group(9, "$EXTMIN")
+	d.coords(10, c.min)
+	d.group(9, "$EXTMAX")
+	d.coords(10, c.max)
+	d.group(9, "$LTSCALE")
+	d.group(40, "1")
+	d.group(0, "ENDSEC")
+
+	d.group(0, "SECTION")
+	d.group(2, "TABLES")
+	d.group(0, "TABLE")
+	d.group(2, "LTYPE")
+	d.group(70, "2")
+	d.lineType("CONTINUOUS", "Solid line")
+	d.lineType("DASHED", "__ __ __", elevationDashMM*float64(scale), -elevationDashGapMM*float64(scale))
+	d.group(0, "ENDTAB")
+	d.group(0, "TABLE")
+	d.group(2, "LAYER")
+	d.group(70, strconv.Itoa(len(dxfLayers)+1))
+	d.layer("0", 7)
+	for _, layer := range dxfLayers {
+		d.layer(layer.name, layer.color)
+	}
+	d.group(0, "ENDTAB")
+	d.group(0, "TABLE")
+	d.group(2, "STYLE")
+	d.group(70, "1")
+	d.group(0, "STYLE")
+	d.group(2, "STANDARD")
+	d.group(70, "0")
+	d.group(40, "0")
+	d.group(41, "1")
+	d.group(50, "0")
+	d.group(71, "0")
+	d.group(42, dxfNum(elevationTextSize*float64(scale)))
+	d.group(3, "txt")
+	d.group(4, "")
+	d.group(0, "ENDTAB")
+	d.group(0, "ENDSEC")
+
+	d.group(0, "SECTION")
+	d.group(2, "ENTITIES")
+	d.b.WriteString(c.b.String())
+	d.group(0, "ENDSEC")
+	d.group(0, "EOF")
+	_, err := io.WriteString(w, d.b.String())
+	return err
+}
+
+// lineType declara un tipo de línea; pattern alterna trazos (positivos) y espacios (negativos).
+func (c *dxfCanvas) lineType(name, description string, pattern ...float64) {
+	total := 0.0
+	for _, length := range pattern {
+		total += math.Abs(length)
+	}
+	c.group(0, "LTYPE")
+	c.group(2, name)
+	c.group(70, "0")
+	c.group(3, description)
+	c.group(72, "65")
+	c.group(73, strconv.Itoa(len(pattern)))
+	c.group(40, dxfNum(total))
+	for _, length := range pattern {
+		c.group(49, dxfNum(length))
+	}
+}
+
+func (c *dxfCanvas) layer(name string, color int) {
+	c.group(0, "LAYER")
+	c.group(2, name)
+	c.group(70, "0")
+	c.group(62, strconv.Itoa(color))
+	c.group(6, "CONTINUOUS")
+}
+
+// dxfNum formatea una coordenada en mm con hasta tres decimales.
+func dxfNum(v float64) string {
+	return strconv.FormatFloat(math.Round(v*1000)/1000, 'f', -1, 64)
+}
+
+// dxfText codifica un texto en Windows-1252, el código de página declarado en la cabecera. Los
+// caracteres Latin-1 coinciden con sus bytes; el resto se escribe con el escape \U+XXXX de AutoCAD.
+func dxfText(text string) string {
+	var b strings.Builder
+	for _, r := range text {
+		switch {
+		case r == '\n' || r == '\r':
+			b.WriteByte(' ')
+		case r < 0x80 || (r >= 0xA0 && r <= 0xFF):
+			b.WriteByte(byte(r))
+		default:
+			fmt.Fprintf(&b, `\U+%04X`, r)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,113 @@
+package reports
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/mvialf/windraw/internal/app/window-api/models"
+)
+
+// Trazos y colores del SVG; los trazos en mm de papel.
+const (
+	svgThinStroke  = 0.18
+	svgThickStroke = 0.35
+	svgLineColor   = "#222222"
+)
+
+// svgFills es el relleno de las superficies de cada capa.
+var svgFills = map[string]string{
+	layerFrame: "#d9d9d9",
+	layerSash:  "#f2f2f2",
+	layerGlass: "#d6eaf5",
+}
+
+// svgCanvas escribe el dibujo en SVG. El viewBox está en mm del modelo con el eje Y invertido, y
+// el tamaño del documento en mm de papel, de modo que al imprimirlo a tamaño real queda a escala.
+type svgCanvas struct {
+	b      strings.Builder
+	scale  float64
+	height float64
+}
+
+// WriteElementSVG dibuja la elevación del elemento a escala en SVG. Ver drawElevation.
+func WriteElementSVG(w io.Writer, element *models.Element, opts ElevationOptions) error {
+	if opts.Scale <= 0 {
+		opts.Scale = defaultElevationScale
+	}
+	c := &svgCanvas{scale: float64(opts.Scale), height: float64(element.Frame.Height)}
+	width, height := float64(element.Frame.Width), c.height
+	left, right, top, bottom := elevationExtents(c.scale)
+	fmt.Fprintf(&c.b, `<svg xmlns="http://www.w3.org/2000/svg" width="%smm" height="%smm" viewBox="%s %s %s %s">`+"\n",
+		svgNum((left+width+right)/c.scale), svgNum((top+height+bottom)/c.scale),
+		svgNum(-left), svgNum(-top), svgNum(left+width+right), svgNum(top+height+bottom))
+	fmt.Fprintf(&c.b, `<g fill="none" stroke="%s" stroke-width="%s" stroke-linejoin="round" font-family="Helvetica, Arial, sans-serif">`+"\n",
+		svgLineColor, svgNum(svgThinStroke*c.scale))
+	if err := drawElevation(c, element, opts); err != nil {
+		return err
+	}
+	c.b.WriteString("</g>\n</svg>\n")
+	_, err := io.WriteString(w, c.b.String())
+	return err
+}
+
+// y convierte una ordenada del modelo (hacia arriba) a la del SVG (hacia abajo).
+func (c *svgCanvas) y(y float64) float64 {
+	return c.height - y
+}
+
+func (c *svgCanvas) line(_ string, a, b models.Point, dashed bool) {
+	fmt.Fprintf(&c.b, `<line x1="%s" y1="%s" x2="%s" y2="%s"`, svgNum(a.X), svgNum(c.y(a.Y)), svgNum(b.X), svgNum(c.y(b.Y)))
+	if dashed {
+		fmt.Fprintf(&c.b, ` stroke-dasharray="%s %s"`, svgNum(elevationDashMM*c.scale), svgNum(elevationDashGapMM*c.scale))
+	}
+	c.b.WriteString("/>\n")
+}
+
+func (c *svgCanvas) rect(layer string, r modelRect) {
+	if r.w <= 0 || r.h <= 0 {
+		return
+	}
+	fmt.Fprintf(&c.b, `<rect x="%s" y="%s" width="%s" height="%s" fill="%s"/>`+"\n",
+		svgNum(r.x), svgNum(c.y(r.y+r.h)), svgNum(r.w), svgNum(r.h), svgFills[layer])
+}
+
+func (c *svgCanvas) polygon(layer string, points []models.Point) {
+	coords := make([]string, len(points))
+	for i, p := range points {
+		coords[i] = svgNum(p.X) + "," + svgNum(c.y(p.Y))
+	}
+	fmt.Fprintf(&c.b, `<polygon points="%s" fill="%s"/>`+"\n", strings.Join(coords, " "), svgFills[layer])
+}
+
+func (c *svgCanvas) ring(layer string, outer, inner []models.Point) {
+	var path strings.Builder
+	for _, ring := range [][]models.Point{outer, inner} {
+		for i, p := range ring {
+			cmd := "L"
+			if i == 0 {
+				cmd = "M"
+			}
+			fmt.Fprintf(&path, "%s%s %s ", cmd, svgNum(p.X), svgNum(c.y(p.Y)))
+		}
+		path.WriteString("Z ")
+	}
+	fmt.Fprintf(&c.b, `<path d="%s" fill="%s" fill-rule="evenodd" stroke-width="%s"/>`+"\n",
+		strings.TrimSpace(path.String()), svgFills[layer], svgNum(svgThickStroke*c.scale))
+}
+
+func (c *svgCanvas) text(_ string, at models.Point, size float64, vertical bool, value string) {
+	x, y := svgNum(at.X), svgNum(c.y(at.Y))
+	fmt.Fprintf(&c.b, `<text x="%s" y="%s" font-size="%s" text-anchor="middle" fill="%s" stroke="none"`, x, y, svgNum(size), svgLineColor)
+	if vertical {
+		fmt.Fprintf(&c.b, ` transform="rotate(-90 %s %s)"`, x, y)
+	}
+	fmt.Fprintf(&c.b, ">%s</text>\n", escapeXML(value))
+}
+
+// svgNum formatea una coordenada con hasta dos decimales, sin ceros de sobra.
+func svgNum(v float64) string {
+	return strconv.FormatFloat(math.Round(v*100)/100, 'f', -1, 64)
+}
@@ -0,0 +1,244 @@
+package reports
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/mvialf/windraw/internal/app/window-api/models"
+	"github.com/mvialf/windraw/internal/pkg/barcode"
+	"github.com/mvialf/windraw/internal/pkg/pdf"
+)
+
+// Simbologías de código de barras de las etiquetas.
+const (
+	LabelSymbologyQR      = "qr"
+	LabelSymbologyCode128 = "code128"
+)
+
+// ErrInvalidSymbology se devuelve cuando se pide una simbología que no existe.
+var ErrInvalidSymbology = errors.New("reports: simbología inválida, se espera 'qr' o 'code128'")
+
+// LabelSheet es una hoja de etiquetas autoadhesivas: tamaño de página, grilla y márgenes en mm.
+type LabelSheet struct {
+	PageWidthMM   float64
+	PageHeightMM  float64
+	Columns       int
+	Rows          int
+	LabelWidthMM  float64
+	LabelHeightMM float64
+	MarginLeftMM  float64 // Del borde de la página a la primera columna
+	MarginTopMM   float64 // Del borde de la página a la primera fila
+	GapXMM        float64 // Entre columnas
+	GapYMM        float64 // Entre filas
+}
+
+// DefaultLabelSheet es una hoja A4 de 2 x 7 etiquetas de 99,1 x 38,1 mm, un formato de oficina
+// común y con ancho suficiente para Code128.
+var DefaultLabelSheet = LabelSheet{
+	PageWidthMM: 210, PageHeightMM: 297, Columns: 2, Rows: 7,
+	LabelWidthMM: 99.1, LabelHeightMM: 38.1, MarginLeftMM: 4.65, MarginTopMM: 15.15, GapXMM: 2.5,
+}
+
+// ZPLLabel es el tamaño de etiqueta y la resolución de una impresora térmica.
+type ZPLLabel struct {
+	WidthMM   float64
+	HeightMM  float64
+	DotsPerMM int // 8 en impresoras de 203 dpi, 12 en las de 300 dpi
+}
+
+// DefaultZPLLabel es una etiqueta de 100 x 50 mm en una impresora de 203 dpi.
+var DefaultZPLLabel = ZPLLabel{WidthMM: 100, HeightMM: 50, DotsPerMM: 8}
+
+// labelLines devuelve las líneas de texto legible de una etiqueta, después del código de la pieza.
+func labelLines(label models.PieceLabel) []string {
+	piece := label.Piece
+	lines := []string{
+		label.ProjectName,
+		fmt.Sprintf("%s · %s", piece.ElementRef, piece.Owner),
+		fmt.Sprintf("%s · %d mm · %s°/%s°", piece.Position, piece.Length, formatAngle(piece.AngleLeft), formatAngle(piece.AngleRight)),
+		strings.TrimSpace(piece.ProfileSKU + " " + piece.Color),
+	}
+	if piece.Reinforced && piece.ReinforcedSKU != "" {
+		lines = append(lines, fmt.Sprintf("Refuerzo %s %d mm", piece.ReinforcedSKU, piece.ReinforcedLength))
+	}
+	return lines
+}
+
+// Diseño de la etiqueta en PDF, en puntos.
+const (
+	labelPadding  = 2 * pdf.MMToPt
+	labelCodeSize = 11.0
+	labelTextSize = 7.0
+	labelHRISize  = 5.0 // Texto bajo el Code128
+)
+
+// WriteLabelsPDF escribe una etiqueta por pieza en hojas del formato sheet. Con QR el código va a
+// la izquierda y los datos a la derecha; con Code128 los datos van arriba y el código abajo, a
+// todo el ancho de la etiqueta. Se dibuja un borde tenue para cortar si la hoja no viene troquelada.
+func WriteLabelsPDF(w io.Writer, labels []models.PieceLabel, sheet LabelSheet, symbology string) error {
+	if symbology != LabelSymbologyQR && symbology != LabelSymbologyCode128 {
+		return ErrInvalidSymbology
+	}
+	if sheet.Columns <= 0 || sheet.Rows <= 0 || sheet.LabelWidthMM <= 0 || sheet.LabelHeightMM <= 0 {
+		return errors.New("reports: la hoja de etiquetas debe tener filas, columnas y medidas positivas")
+	}
+	doc := pdf.New(sheet.PageWidthMM*pdf.MMToPt, sheet.PageHeightMM*pdf.MMToPt)
+	width, height := sheet.LabelWidthMM*pdf.MMToPt, sheet.LabelHeightMM*pdf.MMToPt
+	perPage := sheet.Columns * sheet.Rows
+
+	var page *pdf.Page
+	for i, label := range labels {
+		if i%perPage == 0 {
+			page = doc.AddPage()
+		}
+		slot := i % perPage
+		col, row := slot%sheet.Columns, slot/sheet.Columns
+		x := (sheet.MarginLeftMM + float64(col)*(sheet.LabelWidthMM+sheet.GapXMM)) * pdf.MMToPt
+		top := doc.Height() - (sheet.MarginTopMM+float64(row)*(sheet.LabelHeightMM+sheet.GapYMM))*pdf.MMToPt
+
+		page.SetGray(0.85)
+		page.Rect(x, top-height, width, height, false)
+		page.SetGray(0)
+
+		var err error
+		if symbology == LabelSymbologyQR {
+			err = drawQRLabel(page, label, x, top, width, height)
+		} else {
+			err = drawCode128Label(page, label, x, top, width, height)
+		}
+		if err != nil {
+			return fmt.Errorf("reports: etiqueta %s: %w", label.Piece.Code, err)
+		}
+	}
+	if len(labels) == 0 {
+		doc.AddPage()
+	}
+	_, err := doc.WriteTo(w)
+	return err
+}
+
+func drawQRLabel(page *pdf.Page, label models.PieceLabel, x, top, width, height float64) error {
+	code, err := barcode.QR([]byte(label.Payload))
+	if err != nil {
+		return err
+	}
+	side := height - 2*labelPadding
+	module := side / float64(code.Size+4) // Dos módulos de zona de silencio por lado, además del margen
+	originX, originY := x+labelPadding+2*module, top-labelPadding-2*module
+	for r := 0; r < code.Size; r++ {
+		for c := 0; c < code.Size; {
+			if !code.Dark(r, c) {
+				c++
+				continue
+			}
+			run := 1
+			for c+run < code.Size && code.Dark(r, c+run) {
+				run++
+			}
+			page.Rect(originX+float64(c)*module, originY-float64(r+1)*module, float64(run)*module, module, true)
+			c += run
+		}
+	}
+
+	textX := x + labelPadding + side + labelPadding
+	chars := int((x + width - labelPadding - textX) / (0.5 * labelTextSize))
+	y := top - labelPadding - labelCodeSize
+	page.Text(textX, y, pdf.HelveticaBold, labelCodeSize, label.Piece.Code)
+	for _, line := range labelLines(label) {
+		y -= labelTextSize + 2.5
+		page.Text(textX, y, pdf.Helvetica, labelTextSize, fitText(line, chars))
+	}
+	return nil
+}
+
+func drawCode128Label(page *pdf.Page, label models.PieceLabel, x, top, width, height float64) error {
+	modules, err := barcode.Code128(label.Payload)
+	if err != nil {
+		return err
+	}
+	chars := int((width - 2*labelPadding) / (0.5 * labelTextSize))
+	y := top - labelPadding - labelCodeSize
+	page.Text(x+labelPadding, y, pdf.HelveticaBold, labelCodeSize, label.Piece.Code)
+	lines := labelLines(label)
+	summary := []string{lines[0], strings.Join(lines[1:3], " · ")}
+	for _, line := range summary {
+		y -= labelTextSize + 2
+		page.Text(x+labelPadding, y, pdf.Helvetica, labelTextSize, fitText(line, chars))
+	}
+
+	module := (width - 2*labelPadding) / float64(len(modules)+20) // Diez módulos de silencio por lado
+	barsX := x + labelPadding + 10*module
+	barsBottom := top - height + labelPadding + labelHRISize + 2
+	barsHeight := y - 4 - barsBottom
+	for i := 0; i < len(modules); {
+		if !modules[i] {
+			i++
+			continue
+		}
+		run := 1
+		for i+run < len(modules) && modules[i+run] {
+			run++
+		}
+		page.Rect(barsX+float64(i)*module, barsBottom, float64(run)*module, barsHeight, true)
+		i += run
+	}
+	hri := fitText(label.Payload, int((width-2*labelPadding)/pdf.MonoWidth(labelHRISize, "M")))
+	page.Text(x+(width-pdf.MonoWidth(labelHRISize, hri))/2, top-height+labelPadding, pdf.Courier, labelHRISize, hri)
+	return nil
+}
+
+// zplText quita los caracteres de control de ZPL de un texto libre.
+var zplText = strings.NewReplacer("^", " ", "~", " ")
+
+// WriteLabelsZPL escribe una etiqueta ZPL por pieza para impresoras térmicas. El código de barras
+// lo genera la impresora (^BQ o ^BC); aquí solo se calcula su tamaño para que quepa en la etiqueta.
+func WriteLabelsZPL(w io.Writer, labels []models.PieceLabel, size ZPLLabel, symbology string) error {
+	if symbology != LabelSymbologyQR && symbology != LabelSymbologyCode128 {
+		return ErrInvalidSymbology
+	}
+	if size.WidthMM <= 0 || size.HeightMM <= 0 || size.DotsPerMM <= 0 {
+		return errors.New("reports: la etiqueta ZPL debe tener medidas y resolución positivas")
+	}
+	dots := func(mm float64) int { return int(mm * float64(size.DotsPerMM)) }
+	width, height, margin := dots(size.WidthMM), dots(size.HeightMM), dots(2)
+	codeHeight, textHeight := dots(4), dots(2.6)
+
+	var b strings.Builder
+	for _, label := range labels {
+		fmt.Fprintf(&b, "^XA\n^CI28\n^PW%d\n^LL%d\n", width, height)
+		textX, textY := margin, margin
+		lines := labelLines(label)
+
+		if symbology == LabelSymbologyQR {
+			code, err := barcode.QR([]byte(label.Payload))
+			if err != nil {
+				return fmt.Errorf("reports: etiqueta %s: %w", label.Piece.Code, err)
+			}
+			magnification := min(10, max(1, (height-2*margin)/(code.Size+4)))
+			fmt.Fprintf(&b, "^FO%d,%d^BQN,2,%d^FDMA,%s^FS\n", margin, margin, magnification, label.Payload)
+			textX += (code.Size+4)*magnification + margin
+		} else {
+			modules, err := barcode.Code128(label.Payload)
+			if err != nil {
+				return fmt.Errorf("reports: etiqueta %s: %w", label.Piece.Code, err)
+			}
+			moduleWidth := max(1, (width-2*margin)/(len(modules)+20))
+			barsHeight := height / 3
+			fmt.Fprintf(&b, "^FO%d,%d^BY%d^BCN,%d,Y,N,N^FD%s^FS\n",
+				margin+10*moduleWidth, height-margin-barsHeight-textHeight, moduleWidth, barsHeight, label.Payload)
+			lines = []string{lines[0], strings.Join(lines[1:3], " · ")}
+		}
+
+		fmt.Fprintf(&b, "^FO%d,%d^A0N,%d,%d^FD%s^FS\n", textX, textY, codeHeight, codeHeight, zplText.Replace(label.Piece.Code))
+		textY += codeHeight + textHeight/2
+		for _, line := range lines {
+			fmt.Fprintf(&b, "^FO%d,%d^A0N,%d,%d^FB%d,1,0,L^FD%s^FS\n", textX, textY, textHeight, textHeight, width-textX-margin, zplText.Replace(line))
+			textY += textHeight + textHeight/3
+		}
+		b.WriteString("^XZ\n")
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
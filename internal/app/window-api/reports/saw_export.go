@@ -0,0 +1,378 @@
+package reports
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/mvialf/windraw/internal/app/window-api/models"
+)
+
+// Campos que una columna de un dialecto de sierra puede tomar de cada corte.
+const (
+	SawFieldBar        = "bar"         // Número de barra dentro del trabajo (1, 2, ...)
+	SawFieldBarLength  = "bar_length"  // Largo de la barra o retazo
+	SawFieldBarSource  = "bar_source"  // constants.BAR_SOURCE_*
+	SawFieldOffcutID   = "offcut_id"   // ID del retazo, si la barra es un retazo
+	SawFieldItemSKU    = "item_sku"    // Ítem de stock
+	SawFieldColor      = "color"       // Color
+	SawFieldProfileSKU = "profile_sku" // SKU del perfil
+	SawFieldLength     = "length"      // Largo de la pieza
+	SawFieldAngleLeft  = "angle_left"  // Ángulo del extremo que entra primero en la sierra
+	SawFieldAngleRight = "angle_right" // Ángulo del otro extremo
+	SawFieldOffset     = "offset"      // Distancia desde el inicio de la barra hasta la pieza
+	SawFieldLabel      = "label"       // Código único de la pieza (Piece.Code)
+	SawFieldElement    = "element"     // Referencia del elemento
+	SawFieldOwner      = "owner"       // Marco, hoja, montante o junquillo
+	SawFieldPosition   = "position"    // Posición de la pieza
+	SawFieldQuantity   = "quantity"    // Siempre 1: una fila por corte
+	SawFieldFixed      = "fixed"       // Valor fijo de SawColumn.Value
+)
+
+var sawFields = []string{
+	SawFieldBar, SawFieldBarLength, SawFieldBarSource, SawFieldOffcutID, SawFieldItemSKU, SawFieldColor,
+	SawFieldProfileSKU, SawFieldLength, SawFieldAngleLeft, SawFieldAngleRight, SawFieldOffset, SawFieldLabel,
+	SawFieldElement, SawFieldOwner, SawFieldPosition, SawFieldQuantity, SawFieldFixed,
+}
+
+// Formatos de archivo de trabajo.
+const (
+	SawFormatCSV = "csv"
+	SawFormatXML = "xml"
+)
+
+// Factor de conversión desde mm de cada unidad aceptada en SawDialect.Units.
+var sawUnits = map[string]float64{"mm": 1, "cm": 10, "m": 1000, "in": 25.4}
+
+// SawColumn es una columna del archivo de trabajo.
+type SawColumn struct {
+	Field string `json:"field"`           // SawField*
+	Name  string `json:"name"`            // Título en CSV; nombre del atributo o elemento en XML
+	Value string `json:"value,omitempty"` // Valor con SawFieldFixed
+}
+
+// SawDialect describe el archivo de trabajo que acepta un modelo de sierra. Los dialectos se
+// definen como datos (ver LoadSawDialects) para agregar máquinas sin tocar el código.
+type SawDialect struct {
+	Name            string      `json:"name"`
+	Description     string      `json:"description,omitempty"`
+	Format          string      `json:"format"` // SawFormatCSV o SawFormatXML
+	Columns         []SawColumn `json:"columns"`
+	Separator       string      `json:"separator,omitempty"`         // CSV: un carácter, "," por defecto
+	Header          bool        `json:"header,omitempty"`            // CSV: escribe la fila de títulos
+	CRLF            bool        `json:"crlf,omitempty"`              // Fin de línea Windows
+	DecimalComma    bool        `json:"decimal_comma,omitempty"`     // "1234,5" en lugar de "1234.5"
+	Units           string      `json:"units,omitempty"`             // "mm" (por defecto), "cm", "m" o "in"
+	Decimals        int         `json:"decimals,omitempty"`          // Decimales de largos y distancias
+	AngleDecimals   int         `json:"angle_decimals,omitempty"`    // Decimales de los ángulos
+	AngleFromSquare bool        `json:"angle_from_square,omitempty"` // Ángulo medido desde la escuadra: 90° → 0°, 45° → 45°
+	XMLRoot         string      `json:"xml_root,omitempty"`          // Elemento raíz, "Job" por defecto
+	XMLRow          string      `json:"xml_row,omitempty"`           // Elemento de cada corte, "Cut" por defecto
+	XMLElements     bool        `json:"xml_elements,omitempty"`      // Columnas como elementos hijos en lugar de atributos
+}
+
+// Validate comprueba el formato, las columnas, el separador y las unidades del dialecto.
+func (d SawDialect) Validate() error {
+	if strings.TrimSpace(d.Name) == "" {
+		return errors.New("el dialecto de sierra no tiene nombre")
+	}
+	if d.Format != SawFormatCSV && d.Format != SawFormatXML {
+		return fmt.Errorf("dialecto '%s': formato inválido '%s', se espera '%s' o '%s'", d.Name, d.Format, SawFormatCSV, SawFormatXML)
+	}
+	if len(d.Columns) == 0 {
+		return fmt.Errorf("dialecto '%s': no define columnas", d.Name)
+	}
+	for i, column := range d.Columns {
+		if !models.IsValidOption(column.Field, sawFields) {
+			return fmt.Errorf("dialecto '%s': campo inválido '%s' en la columna %d. Válidos: %v", d.Name, column.Field, i+1, sawFields)
+		}
+		if d.Format == SawFormatXML && !isXMLName(column.Name) {
+			return fmt.Errorf("dialecto '%s': '%s' no es un nombre XML válido (columna %d)", d.Name, column.Name, i+1)
+		}
+	}
+	if d.Separator != "" && utf8.RuneCountInString(d.Separator) != 1 {
+		return fmt.Errorf("dialecto '%s': el separador debe ser un solo carácter, se recibió '%s'", d.Name, d.Separator)
+	}
+	if d.DecimalComma && d.Format == SawFormatCSV && (d.Separator == "" || d.Separator == ",") {
+		return fmt.Errorf("dialecto '%s': con coma decimal el separador no puede ser ','", d.Name)
+	}
+	if _, ok := sawUnits[d.units()]; !ok {
+		return fmt.Errorf("dialecto '%s': unidad inválida '%s', se espera mm, cm, m o in", d.Name, d.Units)
+	}
+	if d.Decimals < 0 || d.AngleDecimals < 0 {
+		return fmt.Errorf("dialecto '%s': la cantidad de decimales no puede ser negativa", d.Name)
+	}
+	if d.Format == SawFormatXML && (!isXMLName(d.xmlRoot()) || !isXMLName(d.xmlRow())) {
+		return fmt.Errorf("dialecto '%s': xml_root y xml_row deben ser nombres XML válidos", d.Name)
+	}
+	return nil
+}
+
+// Extension devuelve la extensión de archivo del dialecto.
+func (d SawDialect) Extension() string { return d.Format }
+
+// ContentType devuelve el tipo MIME del archivo de trabajo.
+func (d SawDialect) ContentType() string {
+	if d.Format == SawFormatXML {
+		return "application/xml; charset=utf-8"
+	}
+	return "text/csv; charset=utf-8"
+}
+
+func (d SawDialect) units() string {
+	if d.Units == "" {
+		return "mm"
+	}
+	return d.Units
+}
+
+func (d SawDialect) xmlRoot() string {
+	if d.XMLRoot == "" {
+		return "Job"
+	}
+	return d.XMLRoot
+}
+
+func (d SawDialect) xmlRow() string {
+	if d.XMLRow == "" {
+		return "Cut"
+	}
+	return d.XMLRow
+}
+
+// BuiltinSawDialects devuelve los dialectos genéricos incluidos: CSV y XML con todos los datos
+// de corte en mm. Sirven tal cual para máquinas configurables o como base de dialectos propios.
+func BuiltinSawDialects() []SawDialect {
+	columns := []SawColumn{
+		{Field: SawFieldBar, Name: "bar"},
+		{Field: SawFieldBarLength, Name: "bar_length"},
+		{Field: SawFieldItemSKU, Name: "item_sku"},
+		{Field: SawFieldColor, Name: "color"},
+		{Field: SawFieldLength, Name: "length"},
+		{Field: SawFieldAngleLeft, Name: "angle_left"},
+		{Field: SawFieldAngleRight, Name: "angle_right"},
+		{Field: SawFieldLabel, Name: "label"},
+	}
+	return []SawDialect{
+		{Name: "generic-csv", Description: "CSV genérico, un corte por fila, mm con punto decimal",
+			Format: SawFormatCSV, Columns: columns, Header: true, Decimals: 1, AngleDecimals: 1},
+		{Name: "generic-xml", Description: "XML genérico, un elemento Cut por corte con atributos en mm",
+			Format: SawFormatXML, Columns: columns, Decimals: 1, AngleDecimals: 1},
+	}
+}
+
+// LoadSawDialects lee dialectos de sierra desde un archivo JSON con una lista de SawDialect.
+func LoadSawDialects(path string) ([]SawDialect, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reports: no se pudo leer el archivo de dialectos de sierra %s: %w", path, err)
+	}
+	var dialects []SawDialect
+	if err := json.Unmarshal(data, &dialects); err != nil {
+		return nil, fmt.Errorf("reports: el archivo de dialectos de sierra %s no es JSON válido: %w", path, err)
+	}
+	for _, dialect := range dialects {
+		if err := dialect.Validate(); err != nil {
+			return nil, fmt.Errorf("reports: %s: %w", path, err)
+		}
+	}
+	return dialects, nil
+}
+
+// sawCut es un corte del plan con los datos de su barra.
+type sawCut struct {
+	bar   int
+	group *models.CuttingGroup
+	cut   *models.CutBar
+	piece models.BarCut
+}
+
+// WriteSawJob escribe el plan de corte como archivo de trabajo de la sierra, una fila por corte en
+// el orden del plan (barra por barra y, en cada barra, desde su inicio). Si la pieza se corta
+// girada, sus ángulos se intercambian para que el izquierdo sea el del extremo que entra primero.
+//
+// Las piezas curvas (BendRadius > 0) no se escriben: sus ángulos son los de los extremos del arco
+// ya curvado y la sierra las cortaría como rectas a inglete. Se devuelven aparte, en el orden del
+// plan, para cortarlas y curvarlas fuera del archivo de trabajo.
+func WriteSawJob(w io.Writer, plan *models.CuttingPlan, dialect SawDialect) (bent []models.Piece, err error) {
+	if err := dialect.Validate(); err != nil {
+		return nil, err
+	}
+	var cuts []sawCut
+	bar := 0
+	for gi := range plan.Groups {
+		group := &plan.Groups[gi]
+		for bi := range group.Bars {
+			bar++
+			for _, cut := range group.Bars[bi].Cuts {
+				if cut.Piece.BendRadius > 0 {
+					bent = append(bent, cut.Piece)
+					continue
+				}
+				cuts = append(cuts, sawCut{bar: bar, group: group, cut: &group.Bars[bi], piece: cut})
+			}
+		}
+	}
+
+	if dialect.Format == SawFormatXML {
+		return bent, writeSawXML(w, cuts, dialect)
+	}
+	return bent, writeSawCSV(w, cuts, dialect)
+}
+
+func writeSawCSV(w io.Writer, cuts []sawCut, dialect SawDialect) error {
+	writer := csv.NewWriter(w)
+	if dialect.Separator != "" {
+		writer.Comma, _ = utf8.DecodeRuneInString(dialect.Separator)
+	}
+	writer.UseCRLF = dialect.CRLF
+	if dialect.Header {
+		header := make([]string, len(dialect.Columns))
+		for i, column := range dialect.Columns {
+			header[i] = column.Name
+		}
+		if err := writer.Write(header); err != nil {
+			return err
+		}
+	}
+	for _, cut := range cuts {
+		record := make([]string, len(dialect.Columns))
+		for i, column := range dialect.Columns {
+			record[i] = sawValue(cut, column, dialect)
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+func writeSawXML(w io.Writer, cuts []sawCut, dialect SawDialect) error {
+	newline := "\n"
+	if dialect.CRLF {
+		newline = "\r\n"
+	}
+	var b strings.Builder
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8"?>` + newline)
+	b.WriteString("<" + dialect.xmlRoot() + ">" + newline)
+	for _, cut := range cuts {
+		if dialect.XMLElements {
+			b.WriteString("  <" + dialect.xmlRow() + ">" + newline)
+			for _, column := range dialect.Columns {
+				b.WriteString("    <" + column.Name + ">" + escapeXML(sawValue(cut, column, dialect)) + "</" + column.Name + ">" + newline)
+			}
+			b.WriteString("  </" + dialect.xmlRow() + ">" + newline)
+			continue
+		}
+		b.WriteString("  <" + dialect.xmlRow())
+		for _, column := range dialect.Columns {
+			b.WriteString(" " + column.Name + `="` + escapeXML(sawValue(cut, column, dialect)) + `"`)
+		}
+		b.WriteString("/>" + newline)
+	}
+	b.WriteString("</" + dialect.xmlRoot() + ">" + newline)
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// sawValue devuelve el valor de una columna para un corte, en las unidades y el formato del dialecto.
+func sawValue(c sawCut, column SawColumn, dialect SawDialect) string {
+	piece := c.piece.Piece
+	angleLeft, angleRight := piece.AngleLeft, piece.AngleRight
+	if c.piece.Flipped {
+		angleLeft, angleRight = angleRight, angleLeft
+	}
+	switch column.Field {
+	case SawFieldBar:
+		return strconv.Itoa(c.bar)
+	case SawFieldBarLength:
+		return dialect.length(float64(c.cut.LengthMM))
+	case SawFieldBarSource:
+		return c.cut.Source
+	case SawFieldOffcutID:
+		return c.cut.OffcutID
+	case SawFieldItemSKU:
+		return c.group.ItemSKU
+	case SawFieldColor:
+		return c.group.Color
+	case SawFieldProfileSKU:
+		return piece.ProfileSKU
+	case SawFieldLength:
+		return dialect.length(float64(piece.Length))
+	case SawFieldAngleLeft:
+		return dialect.angle(angleLeft)
+	case SawFieldAngleRight:
+		return dialect.angle(angleRight)
+	case SawFieldOffset:
+		return dialect.length(float64(c.piece.OffsetMM))
+	case SawFieldLabel:
+		return piece.Code
+	case SawFieldElement:
+		return piece.ElementRef
+	case SawFieldOwner:
+		return piece.Owner
+	case SawFieldPosition:
+		return piece.Position
+	case SawFieldQuantity:
+		return "1"
+	default:
+		return column.Value
+	}
+}
+
+// length convierte un largo en mm a las unidades del dialecto.
+func (d SawDialect) length(mm float64) string {
+	return d.number(mm/sawUnits[d.units()], d.Decimals)
+}
+
+// angle formatea un ángulo de corte, medido desde la escuadra si el dialecto lo pide.
+func (d SawDialect) angle(degrees float64) string {
+	if d.AngleFromSquare {
+		degrees = 90 - degrees
+	}
+	return d.number(degrees, d.AngleDecimals)
+}
+
+// number formatea un número con los decimales y el separador decimal del dialecto.
+func (d SawDialect) number(value float64, decimals int) string {
+	factor := math.Pow(10, float64(decimals))
+	text := strconv.FormatFloat(math.Round(value*factor)/factor, 'f', decimals, 64)
+	if d.DecimalComma {
+		text = strings.Replace(text, ".", ",", 1)
+	}
+	return text
+}
+
+// escapeXML escapa un texto para usarlo como contenido o valor de atributo XML.
+func escapeXML(text string) string {
+	var b strings.Builder
+	_ = xml.EscapeText(&b, []byte(text))
+	return b.String()
+}
+
+// isXMLName indica si name sirve como nombre de elemento o atributo XML (letras, dígitos, "_",
+// "-" y ".", sin empezar por dígito, "-" o ".").
+func isXMLName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i, r := range name {
+		letter := r == '_' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z')
+		if i == 0 && !letter {
+			return false
+		}
+		if !letter && !(r >= '0' && r <= '9') && r != '-' && r != '.' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,13 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/mvialf/windraw/internal/app/window-api/models"
+)
+
+// ColorRepository define las consultas sobre los colores disponibles por sistema.
+type ColorRepository interface {
+	// GetSystemColors devuelve los colores del sistema con el color embebido, ordenados por color_id.
+	GetSystemColors(ctx context.Context, systemID int64) ([]models.SystemColor, error)
+}
@@ -0,0 +1,172 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/mvialf/windraw/internal/app/window-api/models"
+	"github.com/mvialf/windraw/internal/pkg/projectfile"
+	"github.com/sirupsen/logrus"
+)
+
+// fileProjectRepository implementa ProjectRepository guardando cada proyecto como un archivo JSON
+// en un directorio, con el formato de nombre de projectfile ("ProjectID - ClientName.json").
+type fileProjectRepository struct {
+	directory string
+	mu        sync.RWMutex
+	logger    *logrus.Entry
+}
+
+// NewFileProjectRepository crea un repositorio de proyectos respaldado por el sistema de archivos.
+func NewFileProjectRepository(directory string, logger *logrus.Logger) ProjectRepository {
+	return &fileProjectRepository{
+		directory: directory,
+		logger:    logger.WithFields(logrus.Fields{"repository": "project_file", "directory": directory}),
+	}
+}
+
+// Create guarda un nuevo proyecto. Falla si ya existe un archivo para el mismo ID.
+func (r *fileProjectRepository) Create(ctx context.Context, project *models.Project) error {
+	if project == nil || project.ID == "" {
+		return errors.New("repositories: no se puede crear un proyecto nulo o sin ID")
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, err := r.findProjectFile(project.ID)
+	if err != nil {
+		return err
+	}
+	if existing != "" {
+		return fmt.Errorf("repositories: ya existe un proyecto con ID '%s'", project.ID)
+	}
+
+	filePath, err := projectfile.SaveProject(project, r.directory)
+	if err != nil {
+		return err
+	}
+	r.logger.WithFields(logrus.Fields{"method": "Create", "file": filePath}).Info("Proyecto guardado")
+	return nil
+}
+
+// Get carga un proyecto por su ID.
+func (r *fileProjectRepository) Get(ctx context.Context, id string) (*models.Project, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	filePath, err := r.findProjectFile(id)
+	if err != nil {
+		return nil, err
+	}
+	if filePath == "" {
+		return nil, ErrProjectNotFound
+	}
+	return projectfile.LoadProject(filePath)
+}
+
+// List carga todos los proyectos del directorio, del más reciente al más antiguo.
+// Los archivos que no se pueden leer se registran y se omiten.
+func (r *fileProjectRepository) List(ctx context.Context) ([]models.Project, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	entries, err := os.ReadDir(r.directory)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []models.Project{}, nil
+		}
+		return nil, fmt.Errorf("repositories: no se pudo leer el directorio de proyectos %s: %w", r.directory, err)
+	}
+
+	projects := []models.Project{}
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		project, err := projectfile.LoadProject(filepath.Join(r.directory, entry.Name()))
+		if err != nil {
+			r.logger.WithError(err).WithField("file", entry.Name()).Warn("Archivo de proyecto omitido")
+			continue
+		}
+		projects = append(projects, *project)
+	}
+	sort.Slice(projects, func(i, j int) bool { return projects[i].CreatedAt.After(projects[j].CreatedAt) })
+	return projects, nil
+}
+
+// Update reescribe el archivo del proyecto. Si el nombre del cliente cambió,
+// el archivo anterior se elimina tras guardar el nuevo.
+func (r *fileProjectRepository) Update(ctx context.Context, project *models.Project) error {
+	if project == nil || project.ID == "" {
+		return errors.New("repositories: no se puede actualizar un proyecto nulo o sin ID")
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	oldPath, err := r.findProjectFile(project.ID)
+	if err != nil {
+		return err
+	}
+	if oldPath == "" {
+		return ErrProjectNotFound
+	}
+
+	newPath, err := projectfile.SaveProject(project, r.directory)
+	if err != nil {
+		return err
+	}
+	if newPath != oldPath {
+		if err := os.Remove(oldPath); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("repositories: no se pudo eliminar el archivo anterior %s: %w", oldPath, err)
+		}
+	}
+	r.logger.WithFields(logrus.Fields{"method": "Update", "file": newPath}).Info("Proyecto actualizado")
+	return nil
+}
+
+// Delete elimina el archivo del proyecto.
+func (r *fileProjectRepository) Delete(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	filePath, err := r.findProjectFile(id)
+	if err != nil {
+		return err
+	}
+	if filePath == "" {
+		return ErrProjectNotFound
+	}
+	if err := os.Remove(filePath); err != nil {
+		return fmt.Errorf("repositories: no se pudo eliminar el archivo %s: %w", filePath, err)
+	}
+	r.logger.WithFields(logrus.Fields{"method": "Delete", "file": filePath}).Info("Proyecto eliminado")
+	return nil
+}
+
+// findProjectFile busca el archivo cuyo nombre empieza por "<id> - ".
+// Devuelve una ruta vacía si no existe.
+func (r *fileProjectRepository) findProjectFile(id string) (string, error) {
+	if id == "" {
+		return "", nil
+	}
+	entries, err := os.ReadDir(r.directory)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+		return "", fmt.Errorf("repositories: no se pudo leer el directorio de proyectos %s: %w", r.directory, err)
+	}
+	prefix := id + " - "
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasPrefix(entry.Name(), prefix) && filepath.Ext(entry.Name()) == ".json" {
+			return filepath.Join(r.directory, entry.Name()), nil
+		}
+	}
+	return "", nil
+}
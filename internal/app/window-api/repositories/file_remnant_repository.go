@@ -0,0 +1,238 @@
+package repositories
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mvialf/windraw/internal/app/window-api/models"
+	"github.com/sirupsen/logrus"
+)
+
+// fileRemnantRepository implementa RemnantRepository guardando todo el inventario de retazos en un
+// único archivo JSON. Cada escritura reescribe el archivo completo en uno temporal y lo renombra,
+// para no dejar el inventario a medias si el proceso se interrumpe.
+// Los planes de corte registrados se guardan de la misma forma en un segundo archivo, plansPath.
+type fileRemnantRepository struct {
+	filePath  string
+	plansPath string
+	mu        sync.RWMutex
+	logger    *logrus.Entry
+}
+
+// NewFileRemnantRepository crea un repositorio de retazos respaldado por el archivo filePath.
+// Los planes registrados van junto a él, en <nombre>_plans.json. Los archivos se crean con la
+// primera escritura.
+func NewFileRemnantRepository(filePath string, logger *logrus.Logger) RemnantRepository {
+	return &fileRemnantRepository{
+		filePath:  filePath,
+		plansPath: strings.TrimSuffix(filePath, filepath.Ext(filePath)) + "_plans.json",
+		logger:    logger.WithFields(logrus.Fields{"repository": "remnant_file", "file": filePath}),
+	}
+}
+
+// List devuelve los retazos que cumplen el filtro.
+func (r *fileRemnantRepository) List(ctx context.Context, filter models.RemnantFilter) ([]models.Remnant, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	remnants, err := r.load()
+	if err != nil {
+		return nil, err
+	}
+	matched := []models.Remnant{}
+	for _, remnant := range remnants {
+		if filter.Matches(remnant) {
+			matched = append(matched, remnant)
+		}
+	}
+	sortRemnants(matched)
+	return matched, nil
+}
+
+// Get obtiene un retazo por su ID.
+func (r *fileRemnantRepository) Get(ctx context.Context, id string) (*models.Remnant, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	remnants, err := r.load()
+	if err != nil {
+		return nil, err
+	}
+	for i := range remnants {
+		if remnants[i].ID == id {
+			return &remnants[i], nil
+		}
+	}
+	return nil, ErrRemnantNotFound
+}
+
+// Create agrega un retazo. Falla si ya existe uno con el mismo ID.
+func (r *fileRemnantRepository) Create(ctx context.Context, remnant *models.Remnant) error {
+	if remnant == nil || remnant.ID == "" {
+		return errors.New("repositories: no se puede crear un retazo nulo o sin ID")
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	remnants, err := r.load()
+	if err != nil {
+		return err
+	}
+	for _, existing := range remnants {
+		if existing.ID == remnant.ID {
+			return fmt.Errorf("repositories: ya existe un retazo con ID '%s'", remnant.ID)
+		}
+	}
+	if err := r.save(append(remnants, *remnant)); err != nil {
+		return err
+	}
+	r.logger.WithFields(logrus.Fields{"method": "Create", "remnant_id": remnant.ID}).Info("Retazo guardado")
+	return nil
+}
+
+// Delete elimina un retazo por su ID.
+func (r *fileRemnantRepository) Delete(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	remnants, err := r.load()
+	if err != nil {
+		return err
+	}
+	for i := range remnants {
+		if remnants[i].ID != id {
+			continue
+		}
+		if err := r.save(append(remnants[:i], remnants[i+1:]...)); err != nil {
+			return err
+		}
+		r.logger.WithFields(logrus.Fields{"method": "Delete", "remnant_id": id}).Info("Retazo eliminado")
+		return nil
+	}
+	return ErrRemnantNotFound
+}
+
+// ClaimPlan marca el plan planID como registrado.
+func (r *fileRemnantRepository) ClaimPlan(ctx context.Context, planID, projectID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	plans, err := r.loadPlans()
+	if err != nil {
+		return err
+	}
+	for _, plan := range plans {
+		if plan.PlanID == planID {
+			return ErrPlanAlreadyRecorded
+		}
+	}
+	plans = append(plans, models.RecordedPlan{PlanID: planID, ProjectID: projectID, RecordedAt: time.Now()})
+	if err := writeFileAtomic(r.plansPath, plans); err != nil {
+		return err
+	}
+	r.logger.WithFields(logrus.Fields{"method": "ClaimPlan", "plan_id": planID}).Info("Plan de corte marcado como registrado")
+	return nil
+}
+
+// ReleasePlan quita la marca del plan planID. No falla si el plan no estaba marcado.
+func (r *fileRemnantRepository) ReleasePlan(ctx context.Context, planID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	plans, err := r.loadPlans()
+	if err != nil {
+		return err
+	}
+	for i := range plans {
+		if plans[i].PlanID == planID {
+			return writeFileAtomic(r.plansPath, append(plans[:i], plans[i+1:]...))
+		}
+	}
+	return nil
+}
+
+// load lee el inventario completo. Un archivo inexistente es un inventario vacío.
+func (r *fileRemnantRepository) load() ([]models.Remnant, error) {
+	data, err := os.ReadFile(r.filePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []models.Remnant{}, nil
+		}
+		return nil, fmt.Errorf("repositories: no se pudo leer el archivo de retazos %s: %w", r.filePath, err)
+	}
+	var remnants []models.Remnant
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &remnants); err != nil {
+			return nil, fmt.Errorf("repositories: el archivo de retazos %s no es JSON válido: %w", r.filePath, err)
+		}
+	}
+	return remnants, nil
+}
+
+// loadPlans lee los planes registrados. Un archivo inexistente es una lista vacía.
+func (r *fileRemnantRepository) loadPlans() ([]models.RecordedPlan, error) {
+	data, err := os.ReadFile(r.plansPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []models.RecordedPlan{}, nil
+		}
+		return nil, fmt.Errorf("repositories: no se pudo leer el archivo de planes %s: %w", r.plansPath, err)
+	}
+	var plans []models.RecordedPlan
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &plans); err != nil {
+			return nil, fmt.Errorf("repositories: el archivo de planes %s no es JSON válido: %w", r.plansPath, err)
+		}
+	}
+	return plans, nil
+}
+
+// save reescribe el inventario completo.
+func (r *fileRemnantRepository) save(remnants []models.Remnant) error {
+	sortRemnants(remnants)
+	return writeFileAtomic(r.filePath, remnants)
+}
+
+// writeFileAtomic escribe v como JSON en un archivo temporal y lo renombra a path.
+func writeFileAtomic(path string, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("repositories: no se pudo serializar %s: %w", path, err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("repositories: no se pudo crear el directorio de %s: %w", path, err)
+	}
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		return fmt.Errorf("repositories: no se pudo escribir el archivo %s: %w", tmpPath, err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		return fmt.Errorf("repositories: no se pudo reemplazar el archivo %s: %w", path, err)
+	}
+	return nil
+}
+
+// sortRemnants ordena los retazos por SKU, color y largo ascendente.
+func sortRemnants(remnants []models.Remnant) {
+	sort.SliceStable(remnants, func(i, j int) bool {
+		a, b := remnants[i], remnants[j]
+		if a.ItemSKU != b.ItemSKU {
+			return a.ItemSKU < b.ItemSKU
+		}
+		if a.Color != b.Color {
+			return a.Color < b.Color
+		}
+		if a.LengthMM != b.LengthMM {
+			return a.LengthMM < b.LengthMM
+		}
+		return a.ID < b.ID
+	})
+}
@@ -10,5 +10,6 @@ import (
 type ProfileCatalogRepository interface {
 	GetAllProfiles(ctx context.Context) ([]models.Profile, error)
 	GetProfileBySKU(ctx context.Context, sku string) (*models.Profile, error)
+	GetProfileByID(ctx context.Context, id int64) (*models.Profile, error)
 	// Podrías tener más métodos como GetProfilesByMaterial(ctx context.Context, material string) ([]models.Profile, error)
 }
@@ -0,0 +1,19 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/mvialf/windraw/internal/app/window-api/models"
+)
+
+// ProfileSystemRepository define las consultas sobre sistemas de perfiles y sus perfiles habilitados.
+type ProfileSystemRepository interface {
+	// GetMaterialByName devuelve el material con ese nombre (sin distinguir mayúsculas) o nil si no existe.
+	GetMaterialByName(ctx context.Context, name string) (*models.Material, error)
+	// GetSystemsByType devuelve los sistemas de un tipo de elemento y material, ordenados por prymacy.
+	GetSystemsByType(ctx context.Context, systemType string, materialID int64) ([]models.ProfileSystem, error)
+	// GetSystemByID devuelve un sistema por su system_id o nil si no existe.
+	GetSystemByID(ctx context.Context, systemID int64) (*models.ProfileSystem, error)
+	// GetSystemProfiles devuelve las filas de system_profile_list del sistema con el perfil embebido.
+	GetSystemProfiles(ctx context.Context, systemID int64) ([]models.SystemProfile, error)
+}
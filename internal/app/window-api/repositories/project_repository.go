@@ -0,0 +1,21 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+
+	"github.com/mvialf/windraw/internal/app/window-api/models"
+)
+
+// ErrProjectNotFound se devuelve cuando el proyecto solicitado no existe en el almacenamiento.
+var ErrProjectNotFound = errors.New("repositories: proyecto no encontrado")
+
+// ProjectRepository define las operaciones de persistencia de proyectos.
+// Cada proyecto se guarda completo, incluyendo sus Components, Modules y Elements anidados.
+type ProjectRepository interface {
+	Create(ctx context.Context, project *models.Project) error
+	Get(ctx context.Context, id string) (*models.Project, error)
+	List(ctx context.Context) ([]models.Project, error)
+	Update(ctx context.Context, project *models.Project) error
+	Delete(ctx context.Context, id string) error
+}
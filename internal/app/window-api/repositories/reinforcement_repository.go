@@ -0,0 +1,14 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/mvialf/windraw/internal/app/window-api/models"
+)
+
+// ReinforcementRepository define las consultas sobre la tabla profile_reinforcements.
+type ReinforcementRepository interface {
+	// GetReinforcements devuelve el refuerzo de cada perfil indicado, indexado por main_profile_id.
+	// Los perfiles sin refuerzo no aparecen en el mapa.
+	GetReinforcements(ctx context.Context, mainProfileIDs []int64) (map[int64]models.ProfileReinforcement, error)
+}
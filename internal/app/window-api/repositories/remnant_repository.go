@@ -0,0 +1,28 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+
+	"github.com/mvialf/windraw/internal/app/window-api/models"
+)
+
+// ErrRemnantNotFound se devuelve cuando el retazo solicitado no existe en el almacenamiento.
+var ErrRemnantNotFound = errors.New("repositories: retazo no encontrado")
+
+// ErrPlanAlreadyRecorded se devuelve al marcar como registrado un plan de corte que ya lo estaba.
+var ErrPlanAlreadyRecorded = errors.New("repositories: el plan de corte ya fue registrado")
+
+// RemnantRepository define las operaciones de persistencia del inventario de retazos.
+type RemnantRepository interface {
+	// List devuelve los retazos que cumplen el filtro, ordenados por SKU, color y largo ascendente.
+	List(ctx context.Context, filter models.RemnantFilter) ([]models.Remnant, error)
+	Get(ctx context.Context, id string) (*models.Remnant, error)
+	Create(ctx context.Context, remnant *models.Remnant) error
+	Delete(ctx context.Context, id string) error
+	// ClaimPlan marca el plan de corte planID como registrado, o devuelve ErrPlanAlreadyRecorded
+	// si ya lo estaba. La comprobación y la marca son una sola operación.
+	ClaimPlan(ctx context.Context, planID, projectID string) error
+	// ReleasePlan quita la marca de ClaimPlan cuando el registro del plan no se pudo completar.
+	ReleasePlan(ctx context.Context, planID string) error
+}
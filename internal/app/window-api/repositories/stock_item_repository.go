@@ -0,0 +1,15 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/mvialf/windraw/internal/app/window-api/models"
+)
+
+// StockItemRepository define las consultas sobre la tabla stock_items.
+type StockItemRepository interface {
+	// GetStockItems devuelve los ítems de los perfiles indicados en un color.
+	GetStockItems(ctx context.Context, profileIDs []int64, colorID int64) ([]models.StockItem, error)
+	// GetAllStockItems devuelve la tabla completa, leyendo todas las páginas.
+	GetAllStockItems(ctx context.Context) ([]models.StockItem, error)
+}
@@ -0,0 +1,60 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mvialf/windraw/internal/app/window-api/models"
+	"github.com/mvialf/windraw/internal/pkg/apiclient"
+	"github.com/patrickmn/go-cache"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	systemAvailableColorsPath = "/rest/v1/system_available_colors"
+
+	systemColorsCachePrefix = "catalog:system_colors:"
+)
+
+// supabaseColorRepository implementa ColorRepository con Supabase y caché.
+type supabaseColorRepository struct {
+	supabaseClient *apiclient.SupabaseClient
+	cache          *cache.Cache
+	logger         *logrus.Entry
+}
+
+// NewSupabaseColorRepository crea una nueva instancia del repositorio de colores.
+func NewSupabaseColorRepository(client *apiclient.SupabaseClient, logger *logrus.Logger) ColorRepository {
+	return &supabaseColorRepository{
+		supabaseClient: client,
+		cache:          cache.New(profilesCacheDefaultExpiration, profilesCacheCleanupInterval),
+		logger:         logger.WithField("repository", "color"),
+	}
+}
+
+// GetSystemColors devuelve los colores disponibles del sistema, con nombre y código hexadecimal.
+func (r *supabaseColorRepository) GetSystemColors(ctx context.Context, systemID int64) ([]models.SystemColor, error) {
+	cacheKey := fmt.Sprintf("%s%d", systemColorsCachePrefix, systemID)
+	if cached, found := r.cache.Get(cacheKey); found {
+		if colors, ok := cached.([]models.SystemColor); ok {
+			return colors, nil
+		}
+	}
+
+	colors := []models.SystemColor{}
+	queryParams, err := apiclient.NewQuery().
+		Select("system_id", "color_id", "color_code_suffix", "colors(color_id,name,hex_code)").
+		Eq("system_id", systemID).
+		Order("color_id", true).
+		Build()
+	if err != nil {
+		return nil, fmt.Errorf("error construyendo consulta de colores del sistema %d: %w", systemID, err)
+	}
+	if _, err := r.supabaseClient.QueryAll(ctx, systemAvailableColorsPath, queryParams, apiclient.PageOptions{}, &colors); err != nil {
+		r.logger.WithError(err).WithField("system_id", systemID).Error("Error obteniendo colores del sistema de Supabase")
+		return nil, fmt.Errorf("error obteniendo colores del sistema %d de Supabase: %w", systemID, err)
+	}
+
+	r.cache.Set(cacheKey, colors, cache.DefaultExpiration)
+	return colors, nil
+}
@@ -19,21 +19,35 @@ const (
 	// Prefijos y claves para el caché de perfiles
 	allProfilesCacheKey     = "catalog:all_profiles"
 	profileBySKUCachePrefix = "catalog:profile_sku:"
+	profileByIDCachePrefix  = "catalog:profile_id:"
 )
 
+// profilesCatalogPath es el endpoint PostgREST de la tabla de perfiles.
+const profilesCatalogPath = "/rest/v1/profiles"
+
+// profileColumns son las columnas seleccionadas; deben coincidir con los tags JSON de models.Profile.
+var profileColumns = []string{
+	"profile_id", "profile_sku", "profile_name", "profile_type", "material_id", "supplier_id", "profile_weigth_meter",
+	"profile_h", "profile_w", "profile_h1", "profile_h2", "profile_h3", "profile_h4", "profile_w1",
+	"profile_cut_type", "cut_margin_mm", "track_count", "uses_overlap", "profile_position", "profile_structure", "weld_margin",
+}
+
 // supabaseProfileCatalogRepository implementa ProfileCatalogRepository con Supabase y caché.
 type supabaseProfileCatalogRepository struct {
 	supabaseClient *apiclient.SupabaseClient
+	dimensions     models.ProfileDimensionMap // Cotas de las que se toman las medidas de cálculo
 	cache          *cache.Cache
 	logger         *logrus.Entry // Usar logrus.Entry para logging contextualizado
 }
 
 // NewSupabaseProfileCatalogRepository crea una nueva instancia del repositorio de catálogo de perfiles.
-func NewSupabaseProfileCatalogRepository(client *apiclient.SupabaseClient, logger *logrus.Logger) ProfileCatalogRepository {
+// dimensions se aplica a cada perfil leído antes de guardarlo en caché.
+func NewSupabaseProfileCatalogRepository(client *apiclient.SupabaseClient, dimensions models.ProfileDimensionMap, logger *logrus.Logger) ProfileCatalogRepository {
 	// Creamos un logger contextualizado para este repositorio específico
 	repoLogger := logger.WithField("repository", "profile_catalog")
 	return &supabaseProfileCatalogRepository{
 		supabaseClient: client,
+		dimensions:     dimensions,
 		cache:          cache.New(profilesCacheDefaultExpiration, profilesCacheCleanupInterval),
 		logger:         repoLogger,
 	}
@@ -57,16 +71,23 @@ func (r *supabaseProfileCatalogRepository) GetAllProfiles(ctx context.Context) (
 
 	// 2. Si no está en caché, obtener de Supabase
 	var profiles []models.Profile
-	// AJUSTA EL NOMBRE DE TU TABLA DE PERFILES EN SUPABASE SI ES NECESARIO
-	supabasePath := "/rest/v1/profiles_catalog"
-	// Es mejor seleccionar columnas explícitas en lugar de "select=*"
-	queryParams := "select=id,sku,description,material,weight_per_meter,available_colors,created_at,updated_at" // Ejemplo
+	// Se ordena por la clave primaria para que las páginas sean estables
+	queryParams, err := apiclient.NewQuery().Select(profileColumns...).Order("profile_id", true).Build()
+	if err != nil {
+		return nil, fmt.Errorf("error construyendo consulta de perfiles: %w", err)
+	}
 
-	if err := r.supabaseClient.QueryData(supabasePath, queryParams, &profiles); err != nil {
+	// Lectura paginada: PostgREST limita cada respuesta, así que se recorren todas las páginas.
+	// Con ExactCount se verifica que el total leído coincida con el de la tabla.
+	if _, err := r.supabaseClient.QueryAll(ctx, profilesCatalogPath, queryParams, apiclient.PageOptions{ExactCount: true}, &profiles); err != nil {
 		log.WithError(err).Error("Error obteniendo perfiles de Supabase")
 		return nil, fmt.Errorf("error obteniendo perfiles de Supabase: %w", err)
 	}
 
+	for i := range profiles {
+		r.dimensions.Apply(&profiles[i])
+	}
+
 	// 3. Guardar en caché
 	r.cache.Set(allProfilesCacheKey, profiles, cache.DefaultExpiration) // Usa el default del caché para este repo
 	log.Infof("Perfiles obtenidos de Supabase y guardados en caché. Total: %d", len(profiles))
@@ -75,9 +96,20 @@ func (r *supabaseProfileCatalogRepository) GetAllProfiles(ctx context.Context) (
 }
 
 // GetProfileBySKU obtiene un perfil específico por su SKU, utilizando caché.
+// Devuelve nil, nil si el perfil no existe.
 func (r *supabaseProfileCatalogRepository) GetProfileBySKU(ctx context.Context, sku string) (*models.Profile, error) {
-	cacheKey := profileBySKUCachePrefix + sku
-	log := r.logger.WithFields(logrus.Fields{"method": "GetProfileBySKU", "sku": sku, "cache_key": cacheKey})
+	return r.getProfile(ctx, profileBySKUCachePrefix+sku, "profile_sku", sku)
+}
+
+// GetProfileByID obtiene un perfil específico por su profile_id, utilizando caché.
+// Devuelve nil, nil si el perfil no existe.
+func (r *supabaseProfileCatalogRepository) GetProfileByID(ctx context.Context, id int64) (*models.Profile, error) {
+	return r.getProfile(ctx, fmt.Sprintf("%s%d", profileByIDCachePrefix, id), "profile_id", id)
+}
+
+// getProfile obtiene un único perfil filtrando column = value, con caché (incluido el "no encontrado").
+func (r *supabaseProfileCatalogRepository) getProfile(ctx context.Context, cacheKey, column string, value interface{}) (*models.Profile, error) {
+	log := r.logger.WithFields(logrus.Fields{"method": "getProfile", column: value, "cache_key": cacheKey})
 
 	// 1. Intentar obtener del caché
 	if cachedData, found := r.cache.Get(cacheKey); found {
@@ -90,7 +122,7 @@ func (r *supabaseProfileCatalogRepository) GetProfileBySKU(ctx context.Context,
 			log.Info("Cache HIT")
 			return profile, nil
 		}
-		log.Warn("Tipo de dato incorrecto en caché para perfil SKU. Se eliminará.")
+		log.Warn("Tipo de dato incorrecto en caché para perfil. Se eliminará.")
 		r.cache.Delete(cacheKey)
 	}
 
@@ -98,14 +130,14 @@ func (r *supabaseProfileCatalogRepository) GetProfileBySKU(ctx context.Context,
 
 	// 2. Si no está en caché, obtener de Supabase
 	var profiles []models.Profile // Supabase generalmente devuelve un array
-	// AJUSTA EL NOMBRE DE TU TABLA DE PERFILES EN SUPABASE SI ES NECESARIO
-	supabasePath := "/rest/v1/profiles_catalog"
-	// Es mejor seleccionar columnas explícitas. Asegúrate que los nombres de columna coincidan con tu struct models.Profile.
-	supabaseQueryParams := fmt.Sprintf("sku=eq.%s&select=id,sku,description,material,weight_per_meter,available_colors,created_at,updated_at&limit=1", sku)
-
-	if err := r.supabaseClient.QueryData(supabasePath, supabaseQueryParams, &profiles); err != nil {
-		log.WithError(err).Error("Error obteniendo perfil por SKU de Supabase")
-		return nil, fmt.Errorf("error obteniendo perfil SKU '%s' de Supabase: %w", sku, err)
+	supabaseQueryParams, err := apiclient.NewQuery().Select(profileColumns...).Eq(column, value).Limit(1).Build()
+	if err != nil {
+		return nil, fmt.Errorf("error construyendo consulta de perfil %s='%v': %w", column, value, err)
+	}
+
+	if err := r.supabaseClient.QueryData(ctx, profilesCatalogPath, supabaseQueryParams, &profiles); err != nil {
+		log.WithError(err).Error("Error obteniendo perfil de Supabase")
+		return nil, fmt.Errorf("error obteniendo perfil %s='%v' de Supabase: %w", column, value, err)
 	}
 
 	if len(profiles) == 0 {
@@ -116,6 +148,7 @@ func (r *supabaseProfileCatalogRepository) GetProfileBySKU(ctx context.Context,
 	}
 
 	profileToCache := &profiles[0]
+	r.dimensions.Apply(profileToCache)
 
 	// 3. Guardar en caché
 	r.cache.Set(cacheKey, profileToCache, cache.DefaultExpiration) // Usa el default del caché para este repo
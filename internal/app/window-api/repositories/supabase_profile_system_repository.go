@@ -0,0 +1,167 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/mvialf/windraw/internal/app/window-api/models"
+	"github.com/mvialf/windraw/internal/pkg/apiclient"
+	"github.com/patrickmn/go-cache"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	materialsPath         = "/rest/v1/materials"
+	profileSystemsPath    = "/rest/v1/profile_systems"
+	systemProfileListPath = "/rest/v1/system_profile_list"
+
+	// Claves de caché; los sistemas usan los mismos TTL que el catálogo de perfiles
+	materialByNameCachePrefix = "catalog:material_name:"
+	systemsByTypeCachePrefix  = "catalog:systems_type:"
+	systemByIDCachePrefix     = "catalog:system_id:"
+	systemProfilesCachePrefix = "catalog:system_profiles:"
+)
+
+// supabaseProfileSystemRepository implementa ProfileSystemRepository con Supabase y caché.
+type supabaseProfileSystemRepository struct {
+	supabaseClient *apiclient.SupabaseClient
+	dimensions     models.ProfileDimensionMap // Se aplica a los perfiles embebidos, como en el catálogo
+	cache          *cache.Cache
+	logger         *logrus.Entry
+}
+
+// NewSupabaseProfileSystemRepository crea una nueva instancia del repositorio de sistemas de perfiles.
+func NewSupabaseProfileSystemRepository(client *apiclient.SupabaseClient, dimensions models.ProfileDimensionMap, logger *logrus.Logger) ProfileSystemRepository {
+	return &supabaseProfileSystemRepository{
+		supabaseClient: client,
+		dimensions:     dimensions,
+		cache:          cache.New(profilesCacheDefaultExpiration, profilesCacheCleanupInterval),
+		logger:         logger.WithField("repository", "profile_system"),
+	}
+}
+
+// GetMaterialByName busca un material por nombre exacto sin distinguir mayúsculas.
+func (r *supabaseProfileSystemRepository) GetMaterialByName(ctx context.Context, name string) (*models.Material, error) {
+	cacheKey := materialByNameCachePrefix + strings.ToLower(name)
+	if cached, found := r.cache.Get(cacheKey); found {
+		material, _ := cached.(*models.Material)
+		return material, nil
+	}
+
+	var materials []models.Material
+	queryParams, err := apiclient.NewQuery().Select("material_id", "name").IEq("name", name).Order("material_id", true).Build()
+	if err != nil {
+		return nil, fmt.Errorf("error construyendo consulta de material '%s': %w", name, err)
+	}
+	if err := r.supabaseClient.QueryData(ctx, materialsPath, queryParams, &materials); err != nil {
+		r.logger.WithError(err).WithField("material", name).Error("Error obteniendo material de Supabase")
+		return nil, fmt.Errorf("error obteniendo material '%s' de Supabase: %w", name, err)
+	}
+
+	// IEq deja pasar otros caracteres donde el nombre tiene '*'; se confirma la igualdad aquí
+	var material *models.Material
+	for i := range materials {
+		if strings.EqualFold(materials[i].Name, name) {
+			material = &materials[i]
+			break
+		}
+	}
+	r.cache.Set(cacheKey, material, cache.DefaultExpiration)
+	return material, nil
+}
+
+// GetSystemsByType devuelve los sistemas de un tipo y material, ordenados por prymacy (NULL al final) y system_id.
+func (r *supabaseProfileSystemRepository) GetSystemsByType(ctx context.Context, systemType string, materialID int64) ([]models.ProfileSystem, error) {
+	cacheKey := fmt.Sprintf("%s%s:%d", systemsByTypeCachePrefix, strings.ToLower(systemType), materialID)
+	if cached, found := r.cache.Get(cacheKey); found {
+		if systems, ok := cached.([]models.ProfileSystem); ok {
+			return systems, nil
+		}
+	}
+
+	systems := []models.ProfileSystem{}
+	queryParams, err := apiclient.NewQuery().
+		Select("*").
+		IEq("type", systemType).
+		Eq("material_id", materialID).
+		OrderNullsLast("prymacy", true).
+		Order("system_id", true).
+		Build()
+	if err != nil {
+		return nil, fmt.Errorf("error construyendo consulta de sistemas '%s': %w", systemType, err)
+	}
+	if _, err := r.supabaseClient.QueryAll(ctx, profileSystemsPath, queryParams, apiclient.PageOptions{}, &systems); err != nil {
+		r.logger.WithError(err).WithField("type", systemType).Error("Error obteniendo sistemas de Supabase")
+		return nil, fmt.Errorf("error obteniendo sistemas de perfiles tipo '%s' de Supabase: %w", systemType, err)
+	}
+	exact := systems[:0]
+	for _, system := range systems {
+		if strings.EqualFold(system.Type, systemType) {
+			exact = append(exact, system)
+		}
+	}
+	systems = exact
+
+	r.cache.Set(cacheKey, systems, cache.DefaultExpiration)
+	return systems, nil
+}
+
+// GetSystemByID devuelve un sistema por su system_id o nil si no existe.
+func (r *supabaseProfileSystemRepository) GetSystemByID(ctx context.Context, systemID int64) (*models.ProfileSystem, error) {
+	cacheKey := fmt.Sprintf("%s%d", systemByIDCachePrefix, systemID)
+	if cached, found := r.cache.Get(cacheKey); found {
+		system, _ := cached.(*models.ProfileSystem)
+		return system, nil
+	}
+
+	var systems []models.ProfileSystem
+	queryParams, err := apiclient.NewQuery().Select("*").Eq("system_id", systemID).Limit(1).Build()
+	if err != nil {
+		return nil, fmt.Errorf("error construyendo consulta de sistema %d: %w", systemID, err)
+	}
+	if err := r.supabaseClient.QueryData(ctx, profileSystemsPath, queryParams, &systems); err != nil {
+		r.logger.WithError(err).WithField("system_id", systemID).Error("Error obteniendo sistema de Supabase")
+		return nil, fmt.Errorf("error obteniendo sistema %d de Supabase: %w", systemID, err)
+	}
+
+	var system *models.ProfileSystem
+	if len(systems) > 0 {
+		system = &systems[0]
+	}
+	r.cache.Set(cacheKey, system, cache.DefaultExpiration)
+	return system, nil
+}
+
+// GetSystemProfiles devuelve los perfiles habilitados en el sistema, con el perfil completo embebido.
+func (r *supabaseProfileSystemRepository) GetSystemProfiles(ctx context.Context, systemID int64) ([]models.SystemProfile, error) {
+	cacheKey := fmt.Sprintf("%s%d", systemProfilesCachePrefix, systemID)
+	if cached, found := r.cache.Get(cacheKey); found {
+		if list, ok := cached.([]models.SystemProfile); ok {
+			return list, nil
+		}
+	}
+
+	list := []models.SystemProfile{}
+	queryParams, err := apiclient.NewQuery().
+		Select("system_id", "profile_id", "primacy", "profiles("+strings.Join(profileColumns, ",")+")").
+		Eq("system_id", systemID).
+		OrderNullsLast("primacy", true).
+		Order("profile_id", true).
+		Build()
+	if err != nil {
+		return nil, fmt.Errorf("error construyendo consulta de perfiles del sistema %d: %w", systemID, err)
+	}
+	if _, err := r.supabaseClient.QueryAll(ctx, systemProfileListPath, queryParams, apiclient.PageOptions{}, &list); err != nil {
+		r.logger.WithError(err).WithField("system_id", systemID).Error("Error obteniendo perfiles del sistema de Supabase")
+		return nil, fmt.Errorf("error obteniendo perfiles del sistema %d de Supabase: %w", systemID, err)
+	}
+	for _, item := range list {
+		if item.Profile != nil {
+			r.dimensions.Apply(item.Profile)
+		}
+	}
+
+	r.cache.Set(cacheKey, list, cache.DefaultExpiration)
+	return list, nil
+}
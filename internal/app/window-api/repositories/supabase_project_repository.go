@@ -0,0 +1,132 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/mvialf/windraw/internal/app/window-api/models"
+	"github.com/mvialf/windraw/internal/pkg/apiclient"
+	"github.com/sirupsen/logrus"
+)
+
+// projectsPath es el endpoint PostgREST de la tabla de proyectos.
+// La tabla replica la forma JSON de models.Project; los campos anidados se guardan como jsonb:
+//
+//	CREATE TABLE projects (
+//	    id          TEXT PRIMARY KEY,
+//	    name        TEXT NOT NULL,
+//	    created_at  TIMESTAMPTZ NOT NULL DEFAULT now(),
+//	    contact     JSONB NOT NULL,
+//	    costs       JSONB NOT NULL DEFAULT '[]',
+//	    components  JSONB NOT NULL DEFAULT '[]',
+//	    iva_rate    NUMERIC NOT NULL
+//	);
+const projectsPath = "/rest/v1/projects"
+
+// supabaseProjectRepository implementa ProjectRepository sobre la tabla projects de Supabase.
+type supabaseProjectRepository struct {
+	supabaseClient *apiclient.SupabaseClient
+	logger         *logrus.Entry
+}
+
+// NewSupabaseProjectRepository crea un repositorio de proyectos respaldado por Supabase.
+func NewSupabaseProjectRepository(client *apiclient.SupabaseClient, logger *logrus.Logger) ProjectRepository {
+	return &supabaseProjectRepository{
+		supabaseClient: client,
+		logger:         logger.WithField("repository", "project_supabase"),
+	}
+}
+
+// Create inserta un nuevo proyecto.
+func (r *supabaseProjectRepository) Create(ctx context.Context, project *models.Project) error {
+	if project == nil || project.ID == "" {
+		return errors.New("repositories: no se puede crear un proyecto nulo o sin ID")
+	}
+	log := r.logger.WithFields(logrus.Fields{"method": "Create", "project_id": project.ID})
+
+	if err := r.supabaseClient.Insert(ctx, projectsPath, project, nil); err != nil {
+		log.WithError(err).Error("Error insertando proyecto en Supabase")
+		return fmt.Errorf("error insertando proyecto '%s' en Supabase: %w", project.ID, err)
+	}
+	log.Info("Proyecto creado en Supabase")
+	return nil
+}
+
+// Get obtiene un proyecto por su ID.
+func (r *supabaseProjectRepository) Get(ctx context.Context, id string) (*models.Project, error) {
+	log := r.logger.WithFields(logrus.Fields{"method": "Get", "project_id": id})
+
+	var projects []models.Project
+	queryParams, err := apiclient.NewQuery().Select("*").Eq("id", id).Limit(1).Build()
+	if err != nil {
+		return nil, fmt.Errorf("error construyendo consulta de proyecto '%s': %w", id, err)
+	}
+	if err := r.supabaseClient.QueryData(ctx, projectsPath, queryParams, &projects); err != nil {
+		log.WithError(err).Error("Error obteniendo proyecto de Supabase")
+		return nil, fmt.Errorf("error obteniendo proyecto '%s' de Supabase: %w", id, err)
+	}
+	if len(projects) == 0 {
+		return nil, ErrProjectNotFound
+	}
+	return &projects[0], nil
+}
+
+// List obtiene todos los proyectos, del más reciente al más antiguo.
+func (r *supabaseProjectRepository) List(ctx context.Context) ([]models.Project, error) {
+	log := r.logger.WithField("method", "List")
+
+	projects := []models.Project{}
+	queryParams, err := apiclient.NewQuery().Select("*").Order("created_at", false).Order("id", true).Build()
+	if err != nil {
+		return nil, fmt.Errorf("error construyendo consulta de proyectos: %w", err)
+	}
+	if _, err := r.supabaseClient.QueryAll(ctx, projectsPath, queryParams, apiclient.PageOptions{}, &projects); err != nil {
+		log.WithError(err).Error("Error listando proyectos de Supabase")
+		return nil, fmt.Errorf("error listando proyectos de Supabase: %w", err)
+	}
+	return projects, nil
+}
+
+// Update reemplaza el proyecto completo identificado por project.ID.
+func (r *supabaseProjectRepository) Update(ctx context.Context, project *models.Project) error {
+	if project == nil || project.ID == "" {
+		return errors.New("repositories: no se puede actualizar un proyecto nulo o sin ID")
+	}
+	log := r.logger.WithFields(logrus.Fields{"method": "Update", "project_id": project.ID})
+
+	var updated []models.Project
+	queryParams, err := apiclient.NewQuery().Eq("id", project.ID).Build()
+	if err != nil {
+		return fmt.Errorf("error construyendo filtro de proyecto '%s': %w", project.ID, err)
+	}
+	if err := r.supabaseClient.Update(ctx, projectsPath, queryParams, project, &updated); err != nil {
+		log.WithError(err).Error("Error actualizando proyecto en Supabase")
+		return fmt.Errorf("error actualizando proyecto '%s' en Supabase: %w", project.ID, err)
+	}
+	if len(updated) == 0 {
+		return ErrProjectNotFound
+	}
+	log.Info("Proyecto actualizado en Supabase")
+	return nil
+}
+
+// Delete elimina un proyecto por su ID.
+func (r *supabaseProjectRepository) Delete(ctx context.Context, id string) error {
+	log := r.logger.WithFields(logrus.Fields{"method": "Delete", "project_id": id})
+
+	var deleted []models.Project
+	queryParams, err := apiclient.NewQuery().Eq("id", id).Build()
+	if err != nil {
+		return fmt.Errorf("error construyendo filtro de proyecto '%s': %w", id, err)
+	}
+	if err := r.supabaseClient.Delete(ctx, projectsPath, queryParams, &deleted); err != nil {
+		log.WithError(err).Error("Error eliminando proyecto de Supabase")
+		return fmt.Errorf("error eliminando proyecto '%s' de Supabase: %w", id, err)
+	}
+	if len(deleted) == 0 {
+		return ErrProjectNotFound
+	}
+	log.Info("Proyecto eliminado de Supabase")
+	return nil
+}
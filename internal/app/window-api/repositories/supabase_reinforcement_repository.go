@@ -0,0 +1,86 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/mvialf/windraw/internal/app/window-api/models"
+	"github.com/mvialf/windraw/internal/pkg/apiclient"
+	"github.com/patrickmn/go-cache"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	profileReinforcementsPath = "/rest/v1/profile_reinforcements"
+
+	// La tabla es pequeña y cambia poco: se cachea completa con los TTL del catálogo
+	allReinforcementsCacheKey = "catalog:all_reinforcements"
+)
+
+// supabaseReinforcementRepository implementa ReinforcementRepository con Supabase y caché.
+type supabaseReinforcementRepository struct {
+	supabaseClient *apiclient.SupabaseClient
+	cache          *cache.Cache
+	logger         *logrus.Entry
+}
+
+// NewSupabaseReinforcementRepository crea una nueva instancia del repositorio de refuerzos.
+func NewSupabaseReinforcementRepository(client *apiclient.SupabaseClient, logger *logrus.Logger) ReinforcementRepository {
+	return &supabaseReinforcementRepository{
+		supabaseClient: client,
+		cache:          cache.New(profilesCacheDefaultExpiration, profilesCacheCleanupInterval),
+		logger:         logger.WithField("repository", "reinforcement"),
+	}
+}
+
+// GetReinforcements devuelve el refuerzo de cada perfil indicado. Si un perfil tiene varias filas
+// se usa la de menor reinforcement_profile_id.
+func (r *supabaseReinforcementRepository) GetReinforcements(ctx context.Context, mainProfileIDs []int64) (map[int64]models.ProfileReinforcement, error) {
+	all, err := r.getAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[int64]models.ProfileReinforcement, len(mainProfileIDs))
+	for _, id := range mainProfileIDs {
+		if reinforcement, ok := all[id]; ok {
+			result[id] = reinforcement
+		}
+	}
+	return result, nil
+}
+
+// getAll lee la tabla completa con el perfil de refuerzo embebido y la indexa por main_profile_id.
+func (r *supabaseReinforcementRepository) getAll(ctx context.Context) (map[int64]models.ProfileReinforcement, error) {
+	if cached, found := r.cache.Get(allReinforcementsCacheKey); found {
+		if all, ok := cached.(map[int64]models.ProfileReinforcement); ok {
+			return all, nil
+		}
+	}
+
+	rows := []models.ProfileReinforcement{}
+	// La tabla referencia dos veces a profiles: el hint indica la relación del refuerzo
+	queryParams, err := apiclient.NewQuery().
+		Select("main_profile_id", "reinforcement_profile_id", "reinforcement_gap_mm",
+			"reinforcement:profiles!reinforcement_profile_id("+strings.Join(profileColumns, ",")+")").
+		Order("main_profile_id", true).
+		Order("reinforcement_profile_id", true).
+		Build()
+	if err != nil {
+		return nil, fmt.Errorf("error construyendo consulta de profile_reinforcements: %w", err)
+	}
+	if _, err := r.supabaseClient.QueryAll(ctx, profileReinforcementsPath, queryParams, apiclient.PageOptions{ExactCount: true}, &rows); err != nil {
+		r.logger.WithError(err).Error("Error obteniendo profile_reinforcements de Supabase")
+		return nil, fmt.Errorf("error obteniendo profile_reinforcements de Supabase: %w", err)
+	}
+
+	all := make(map[int64]models.ProfileReinforcement, len(rows))
+	for _, row := range rows {
+		if _, exists := all[row.MainProfileID]; !exists {
+			all[row.MainProfileID] = row
+		}
+	}
+	r.cache.Set(allReinforcementsCacheKey, all, cache.DefaultExpiration)
+	r.logger.Infof("profile_reinforcements obtenidos de Supabase y guardados en caché. Total: %d", len(rows))
+	return all, nil
+}
@@ -0,0 +1,166 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/mvialf/windraw/internal/app/window-api/models"
+	"github.com/mvialf/windraw/internal/pkg/apiclient"
+	"github.com/sirupsen/logrus"
+)
+
+// remnantsPath es el endpoint PostgREST de la tabla de retazos:
+//
+//	CREATE TABLE remnants (
+//	    id          TEXT PRIMARY KEY,
+//	    item_sku    TEXT NOT NULL,
+//	    color       TEXT NOT NULL DEFAULT '',
+//	    length_mm   INTEGER NOT NULL CHECK (length_mm > 0),
+//	    location    TEXT NOT NULL DEFAULT '',
+//	    project_id  TEXT,
+//	    created_at  TIMESTAMPTZ NOT NULL DEFAULT now()
+//	);
+//	CREATE INDEX remnants_material_idx ON remnants (item_sku, color, length_mm);
+const remnantsPath = "/rest/v1/remnants"
+
+// recordedPlansPath es el endpoint PostgREST de la tabla de planes de corte registrados. La clave
+// primaria hace que un segundo ClaimPlan del mismo plan falle con 409:
+//
+//	CREATE TABLE recorded_cutting_plans (
+//	    plan_id      TEXT PRIMARY KEY,
+//	    project_id   TEXT,
+//	    recorded_at  TIMESTAMPTZ NOT NULL DEFAULT now()
+//	);
+const recordedPlansPath = "/rest/v1/recorded_cutting_plans"
+
+// supabaseRemnantRepository implementa RemnantRepository sobre la tabla remnants de Supabase.
+// No usa caché: el inventario cambia con cada corte registrado.
+type supabaseRemnantRepository struct {
+	supabaseClient *apiclient.SupabaseClient
+	logger         *logrus.Entry
+}
+
+// NewSupabaseRemnantRepository crea un repositorio de retazos respaldado por Supabase.
+func NewSupabaseRemnantRepository(client *apiclient.SupabaseClient, logger *logrus.Logger) RemnantRepository {
+	return &supabaseRemnantRepository{
+		supabaseClient: client,
+		logger:         logger.WithField("repository", "remnant_supabase"),
+	}
+}
+
+// List obtiene los retazos que cumplen el filtro.
+func (r *supabaseRemnantRepository) List(ctx context.Context, filter models.RemnantFilter) ([]models.Remnant, error) {
+	log := r.logger.WithFields(logrus.Fields{"method": "List", "item_sku": filter.ItemSKU, "color": filter.Color})
+
+	query := apiclient.NewQuery().Select("*")
+	if filter.ItemSKU != "" {
+		query = query.Eq("item_sku", filter.ItemSKU)
+	}
+	if filter.Color != "" {
+		query = query.Eq("color", filter.Color)
+	}
+	if filter.MinLengthMM > 0 {
+		query = query.Gte("length_mm", filter.MinLengthMM)
+	}
+	queryParams, err := query.Order("item_sku", true).Order("color", true).Order("length_mm", true).Order("id", true).Build()
+	if err != nil {
+		return nil, fmt.Errorf("error construyendo consulta de retazos: %w", err)
+	}
+
+	remnants := []models.Remnant{}
+	if _, err := r.supabaseClient.QueryAll(ctx, remnantsPath, queryParams, apiclient.PageOptions{}, &remnants); err != nil {
+		log.WithError(err).Error("Error listando retazos de Supabase")
+		return nil, fmt.Errorf("error listando retazos de Supabase: %w", err)
+	}
+	return remnants, nil
+}
+
+// Get obtiene un retazo por su ID.
+func (r *supabaseRemnantRepository) Get(ctx context.Context, id string) (*models.Remnant, error) {
+	log := r.logger.WithFields(logrus.Fields{"method": "Get", "remnant_id": id})
+
+	var remnants []models.Remnant
+	queryParams, err := apiclient.NewQuery().Select("*").Eq("id", id).Limit(1).Build()
+	if err != nil {
+		return nil, fmt.Errorf("error construyendo consulta de retazo '%s': %w", id, err)
+	}
+	if err := r.supabaseClient.QueryData(ctx, remnantsPath, queryParams, &remnants); err != nil {
+		log.WithError(err).Error("Error obteniendo retazo de Supabase")
+		return nil, fmt.Errorf("error obteniendo retazo '%s' de Supabase: %w", id, err)
+	}
+	if len(remnants) == 0 {
+		return nil, ErrRemnantNotFound
+	}
+	return &remnants[0], nil
+}
+
+// Create inserta un retazo.
+func (r *supabaseRemnantRepository) Create(ctx context.Context, remnant *models.Remnant) error {
+	if remnant == nil || remnant.ID == "" {
+		return errors.New("repositories: no se puede crear un retazo nulo o sin ID")
+	}
+	log := r.logger.WithFields(logrus.Fields{"method": "Create", "remnant_id": remnant.ID})
+
+	if err := r.supabaseClient.Insert(ctx, remnantsPath, remnant, nil); err != nil {
+		log.WithError(err).Error("Error insertando retazo en Supabase")
+		return fmt.Errorf("error insertando retazo '%s' en Supabase: %w", remnant.ID, err)
+	}
+	log.Info("Retazo creado en Supabase")
+	return nil
+}
+
+// Delete elimina un retazo por su ID.
+func (r *supabaseRemnantRepository) Delete(ctx context.Context, id string) error {
+	log := r.logger.WithFields(logrus.Fields{"method": "Delete", "remnant_id": id})
+
+	var deleted []models.Remnant
+	queryParams, err := apiclient.NewQuery().Eq("id", id).Build()
+	if err != nil {
+		return fmt.Errorf("error construyendo filtro de retazo '%s': %w", id, err)
+	}
+	if err := r.supabaseClient.Delete(ctx, remnantsPath, queryParams, &deleted); err != nil {
+		log.WithError(err).Error("Error eliminando retazo de Supabase")
+		return fmt.Errorf("error eliminando retazo '%s' de Supabase: %w", id, err)
+	}
+	if len(deleted) == 0 {
+		return ErrRemnantNotFound
+	}
+	log.Info("Retazo eliminado de Supabase")
+	return nil
+}
+
+// ClaimPlan inserta el plan en recorded_cutting_plans; un conflicto de clave primaria indica que ya
+// estaba registrado.
+func (r *supabaseRemnantRepository) ClaimPlan(ctx context.Context, planID, projectID string) error {
+	log := r.logger.WithFields(logrus.Fields{"method": "ClaimPlan", "plan_id": planID})
+
+	plan := models.RecordedPlan{PlanID: planID, ProjectID: projectID, RecordedAt: time.Now()}
+	if err := r.supabaseClient.Insert(ctx, recordedPlansPath, plan, nil); err != nil {
+		var apiErr *apiclient.APIError
+		if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusConflict {
+			return ErrPlanAlreadyRecorded
+		}
+		log.WithError(err).Error("Error marcando plan de corte en Supabase")
+		return fmt.Errorf("error marcando plan de corte '%s' en Supabase: %w", planID, err)
+	}
+	log.Info("Plan de corte marcado como registrado en Supabase")
+	return nil
+}
+
+// ReleasePlan elimina el plan de recorded_cutting_plans.
+func (r *supabaseRemnantRepository) ReleasePlan(ctx context.Context, planID string) error {
+	log := r.logger.WithFields(logrus.Fields{"method": "ReleasePlan", "plan_id": planID})
+
+	queryParams, err := apiclient.NewQuery().Eq("plan_id", planID).Build()
+	if err != nil {
+		return fmt.Errorf("error construyendo filtro de plan '%s': %w", planID, err)
+	}
+	if err := r.supabaseClient.Delete(ctx, recordedPlansPath, queryParams, nil); err != nil {
+		log.WithError(err).Error("Error liberando plan de corte en Supabase")
+		return fmt.Errorf("error liberando plan de corte '%s' en Supabase: %w", planID, err)
+	}
+	return nil
+}
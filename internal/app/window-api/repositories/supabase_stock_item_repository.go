@@ -0,0 +1,92 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/mvialf/windraw/internal/app/window-api/models"
+	"github.com/mvialf/windraw/internal/pkg/apiclient"
+	"github.com/patrickmn/go-cache"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	stockItemsPath = "/rest/v1/stock_items"
+
+	// El stock cambia a menudo: TTL corto para precios y existencias
+	stockCacheDefaultExpiration = 5 * time.Minute
+	stockCacheCleanupInterval   = 10 * time.Minute
+
+	allStockItemsCacheKey = "stock:all_items"
+)
+
+// supabaseStockItemRepository implementa StockItemRepository con Supabase y caché.
+type supabaseStockItemRepository struct {
+	supabaseClient *apiclient.SupabaseClient
+	cache          *cache.Cache
+	logger         *logrus.Entry
+}
+
+// NewSupabaseStockItemRepository crea una nueva instancia del repositorio de ítems de stock.
+func NewSupabaseStockItemRepository(client *apiclient.SupabaseClient, logger *logrus.Logger) StockItemRepository {
+	return &supabaseStockItemRepository{
+		supabaseClient: client,
+		cache:          cache.New(stockCacheDefaultExpiration, stockCacheCleanupInterval),
+		logger:         logger.WithField("repository", "stock_item"),
+	}
+}
+
+// GetStockItems devuelve los ítems de los perfiles indicados en un color. No usa caché
+// porque precio y stock deben estar al día al cotizar.
+func (r *supabaseStockItemRepository) GetStockItems(ctx context.Context, profileIDs []int64, colorID int64) ([]models.StockItem, error) {
+	items := []models.StockItem{}
+	if len(profileIDs) == 0 {
+		return items, nil
+	}
+
+	ids := make([]interface{}, len(profileIDs))
+	for i, id := range profileIDs {
+		ids[i] = id
+	}
+	// select=* para incluir columnas opcionales como 'stock' si existen en la tabla
+	queryParams, err := apiclient.NewQuery().
+		Select("*").
+		In("profile_id", ids...).
+		Eq("color_id", colorID).
+		Order("stock_item_id", true).
+		Build()
+	if err != nil {
+		return nil, fmt.Errorf("error construyendo consulta de stock_items: %w", err)
+	}
+	if _, err := r.supabaseClient.QueryAll(ctx, stockItemsPath, queryParams, apiclient.PageOptions{ExactCount: true}, &items); err != nil {
+		r.logger.WithError(err).WithField("color_id", colorID).Error("Error obteniendo stock_items de Supabase")
+		return nil, fmt.Errorf("error obteniendo stock_items del color %d de Supabase: %w", colorID, err)
+	}
+	return items, nil
+}
+
+// GetAllStockItems devuelve todos los ítems de stock, verificando con el conteo exacto que no falte ninguno.
+func (r *supabaseStockItemRepository) GetAllStockItems(ctx context.Context) ([]models.StockItem, error) {
+	log := r.logger.WithField("method", "GetAllStockItems")
+	if cached, found := r.cache.Get(allStockItemsCacheKey); found {
+		if items, ok := cached.([]models.StockItem); ok {
+			log.Info("Cache HIT")
+			return items, nil
+		}
+	}
+
+	items := []models.StockItem{}
+	queryParams, err := apiclient.NewQuery().Select("*").Order("stock_item_id", true).Build()
+	if err != nil {
+		return nil, fmt.Errorf("error construyendo consulta de stock_items: %w", err)
+	}
+	if _, err := r.supabaseClient.QueryAll(ctx, stockItemsPath, queryParams, apiclient.PageOptions{ExactCount: true}, &items); err != nil {
+		log.WithError(err).Error("Error obteniendo stock_items de Supabase")
+		return nil, fmt.Errorf("error obteniendo stock_items de Supabase: %w", err)
+	}
+
+	r.cache.Set(allStockItemsCacheKey, items, cache.DefaultExpiration)
+	log.Infof("stock_items obtenidos de Supabase y guardados en caché. Total: %d", len(items))
+	return items, nil
+}
@@ -0,0 +1,54 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mvialf/windraw/internal/app/window-api/models"
+	"github.com/mvialf/windraw/internal/app/window-api/repositories"
+	"github.com/sirupsen/logrus"
+)
+
+// BOMService arma la lista de materiales de perfiles de un proyecto.
+type BOMService struct {
+	profileRepo repositories.ProfileCatalogRepository
+	optimizer   *OptimizerService
+	logger      *logrus.Entry
+}
+
+// NewBOMService crea un BOMService.
+func NewBOMService(profileRepo repositories.ProfileCatalogRepository, optimizer *OptimizerService, logger *logrus.Logger) *BOMService {
+	return &BOMService{
+		profileRepo: profileRepo,
+		optimizer:   optimizer,
+		logger:      logger.WithField("service", "bom"),
+	}
+}
+
+// Generate suma las piezas del proyecto por material. Las barras a comprar salen del plan de
+// corte calculado con opts (con sus retazos, si se indican).
+func (s *BOMService) Generate(ctx context.Context, project *models.Project, opts OptimizeOptions) (*models.BillOfMaterials, error) {
+	profiles := make(map[string]models.Profile)
+	for _, piece := range project.Pieces() {
+		if _, ok := profiles[piece.ProfileSKU]; ok {
+			continue
+		}
+		profile, err := s.profileRepo.GetProfileBySKU(ctx, piece.ProfileSKU)
+		if err != nil {
+			return nil, err
+		}
+		if profile == nil {
+			return nil, fmt.Errorf("%w: '%s'", ErrProfileNotFound, piece.ProfileSKU)
+		}
+		profiles[piece.ProfileSKU] = *profile
+	}
+
+	plan, err := s.optimizer.Optimize(ctx, project, opts)
+	if err != nil {
+		return nil, err
+	}
+	bom := project.BillOfMaterials(profiles, plan)
+	s.logger.WithField("project_id", project.ID).
+		Infof("Lista de materiales: %d materiales, %d barras, %.2f kg", len(bom.Lines), bom.TotalBars, bom.TotalWeightKg)
+	return bom, nil
+}
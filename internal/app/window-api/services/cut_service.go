@@ -0,0 +1,103 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/mvialf/windraw/internal/app/window-api/models"
+	"github.com/mvialf/windraw/internal/app/window-api/repositories"
+	"github.com/sirupsen/logrus"
+)
+
+// ErrProfileNotFound se devuelve cuando un SKU asignado a una pieza no existe en el catálogo.
+var ErrProfileNotFound = errors.New("services: perfil no encontrado en el catálogo")
+
+// CutService calcula el despiece (largos y ángulos de corte) de los elementos y decide sus refuerzos.
+type CutService struct {
+	profileRepo   repositories.ProfileCatalogRepository
+	reinforcement *ReinforcementService
+	weldMarginMM  float64
+	logger        *logrus.Entry
+}
+
+// NewCutService crea un CutService. weldMarginMM es el margen de soldadura por extremo
+// para perfiles con weld_margin (ej. constants.WELD_MARGIN_MM).
+func NewCutService(profileRepo repositories.ProfileCatalogRepository, reinforcement *ReinforcementService,
+	weldMarginMM float64, logger *logrus.Logger) *CutService {
+	return &CutService{
+		profileRepo:   profileRepo,
+		reinforcement: reinforcement,
+		weldMarginMM:  weldMarginMM,
+		logger:        logger.WithField("service", "cut"),
+	}
+}
+
+// CalculateElement calcula las piezas del marco, de los montantes y de todas las hojas del
+// elemento con la geometría de cada perfil del catálogo y, después, aplica los refuerzos.
+func (s *CutService) CalculateElement(ctx context.Context, element *models.Element) error {
+	profiles, err := loadElementProfiles(ctx, s.profileRepo, element)
+	if err != nil {
+		return err
+	}
+	if err := element.Frame.CalculateFrameDetails(profiles, s.weldMarginMM); err != nil {
+		return err
+	}
+	if err := element.CalculateMullionDetails(profiles); err != nil {
+		return err
+	}
+	for i := range element.Winds {
+		if err := element.Winds[i].CalculateWindDetails(profiles, s.weldMarginMM); err != nil {
+			return err
+		}
+	}
+	if s.reinforcement != nil {
+		if err := s.reinforcement.Apply(ctx, element); err != nil {
+			return err
+		}
+	}
+	s.logger.WithField("element_id", element.ID).Debugf("Despiece calculado con %d perfiles", len(profiles))
+	return nil
+}
+
+// loadElementProfiles obtiene del catálogo los perfiles de todos los SKU usados por el elemento
+// (marco, hojas y montantes), indexados por SKU.
+func loadElementProfiles(ctx context.Context, repo repositories.ProfileCatalogRepository, element *models.Element) (map[string]models.Profile, error) {
+	profiles := make(map[string]models.Profile)
+	load := func(sku string) error {
+		if sku == "" {
+			return nil
+		}
+		if _, ok := profiles[sku]; ok {
+			return nil
+		}
+		profile, err := repo.GetProfileBySKU(ctx, sku)
+		if err != nil {
+			return err
+		}
+		if profile == nil {
+			return fmt.Errorf("%w: '%s'", ErrProfileNotFound, sku)
+		}
+		profiles[sku] = *profile
+		return nil
+	}
+
+	for _, detail := range element.Frame.Details {
+		if err := load(detail.ProfileSKU); err != nil {
+			return nil, err
+		}
+	}
+	for _, wind := range element.Winds {
+		for _, detail := range wind.Details {
+			if err := load(detail.ProfileSKU); err != nil {
+				return nil, err
+			}
+		}
+	}
+	for _, detail := range element.Layout.MullionDetails() {
+		if err := load(detail.ProfileSKU); err != nil {
+			return nil, err
+		}
+	}
+	return profiles, nil
+}
@@ -0,0 +1,121 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/mvialf/windraw/internal/app/window-api/models"
+	"github.com/mvialf/windraw/internal/app/window-api/reports"
+	"github.com/mvialf/windraw/internal/app/window-api/repositories"
+	"github.com/sirupsen/logrus"
+)
+
+// ErrInvalidDrawing se devuelve cuando la geometría del elemento no permite dibujarlo.
+var ErrInvalidDrawing = errors.New("services: el elemento no se puede dibujar")
+
+// DrawingService dibuja las elevaciones de los elementos con el ancho visto de sus perfiles.
+type DrawingService struct {
+	profileRepo repositories.ProfileCatalogRepository
+	logger      *logrus.Entry
+}
+
+// NewDrawingService crea un DrawingService.
+func NewDrawingService(profileRepo repositories.ProfileCatalogRepository, logger *logrus.Logger) *DrawingService {
+	return &DrawingService{
+		profileRepo: profileRepo,
+		logger:      logger.WithField("service", "drawing"),
+	}
+}
+
+// ElementSVG dibuja la elevación del elemento en SVG a escala 1:scale (0 usa la escala por defecto).
+// Los perfiles que no están en el catálogo se dibujan con un ancho visto por defecto.
+func (s *DrawingService) ElementSVG(ctx context.Context, element *models.Element, title string, scale int) ([]byte, error) {
+	return s.draw(ctx, []*models.Element{element}, scale, title, func(buf *bytes.Buffer, opts reports.ElevationOptions) error {
+		return reports.WriteElementSVG(buf, element, opts)
+	})
+}
+
+// ElementDXF dibuja la elevación del elemento en DXF, en mm y por capas. scale solo fija el
+// tamaño de textos y cotas.
+func (s *DrawingService) ElementDXF(ctx context.Context, element *models.Element, title string, scale int) ([]byte, error) {
+	return s.draw(ctx, []*models.Element{element}, scale, title, func(buf *bytes.Buffer, opts reports.ElevationOptions) error {
+		return reports.WriteElementDXF(buf, element, opts)
+	})
+}
+
+// ComponentDXF dibuja en un DXF todos los elementos del componente lado a lado. ref es la
+// referencia del componente en el proyecto ("C1", "C2"...) con la que se rotula cada elemento.
+func (s *DrawingService) ComponentDXF(ctx context.Context, component *models.Component, ref, title string, scale int) ([]byte, error) {
+	var elements []*models.Element
+	for m := range component.Modules {
+		for e := range component.Modules[m].Elements {
+			elements = append(elements, &component.Modules[m].Elements[e])
+		}
+	}
+	data, err := s.draw(ctx, elements, scale, title, func(buf *bytes.Buffer, opts reports.ElevationOptions) error {
+		return reports.WriteComponentDXF(buf, component, ref, opts)
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.logger.WithField("component_id", component.ID).Infof("DXF del componente generado con %d elementos", len(elements))
+	return data, nil
+}
+
+// draw busca el ancho visto de los perfiles de los elementos y ejecuta el dibujo con ellos.
+func (s *DrawingService) draw(ctx context.Context, elements []*models.Element, scale int, title string,
+	write func(buf *bytes.Buffer, opts reports.ElevationOptions) error) ([]byte, error) {
+	widths := make(map[string]float64)
+	for _, element := range elements {
+		if err := s.profileWidths(ctx, element, widths); err != nil {
+			return nil, err
+		}
+	}
+	var buf bytes.Buffer
+	if err := write(&buf, reports.ElevationOptions{Scale: scale, ProfileWidths: widths, Title: title}); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidDrawing, err)
+	}
+	return buf.Bytes(), nil
+}
+
+// AttachToQuote agrega a cada elemento del presupuesto el SVG de su elevación.
+func (s *DrawingService) AttachToQuote(ctx context.Context, project *models.Project, quote *models.ProjectQuote) error {
+	for c := range quote.Components {
+		for m := range quote.Components[c].Modules {
+			for e := range quote.Components[c].Modules[m].Elements {
+				elementQuote := &quote.Components[c].Modules[m].Elements[e]
+				element := &project.Components[c].Modules[m].Elements[e]
+				svg, err := s.ElementSVG(ctx, element, elementQuote.Ref, 0)
+				if err != nil {
+					return fmt.Errorf("elemento %s: %w", elementQuote.Ref, err)
+				}
+				elementQuote.Drawing = string(svg)
+			}
+		}
+	}
+	s.logger.WithField("project_id", project.ID).Info("Dibujos agregados al presupuesto")
+	return nil
+}
+
+// profileWidths agrega a widths el ancho visto W de los perfiles de marco, montantes y hojas del
+// elemento que aún no estén, buscándolos en el catálogo.
+func (s *DrawingService) profileWidths(ctx context.Context, element *models.Element, widths map[string]float64) error {
+	for _, piece := range element.Pieces("") {
+		if _, ok := widths[piece.ProfileSKU]; ok || piece.Reinforcement {
+			continue
+		}
+		profile, err := s.profileRepo.GetProfileBySKU(ctx, piece.ProfileSKU)
+		if err != nil {
+			return err
+		}
+		if profile == nil {
+			s.logger.Warnf("Perfil '%s' no encontrado en el catálogo; se dibuja con el ancho por defecto", piece.ProfileSKU)
+			widths[piece.ProfileSKU] = 0
+			continue
+		}
+		widths[piece.ProfileSKU] = profile.W
+	}
+	return nil
+}
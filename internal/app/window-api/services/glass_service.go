@@ -0,0 +1,50 @@
+package services
+
+import (
+	"context"
+
+	"github.com/mvialf/windraw/internal/app/window-api/models"
+	"github.com/mvialf/windraw/internal/app/window-api/repositories"
+	"github.com/mvialf/windraw/internal/pkg/constants"
+	"github.com/sirupsen/logrus"
+)
+
+// GlassService calcula los paños de vidrio y los junquillos de los elementos.
+type GlassService struct {
+	resolver    *ProfileSystemResolver
+	profileRepo repositories.ProfileCatalogRepository
+	logger      *logrus.Entry
+}
+
+// NewGlassService crea un GlassService.
+func NewGlassService(resolver *ProfileSystemResolver, profileRepo repositories.ProfileCatalogRepository, logger *logrus.Logger) *GlassService {
+	return &GlassService{
+		resolver:    resolver,
+		profileRepo: profileRepo,
+		logger:      logger.WithField("service", "glass"),
+	}
+}
+
+// CalculateElement reemplaza los paños del elemento con el vidrio spec por defecto, usando el
+// margen de vidrio y el junquillo del sistema resuelto para su tipo y material.
+// Las hojas y la división en campos deben estar ya generadas.
+func (s *GlassService) CalculateElement(ctx context.Context, element *models.Element, spec models.GlassSpec) error {
+	resolved, err := s.resolver.Resolve(ctx, element.Type, element.Material)
+	if err != nil {
+		return err
+	}
+	bySKU, err := loadElementProfiles(ctx, s.profileRepo, element)
+	if err != nil {
+		return err
+	}
+	profiles := models.GlassProfiles{System: resolved.System, BySKU: bySKU}
+	if bead, ok := resolved.Profiles[constants.PROFILE_TYPE_GLASSBEAD]; ok {
+		profiles.GlassBead = &bead
+	}
+
+	if err := element.CalculateGlass(spec, profiles); err != nil {
+		return err
+	}
+	s.logger.WithField("element_id", element.ID).Infof("Calculados %d paños de vidrio", len(element.Glasses))
+	return nil
+}
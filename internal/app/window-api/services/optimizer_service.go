@@ -0,0 +1,125 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/mvialf/windraw/internal/app/window-api/models"
+	"github.com/mvialf/windraw/internal/app/window-api/repositories"
+	"github.com/sirupsen/logrus"
+)
+
+// ErrInvalidOptimization se devuelve cuando el plan de corte no se puede calcular con los parámetros dados.
+var ErrInvalidOptimization = errors.New("services: parámetros de optimización inválidos")
+
+// OptimizeOptions son los parámetros de una optimización. Los campos vacíos toman el valor por defecto.
+type OptimizeOptions struct {
+	Mode       string          // constants.OPTIMIZER_MODE_*
+	KerfMM     *float64        // Ancho del disco de sierra
+	TrimMM     *float64        // Recorte por extremo de barra
+	TimeBudget time.Duration   // Tiempo de búsqueda
+	Offcuts    []models.Offcut // Retazos disponibles
+}
+
+// OptimizerService arma el plan de corte en barras de las piezas de un proyecto.
+type OptimizerService struct {
+	stockRepo          repositories.StockItemRepository
+	profileRepo        repositories.ProfileCatalogRepository
+	defaults           models.OptimizerSettings
+	defaultBarLengthMM int
+	logger             *logrus.Entry
+}
+
+// NewOptimizerService crea un OptimizerService. defaults se usa para los parámetros no indicados
+// y defaultBarLengthMM para los materiales cuyo ítem de stock no informa profile_length.
+func NewOptimizerService(stockRepo repositories.StockItemRepository, profileRepo repositories.ProfileCatalogRepository,
+	defaults models.OptimizerSettings, defaultBarLengthMM int, logger *logrus.Logger) *OptimizerService {
+	return &OptimizerService{
+		stockRepo:          stockRepo,
+		profileRepo:        profileRepo,
+		defaults:           defaults,
+		defaultBarLengthMM: defaultBarLengthMM,
+		logger:             logger.WithField("service", "optimizer"),
+	}
+}
+
+// Optimize calcula el plan de corte de todas las piezas calculadas del proyecto.
+func (s *OptimizerService) Optimize(ctx context.Context, project *models.Project, opts OptimizeOptions) (*models.CuttingPlan, error) {
+	settings := s.defaults
+	if opts.Mode != "" {
+		settings.Mode = opts.Mode
+	}
+	if opts.KerfMM != nil {
+		settings.KerfMM = *opts.KerfMM
+	}
+	if opts.TrimMM != nil {
+		settings.TrimMM = *opts.TrimMM
+	}
+	if opts.TimeBudget > 0 {
+		settings.TimeBudget = opts.TimeBudget
+	}
+	if err := settings.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidOptimization, err)
+	}
+
+	pieces := project.Pieces()
+	items, err := stockItemsForPieces(ctx, s.stockRepo, pieces)
+	if err != nil {
+		return nil, err
+	}
+	barLengths := s.barLengths(pieces, items)
+	widths, err := s.angledProfileWidths(ctx, pieces)
+	if err != nil {
+		return nil, err
+	}
+
+	plan, err := models.OptimizeCuts(pieces, barLengths, widths, opts.Offcuts, settings)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidOptimization, err)
+	}
+	s.logger.WithField("project_id", project.ID).
+		Infof("Plan de corte (%s): %d piezas, %d barras nuevas, %d retazos, %.2f%% de aprovechamiento",
+			settings.Mode, len(pieces), plan.NewBars, plan.OffcutsUsed, plan.Yield)
+	return plan, nil
+}
+
+// barLengths devuelve el largo de barra (profile_length) de cada material según su ítem de stock.
+// Los materiales sin ítem de stock o sin largo informado usan el largo por defecto.
+func (s *OptimizerService) barLengths(pieces []models.Piece, items map[[2]int64]models.StockItem) map[models.StockKey]int {
+	lengths := make(map[models.StockKey]int)
+	for _, piece := range pieces {
+		key := piece.StockKey()
+		if _, ok := lengths[key]; ok {
+			continue
+		}
+		item, ok := items[[2]int64{piece.ProfileID, piece.ColorID}]
+		if !ok || item.LengthMM <= 0 {
+			s.logger.Debugf("Material %s sin profile_length, se usa la barra por defecto de %d mm", key, s.defaultBarLengthMM)
+			lengths[key] = s.defaultBarLengthMM
+			continue
+		}
+		lengths[key] = int(item.LengthMM)
+	}
+	return lengths
+}
+
+// angledProfileWidths obtiene el ancho W de los perfiles con piezas cortadas en ángulo.
+func (s *OptimizerService) angledProfileWidths(ctx context.Context, pieces []models.Piece) (map[string]float64, error) {
+	widths := make(map[string]float64)
+	for _, piece := range pieces {
+		if _, ok := widths[piece.ProfileSKU]; ok || (piece.AngleLeft == 90 && piece.AngleRight == 90) {
+			continue
+		}
+		profile, err := s.profileRepo.GetProfileBySKU(ctx, piece.ProfileSKU)
+		if err != nil {
+			return nil, err
+		}
+		if profile == nil {
+			return nil, fmt.Errorf("%w: '%s'", ErrProfileNotFound, piece.ProfileSKU)
+		}
+		widths[piece.ProfileSKU] = profile.W
+	}
+	return widths, nil
+}
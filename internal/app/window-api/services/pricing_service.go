@@ -0,0 +1,114 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/mvialf/windraw/internal/app/window-api/models"
+	"github.com/mvialf/windraw/internal/app/window-api/repositories"
+	"github.com/mvialf/windraw/internal/pkg/constants"
+	"github.com/sirupsen/logrus"
+)
+
+// ErrInvalidPricing se devuelve cuando el presupuesto no se puede calcular con los precios dados.
+var ErrInvalidPricing = errors.New("services: parámetros de presupuesto inválidos")
+
+// QuoteOptions son los precios de un presupuesto. Los campos vacíos toman el valor por defecto y
+// los mapas se combinan con los por defecto, clave por clave.
+type QuoteOptions struct {
+	ProfilePricing   string             // constants.PROFILE_PRICING_*
+	GlassPerM2       map[string]float64 // Precio por m² según tipo de vidrio
+	HardwarePerWind  map[string]float64 // Precio del herraje según tipo de hoja
+	LabourPerElement *float64
+	LabourPerM2      *float64
+	Optimize         OptimizeOptions // Plan de corte con constants.PROFILE_PRICING_BARS
+}
+
+// PricingService calcula el presupuesto de un proyecto con los precios de stock_items.
+type PricingService struct {
+	stockRepo repositories.StockItemRepository
+	optimizer *OptimizerService
+	defaults  models.PriceList
+	logger    *logrus.Entry
+}
+
+// NewPricingService crea un PricingService. defaults se usa para los precios no indicados.
+func NewPricingService(stockRepo repositories.StockItemRepository, optimizer *OptimizerService,
+	defaults models.PriceList, logger *logrus.Logger) *PricingService {
+	return &PricingService{
+		stockRepo: stockRepo,
+		optimizer: optimizer,
+		defaults:  defaults,
+		logger:    logger.WithField("service", "pricing"),
+	}
+}
+
+// Quote calcula el presupuesto de todas las piezas, vidrios y hojas calculados del proyecto.
+// Con constants.PROFILE_PRICING_BARS se arma antes el plan de corte con opts.Optimize.
+func (s *PricingService) Quote(ctx context.Context, project *models.Project, opts QuoteOptions) (*models.ProjectQuote, error) {
+	prices := s.priceList(opts)
+	if err := prices.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPricing, err)
+	}
+
+	pieces := project.Pieces()
+	items, err := stockItemsForPieces(ctx, s.stockRepo, pieces)
+	if err != nil {
+		return nil, err
+	}
+	barLengths := s.optimizer.barLengths(pieces, items)
+	profilePrices := make(map[models.StockKey]models.ProfilePrice)
+	for _, piece := range pieces {
+		item, ok := items[[2]int64{piece.ProfileID, piece.ColorID}]
+		if _, done := profilePrices[piece.StockKey()]; done || !ok {
+			continue
+		}
+		profilePrices[piece.StockKey()] = models.ProfilePrice{BarPrice: item.Price, BarLengthMM: barLengths[piece.StockKey()]}
+	}
+
+	var plan *models.CuttingPlan
+	if prices.ProfilePricing == constants.PROFILE_PRICING_BARS {
+		if plan, err = s.optimizer.Optimize(ctx, project, opts.Optimize); err != nil {
+			return nil, err
+		}
+	}
+
+	quote, err := project.Quote(prices, profilePrices, plan)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPricing, err)
+	}
+	s.logger.WithField("project_id", project.ID).
+		Infof("Presupuesto (%s): neto %.2f, IVA %.2f, total %.2f, %d avisos",
+			prices.ProfilePricing, quote.NetTotal, quote.Iva, quote.Total, len(quote.Warnings))
+	return quote, nil
+}
+
+// priceList combina los precios por defecto con los de opts.
+func (s *PricingService) priceList(opts QuoteOptions) models.PriceList {
+	prices := s.defaults
+	if opts.ProfilePricing != "" {
+		prices.ProfilePricing = opts.ProfilePricing
+	}
+	prices.GlassPerM2 = mergePrices(s.defaults.GlassPerM2, opts.GlassPerM2)
+	prices.HardwarePerWind = mergePrices(s.defaults.HardwarePerWind, opts.HardwarePerWind)
+	if opts.LabourPerElement != nil {
+		prices.LabourPerElement = *opts.LabourPerElement
+	}
+	if opts.LabourPerM2 != nil {
+		prices.LabourPerM2 = *opts.LabourPerM2
+	}
+	return prices
+}
+
+// mergePrices devuelve una copia de defaults con los precios de overrides reemplazados o agregados.
+func mergePrices(defaults, overrides map[string]float64) map[string]float64 {
+	merged := make(map[string]float64, len(defaults)+len(overrides))
+	for key, price := range defaults {
+		merged[key] = price
+	}
+	for key, price := range overrides {
+		merged[key] = price
+	}
+	return merged
+}
@@ -0,0 +1,161 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/mvialf/windraw/internal/app/window-api/models"
+	"github.com/mvialf/windraw/internal/app/window-api/repositories"
+	"github.com/mvialf/windraw/internal/pkg/constants"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	// ErrMaterialNotFound se devuelve cuando el material pedido no existe en la tabla materials.
+	ErrMaterialNotFound = errors.New("services: material no encontrado")
+	// ErrNoProfileSystem se devuelve cuando no hay sistemas para el tipo de elemento y material.
+	ErrNoProfileSystem = errors.New("services: no hay sistema de perfiles para el tipo y material indicados")
+)
+
+// knownProfileTypes son los tipos de perfil que entiende la aplicación (constants.PROFILE_TYPE_*).
+var knownProfileTypes = []string{
+	constants.PROFILE_TYPE_SLIDING_FRAME,
+	constants.PROFILE_TYPE_SLIDING_WIND,
+	constants.PROFILE_TYPE_SLIDING_OVERLAP,
+	constants.PROFILE_TYPE_SLIDING_ADAPTER,
+	constants.PROFILE_TYPE_SLIDING_RAIL,
+	constants.PROFILE_TYPE_CASEMENT_FRAME,
+	constants.PROFILE_TYPE_CASEMENT_WIND_IN,
+	constants.PROFILE_TYPE_CASEMENT_WIND_OUT,
+	constants.PROFILE_TYPE_CASEMENT_ADAPTER,
+	constants.PROFILE_TYPE_MULLION,
+	constants.PROFILE_TYPE_GLASSBEAD,
+	constants.PROFILE_TYPE_JOINT,
+	constants.PROFILE_TYPE_AUXILIAR,
+	constants.PROFILE_TYPE_REINFORCEMENT,
+}
+
+// ResolvedSystem es el resultado de elegir un sistema de perfiles para un elemento:
+// el sistema y, por cada tipo de perfil (constants.PROFILE_TYPE_*), el perfil que se usará.
+type ResolvedSystem struct {
+	System   models.ProfileSystem      `json:"system"`
+	Profiles map[string]models.Profile `json:"profiles"`
+}
+
+// ProfileSystemResolver aplica el flujo de selección de README2:
+// sistema por tipo ordenado por prymacy y, dentro del sistema, un perfil por tipo según primacy.
+type ProfileSystemResolver struct {
+	repo   repositories.ProfileSystemRepository
+	logger *logrus.Entry
+}
+
+// NewProfileSystemResolver crea un ProfileSystemResolver.
+func NewProfileSystemResolver(repo repositories.ProfileSystemRepository, logger *logrus.Logger) *ProfileSystemResolver {
+	return &ProfileSystemResolver{
+		repo:   repo,
+		logger: logger.WithField("service", "profile_system_resolver"),
+	}
+}
+
+// Resolve elige el sistema para un tipo de elemento (constants.TYPE_*) y material (constants.MATERIAL_*)
+// y devuelve el perfil resuelto para cada tipo de perfil del sistema.
+func (s *ProfileSystemResolver) Resolve(ctx context.Context, elementType, material string) (*ResolvedSystem, error) {
+	log := s.logger.WithFields(logrus.Fields{"element_type": elementType, "material": material})
+
+	mat, err := s.repo.GetMaterialByName(ctx, material)
+	if err != nil {
+		return nil, err
+	}
+	if mat == nil {
+		return nil, fmt.Errorf("%w: '%s'", ErrMaterialNotFound, material)
+	}
+
+	systems, err := s.repo.GetSystemsByType(ctx, elementType, mat.ID)
+	if err != nil {
+		return nil, err
+	}
+	system := SelectSystem(systems)
+	if system == nil {
+		return nil, fmt.Errorf("%w (tipo '%s', material '%s')", ErrNoProfileSystem, elementType, material)
+	}
+
+	list, err := s.repo.GetSystemProfiles(ctx, system.ID)
+	if err != nil {
+		return nil, err
+	}
+	profiles := SelectProfilesByType(list)
+	for _, entry := range list {
+		if entry.Profile != nil && !models.IsValidOption(entry.Profile.Type, knownProfileTypes) {
+			log.Warnf("Perfil %s con tipo desconocido '%s' ignorado", entry.Profile.SKU, entry.Profile.Type)
+		}
+	}
+
+	log.WithField("system_id", system.ID).Infof("Sistema '%s' resuelto con %d tipos de perfil", system.Name, len(profiles))
+	return &ResolvedSystem{System: *system, Profiles: profiles}, nil
+}
+
+// SelectSystem devuelve el sistema de mayor importancia: menor prymacy (1 es la más alta),
+// los sistemas sin prymacy al final y, a igualdad, el menor system_id. Devuelve nil si la lista está vacía.
+func SelectSystem(systems []models.ProfileSystem) *models.ProfileSystem {
+	if len(systems) == 0 {
+		return nil
+	}
+	sorted := append([]models.ProfileSystem(nil), systems...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if c := comparePrimacy(sorted[i].Primacy, sorted[j].Primacy); c != 0 {
+			return c < 0
+		}
+		return sorted[i].ID < sorted[j].ID
+	})
+	return &sorted[0]
+}
+
+// SelectProfilesByType agrupa los perfiles de un sistema por profile_type y, cuando varios comparten tipo,
+// conserva el de menor primacy (1 es la más alta), dejando los NULL al final y desempatando por menor profile_id.
+// Solo se incluyen los tipos conocidos (constants.PROFILE_TYPE_*).
+func SelectProfilesByType(list []models.SystemProfile) map[string]models.Profile {
+	best := make(map[string]models.SystemProfile)
+	for _, entry := range list {
+		if entry.Profile == nil || !models.IsValidOption(entry.Profile.Type, knownProfileTypes) {
+			continue
+		}
+		current, ok := best[entry.Profile.Type]
+		if !ok || isBetterSystemProfile(entry, current) {
+			best[entry.Profile.Type] = entry
+		}
+	}
+
+	profiles := make(map[string]models.Profile, len(best))
+	for profileType, entry := range best {
+		profiles[profileType] = *entry.Profile
+	}
+	return profiles
+}
+
+func isBetterSystemProfile(candidate, current models.SystemProfile) bool {
+	if c := comparePrimacy(candidate.Primacy, current.Primacy); c != 0 {
+		return c < 0
+	}
+	return candidate.ProfileID < current.ProfileID
+}
+
+// comparePrimacy compara dos prioridades: -1 si a es más importante, 1 si lo es b, 0 si empatan.
+// Un número menor es más importante y nil es la menor importancia.
+func comparePrimacy(a, b *int64) int {
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return 1
+	case b == nil:
+		return -1
+	case *a < *b:
+		return -1
+	case *a > *b:
+		return 1
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,72 @@
+package services
+
+import (
+	"context"
+
+	"github.com/mvialf/windraw/internal/app/window-api/models"
+	"github.com/mvialf/windraw/internal/app/window-api/repositories"
+	"github.com/sirupsen/logrus"
+)
+
+// ReinforcementService decide el refuerzo de las piezas de un elemento con la política
+// configurada y la tabla profile_reinforcements.
+type ReinforcementService struct {
+	repo   repositories.ReinforcementRepository
+	policy models.ReinforcementPolicy
+	logger *logrus.Entry
+}
+
+// NewReinforcementService crea un ReinforcementService con la política indicada.
+func NewReinforcementService(repo repositories.ReinforcementRepository, policy models.ReinforcementPolicy, logger *logrus.Logger) *ReinforcementService {
+	return &ReinforcementService{
+		repo:   repo,
+		policy: policy,
+		logger: logger.WithField("service", "reinforcement"),
+	}
+}
+
+// Policy devuelve la política de refuerzo en uso.
+func (s *ReinforcementService) Policy() models.ReinforcementPolicy {
+	return s.policy
+}
+
+// Apply marca el refuerzo de todas las piezas del elemento. Las dimensiones deben estar ya calculadas.
+func (s *ReinforcementService) Apply(ctx context.Context, element *models.Element) error {
+	profileIDs := elementProfileIDs(element)
+	if len(profileIDs) == 0 {
+		return nil
+	}
+	reinforcements, err := s.repo.GetReinforcements(ctx, profileIDs)
+	if err != nil {
+		return err
+	}
+	if err := element.ApplyReinforcement(s.policy, reinforcements); err != nil {
+		return err
+	}
+	s.logger.WithField("element_id", element.ID).Debugf("Refuerzos aplicados con %d perfiles reforzables", len(reinforcements))
+	return nil
+}
+
+// elementProfileIDs devuelve los profile_id distintos usados por el marco, las hojas y los montantes del elemento.
+func elementProfileIDs(element *models.Element) []int64 {
+	seen := make(map[int64]bool)
+	var ids []int64
+	add := func(id int64) {
+		if id != 0 && !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	for _, detail := range element.Frame.Details {
+		add(detail.ProfileID)
+	}
+	for _, wind := range element.Winds {
+		for _, detail := range wind.Details {
+			add(detail.ProfileID)
+		}
+	}
+	for _, detail := range element.Layout.MullionDetails() {
+		add(detail.ProfileID)
+	}
+	return ids
+}
@@ -0,0 +1,188 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/mvialf/windraw/internal/app/window-api/models"
+	"github.com/mvialf/windraw/internal/app/window-api/repositories"
+	"github.com/mvialf/windraw/internal/pkg/constants"
+	"github.com/sirupsen/logrus"
+)
+
+// ErrInvalidRemnant se devuelve cuando un retazo o un corte registrado contra el inventario no es válido.
+var ErrInvalidRemnant = errors.New("services: retazo inválido")
+
+// RemnantMovements resume los cambios en el inventario al registrar un plan de corte.
+type RemnantMovements struct {
+	Consumed  []models.Remnant `json:"consumed"`  // Retazos cortados y retirados del inventario
+	Created   []models.Remnant `json:"created"`   // Sobrantes guardados como retazos nuevos
+	Discarded []models.Offcut  `json:"discarded"` // Sobrantes bajo el largo mínimo, que se descartan
+}
+
+// RemnantService administra el inventario de retazos: los ofrece al optimizador antes que las
+// barras nuevas y lo actualiza con los cortes realizados.
+type RemnantService struct {
+	repo        repositories.RemnantRepository
+	minLengthMM int
+	logger      *logrus.Entry
+}
+
+// NewRemnantService crea un RemnantService. Los sobrantes de menos de minLengthMM no se guardan.
+func NewRemnantService(repo repositories.RemnantRepository, minLengthMM int, logger *logrus.Logger) *RemnantService {
+	return &RemnantService{
+		repo:        repo,
+		minLengthMM: minLengthMM,
+		logger:      logger.WithField("service", "remnant"),
+	}
+}
+
+// List devuelve los retazos del inventario que cumplen el filtro.
+func (s *RemnantService) List(ctx context.Context, filter models.RemnantFilter) ([]models.Remnant, error) {
+	return s.repo.List(ctx, filter)
+}
+
+// Add ingresa un retazo al inventario. Se rechaza si es más corto que el largo mínimo.
+func (s *RemnantService) Add(ctx context.Context, itemSKU, color string, lengthMM int, location string) (*models.Remnant, error) {
+	remnant, err := models.NewRemnant(itemSKU, color, lengthMM, location, "")
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidRemnant, err)
+	}
+	if lengthMM < s.minLengthMM {
+		return nil, fmt.Errorf("%w: %d mm es menos que el largo mínimo de %d mm", ErrInvalidRemnant, lengthMM, s.minLengthMM)
+	}
+	if err := s.repo.Create(ctx, remnant); err != nil {
+		return nil, err
+	}
+	return remnant, nil
+}
+
+// Remove retira un retazo del inventario (usado fuera de un plan de corte o dañado).
+func (s *RemnantService) Remove(ctx context.Context, id string) error {
+	return s.repo.Delete(ctx, id)
+}
+
+// OffcutsFor devuelve los retazos del inventario del mismo material (ítem de stock y color) que
+// las piezas, listos para pasarlos al optimizador.
+func (s *RemnantService) OffcutsFor(ctx context.Context, pieces []models.Piece) ([]models.Offcut, error) {
+	seen := make(map[models.StockKey]bool)
+	var offcuts []models.Offcut
+	for _, piece := range pieces {
+		key := piece.StockKey()
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		remnants, err := s.repo.List(ctx, models.RemnantFilter{ItemSKU: key.ItemSKU, Color: key.Color})
+		if err != nil {
+			return nil, err
+		}
+		for _, remnant := range remnants {
+			// Un filtro de color vacío no filtra: se descartan los retazos de color de otras piezas.
+			if remnant.StockKey() == key {
+				offcuts = append(offcuts, remnant.Offcut())
+			}
+		}
+	}
+	return offcuts, nil
+}
+
+// RecordCuts actualiza el inventario con un plan de corte ya ejecutado en el taller: retira los
+// retazos cortados y guarda como retazos nuevos los sobrantes de al menos el largo mínimo.
+// Los sobrantes nuevos se ubican en location o, si viene vacía y se cortaron de un retazo, en la
+// ubicación de ese retazo.
+//
+// Cada plan se registra una sola vez: su ID se marca en el repositorio y un segundo registro se
+// rechaza con repositories.ErrPlanAlreadyRecorded, aunque el plan solo use barras nuevas.
+// Antes de modificar nada se comprueba que todos los retazos del plan sigan en el inventario con
+// su material y largo. Después se crean los retazos nuevos y solo al final se retiran los usados;
+// si algún paso falla se deshace lo hecho, para no perder retazos ni duplicarlos al reintentar.
+func (s *RemnantService) RecordCuts(ctx context.Context, projectID string, plan *models.CuttingPlan, location string) (*RemnantMovements, error) {
+	if plan == nil {
+		return nil, fmt.Errorf("%w: el plan de corte está vacío", ErrInvalidRemnant)
+	}
+	if plan.ID == "" {
+		return nil, fmt.Errorf("%w: el plan de corte no tiene ID; se debe registrar el plan tal como lo devuelve el optimizador", ErrInvalidRemnant)
+	}
+	log := s.logger.WithFields(logrus.Fields{"project_id": projectID, "plan_id": plan.ID})
+
+	movements := &RemnantMovements{Consumed: []models.Remnant{}, Created: []models.Remnant{}, Discarded: []models.Offcut{}}
+	seen := make(map[string]bool)
+	for _, group := range plan.Groups {
+		for _, bar := range group.Bars {
+			barLocation := location
+			if bar.Source == constants.BAR_SOURCE_OFFCUT {
+				if seen[bar.OffcutID] {
+					return nil, fmt.Errorf("%w: el retazo '%s' aparece en más de una barra", ErrInvalidRemnant, bar.OffcutID)
+				}
+				seen[bar.OffcutID] = true
+				remnant, err := s.repo.Get(ctx, bar.OffcutID)
+				if err != nil {
+					return nil, fmt.Errorf("retazo '%s' del plan: %w", bar.OffcutID, err)
+				}
+				if remnant.StockKey() != group.StockKey || remnant.LengthMM != bar.LengthMM {
+					return nil, fmt.Errorf("%w: el retazo '%s' es %s de %d mm y el plan lo usa como %s de %d mm",
+						ErrInvalidRemnant, remnant.ID, remnant.StockKey(), remnant.LengthMM, group.StockKey, bar.LengthMM)
+				}
+				movements.Consumed = append(movements.Consumed, *remnant)
+				if barLocation == "" {
+					barLocation = remnant.Location
+				}
+			}
+			if bar.OffcutMM <= 0 {
+				continue
+			}
+			if bar.OffcutMM < s.minLengthMM {
+				movements.Discarded = append(movements.Discarded,
+					models.Offcut{ItemSKU: group.ItemSKU, Color: group.Color, LengthMM: bar.OffcutMM})
+				continue
+			}
+			remnant, err := models.NewRemnant(group.ItemSKU, group.Color, bar.OffcutMM, barLocation, projectID)
+			if err != nil {
+				return nil, fmt.Errorf("%w: %v", ErrInvalidRemnant, err)
+			}
+			movements.Created = append(movements.Created, *remnant)
+		}
+	}
+
+	if err := s.repo.ClaimPlan(ctx, plan.ID, projectID); err != nil {
+		return nil, fmt.Errorf("plan de corte '%s': %w", plan.ID, err)
+	}
+	for i := range movements.Created {
+		if err := s.repo.Create(ctx, &movements.Created[i]); err != nil {
+			s.undoCuts(ctx, log, plan.ID, movements.Created[:i], nil)
+			return nil, err
+		}
+	}
+	for i, remnant := range movements.Consumed {
+		if err := s.repo.Delete(ctx, remnant.ID); err != nil {
+			s.undoCuts(ctx, log, plan.ID, movements.Created, movements.Consumed[:i])
+			return nil, fmt.Errorf("retazo '%s' del plan: %w", remnant.ID, err)
+		}
+	}
+
+	log.Infof("Cortes registrados: %d retazos usados, %d retazos nuevos, %d sobrantes descartados (< %d mm)",
+		len(movements.Consumed), len(movements.Created), len(movements.Discarded), s.minLengthMM)
+	return movements, nil
+}
+
+// undoCuts deshace un registro de cortes que falló a medias: devuelve al inventario los retazos
+// ya retirados, elimina los creados y libera el plan para que se pueda volver a registrar.
+// Se ejecuta aunque ctx se haya cancelado; lo que no se pueda deshacer queda en el log.
+func (s *RemnantService) undoCuts(ctx context.Context, log *logrus.Entry, planID string, created, deleted []models.Remnant) {
+	ctx = context.WithoutCancel(ctx)
+	for i := range deleted {
+		if err := s.repo.Create(ctx, &deleted[i]); err != nil {
+			log.WithError(err).Errorf("No se pudo devolver al inventario el retazo '%s'", deleted[i].ID)
+		}
+	}
+	for _, remnant := range created {
+		if err := s.repo.Delete(ctx, remnant.ID); err != nil {
+			log.WithError(err).Errorf("No se pudo eliminar el retazo nuevo '%s'", remnant.ID)
+		}
+	}
+	if err := s.repo.ReleasePlan(ctx, planID); err != nil {
+		log.WithError(err).Error("No se pudo liberar el plan de corte")
+	}
+}
@@ -0,0 +1,87 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/mvialf/windraw/internal/app/window-api/models"
+	"github.com/mvialf/windraw/internal/app/window-api/reports"
+	"github.com/sirupsen/logrus"
+)
+
+// ErrSawDialectNotFound se devuelve cuando se pide un dialecto de sierra que no está configurado.
+var ErrSawDialectNotFound = errors.New("services: dialecto de sierra no encontrado")
+
+// SawExportService genera los archivos de trabajo de las sierras de doble cabezal.
+type SawExportService struct {
+	optimizer *OptimizerService
+	dialects  map[string]reports.SawDialect
+	names     []string // Orden de registro
+	logger    *logrus.Entry
+}
+
+// NewSawExportService crea un SawExportService con los dialectos indicados. Si dos dialectos
+// tienen el mismo nombre queda el último, de modo que un archivo de configuración puede
+// reemplazar a los dialectos incluidos.
+func NewSawExportService(optimizer *OptimizerService, dialects []reports.SawDialect, logger *logrus.Logger) *SawExportService {
+	s := &SawExportService{
+		optimizer: optimizer,
+		dialects:  make(map[string]reports.SawDialect),
+		logger:    logger.WithField("service", "saw_export"),
+	}
+	for _, dialect := range dialects {
+		if _, ok := s.dialects[dialect.Name]; !ok {
+			s.names = append(s.names, dialect.Name)
+		}
+		s.dialects[dialect.Name] = dialect
+	}
+	return s
+}
+
+// SawJob es un archivo de trabajo de sierra generado.
+type SawJob struct {
+	Data    []byte
+	Dialect reports.SawDialect
+	Bent    []models.Piece // Piezas curvas del plan, que no van en el archivo (ver reports.WriteSawJob)
+}
+
+// Dialects devuelve los dialectos configurados.
+func (s *SawExportService) Dialects() []reports.SawDialect {
+	dialects := make([]reports.SawDialect, 0, len(s.names))
+	for _, name := range s.names {
+		dialects = append(dialects, s.dialects[name])
+	}
+	return dialects
+}
+
+// Export genera el archivo de trabajo del proyecto en el dialecto indicado. Si plan es nil se
+// calcula con opts; conviene pasar el plan ya revisado para que la sierra corte exactamente
+// las barras que se registraron contra el inventario de retazos.
+// Las piezas curvas no van en el archivo y se devuelven en SawJob.Bent.
+func (s *SawExportService) Export(ctx context.Context, project *models.Project, dialectName string,
+	plan *models.CuttingPlan, opts OptimizeOptions) (*SawJob, error) {
+	dialect, ok := s.dialects[dialectName]
+	if !ok {
+		return nil, fmt.Errorf("%w: '%s'", ErrSawDialectNotFound, dialectName)
+	}
+	if plan == nil {
+		var err error
+		if plan, err = s.optimizer.Optimize(ctx, project, opts); err != nil {
+			return nil, err
+		}
+	}
+
+	var buf bytes.Buffer
+	bent, err := reports.WriteSawJob(&buf, plan, dialect)
+	if err != nil {
+		return nil, err
+	}
+	log := s.logger.WithFields(logrus.Fields{"project_id": project.ID, "dialect": dialect.Name})
+	if len(bent) > 0 {
+		log.Warnf("%d piezas curvas quedan fuera del archivo de sierra", len(bent))
+	}
+	log.Infof("Archivo de sierra generado: %d bytes", buf.Len())
+	return &SawJob{Data: buf.Bytes(), Dialect: dialect, Bent: bent}, nil
+}
@@ -0,0 +1,288 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/mvialf/windraw/internal/app/window-api/models"
+	"github.com/mvialf/windraw/internal/app/window-api/repositories"
+	"github.com/mvialf/windraw/internal/pkg/constants"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	// ErrColorNotAvailable se devuelve cuando el color no figura en system_available_colors del sistema.
+	ErrColorNotAvailable = errors.New("services: color no disponible para el sistema")
+	// ErrStockItemNotFound se devuelve cuando una combinación perfil + color no tiene fila en stock_items.
+	ErrStockItemNotFound = errors.New("services: combinación perfil + color sin ítem de stock")
+)
+
+// ResolvedStockItem es el ítem de stock elegido para un tipo de perfil en el color seleccionado.
+type ResolvedStockItem struct {
+	ProfileType string           `json:"profile_type"`
+	Profile     models.Profile   `json:"profile"`
+	StockItem   models.StockItem `json:"stock_item"`
+	ItemSKU     string           `json:"item_sku"`     // item_sku con el sufijo de color (color_code_suffix)
+	DisplayName string           `json:"display_name"` // profile_name + ' ' + nombre del color
+}
+
+// ResolvedMaterials reúne, para un sistema y un color, el ítem de stock de cada tipo de perfil.
+type ResolvedMaterials struct {
+	System models.ProfileSystem         `json:"system"`
+	Color  models.SystemColor           `json:"color"`
+	Items  map[string]ResolvedStockItem `json:"items"` // Por tipo de perfil (constants.PROFILE_TYPE_*)
+}
+
+// StockItemService resuelve colores y stock_items para un sistema de perfiles ya elegido.
+type StockItemService struct {
+	colorRepo repositories.ColorRepository
+	stockRepo repositories.StockItemRepository
+	logger    *logrus.Entry
+}
+
+// NewStockItemService crea un StockItemService.
+func NewStockItemService(colorRepo repositories.ColorRepository, stockRepo repositories.StockItemRepository, logger *logrus.Logger) *StockItemService {
+	return &StockItemService{
+		colorRepo: colorRepo,
+		stockRepo: stockRepo,
+		logger:    logger.WithField("service", "stock_item"),
+	}
+}
+
+// GetSystemColors devuelve los colores disponibles para un sistema.
+func (s *StockItemService) GetSystemColors(ctx context.Context, systemID int64) ([]models.SystemColor, error) {
+	return s.colorRepo.GetSystemColors(ctx, systemID)
+}
+
+// Resolve devuelve el ítem de stock de cada perfil del sistema en el color indicado.
+// Falla con ErrColorNotAvailable si el sistema no ofrece el color y con ErrStockItemNotFound
+// si algún perfil no tiene ítem en ese color (el error enumera todos los que faltan).
+func (s *StockItemService) Resolve(ctx context.Context, resolved *ResolvedSystem, colorID int64) (*ResolvedMaterials, error) {
+	if resolved == nil {
+		return nil, errors.New("services: sistema resuelto nulo")
+	}
+	log := s.logger.WithFields(logrus.Fields{"system_id": resolved.System.ID, "color_id": colorID})
+
+	colors, err := s.colorRepo.GetSystemColors(ctx, resolved.System.ID)
+	if err != nil {
+		return nil, err
+	}
+	var systemColor *models.SystemColor
+	for i := range colors {
+		if colors[i].ColorID == colorID {
+			systemColor = &colors[i]
+			break
+		}
+	}
+	if systemColor == nil {
+		return nil, fmt.Errorf("%w: color %d en sistema '%s'", ErrColorNotAvailable, colorID, resolved.System.Name)
+	}
+	colorName := ""
+	if systemColor.Color != nil {
+		colorName = systemColor.Color.Name
+	}
+
+	profileIDs := make([]int64, 0, len(resolved.Profiles))
+	for _, profile := range resolved.Profiles {
+		profileIDs = append(profileIDs, profile.ID)
+	}
+	stockItems, err := s.stockRepo.GetStockItems(ctx, profileIDs, colorID)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make(map[string]ResolvedStockItem, len(resolved.Profiles))
+	var missing []string
+	for profileType, profile := range resolved.Profiles {
+		item := selectStockItem(stockItems, profile.ID, resolved.System.SupplierID)
+		if item == nil {
+			missing = append(missing, fmt.Sprintf("%s (%s, '%s')", profile.SKU, profileType, colorName))
+			continue
+		}
+		items[profileType] = ResolvedStockItem{
+			ProfileType: profileType,
+			Profile:     profile,
+			StockItem:   *item,
+			ItemSKU:     ItemSKUWithSuffix(item.ItemSKU, systemColor.ColorCodeSuffix),
+			DisplayName: strings.TrimSpace(profile.Name + " " + colorName),
+		}
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return nil, fmt.Errorf("%w: %s", ErrStockItemNotFound, strings.Join(missing, ", "))
+	}
+
+	log.Infof("%d ítems de stock resueltos", len(items))
+	return &ResolvedMaterials{System: resolved.System, Color: *systemColor, Items: items}, nil
+}
+
+// AssignElement asigna el ítem de stock a cada pieza del marco, de las hojas, de los montantes y
+// de los junquillos del elemento. Las posiciones sin profile_id resuelto reciben el perfil del tipo
+// que les corresponde: el marco y la hoja del tipo del elemento (en las hojas de abatir, la interior
+// o la exterior según su sentido de apertura), y el montante y el junquillo del sistema.
+func (m *ResolvedMaterials) AssignElement(element *models.Element) error {
+	frameType, windType := constants.PROFILE_TYPE_CASEMENT_FRAME, constants.PROFILE_TYPE_CASEMENT_WIND_IN
+	if element.Type == constants.TYPE_SLIDING {
+		frameType, windType = constants.PROFILE_TYPE_SLIDING_FRAME, constants.PROFILE_TYPE_SLIDING_WIND
+	}
+	if err := m.AssignFrame(&element.Frame, frameType); err != nil {
+		return fmt.Errorf("marco: %w", err)
+	}
+	for i := range element.Winds {
+		wind := &element.Winds[i]
+		profileType := windType
+		if element.Type != constants.TYPE_SLIDING && wind.OpeningDirection == constants.OPENING_EXT {
+			profileType = constants.PROFILE_TYPE_CASEMENT_WIND_OUT
+		}
+		if err := m.AssignWind(wind, profileType); err != nil {
+			return fmt.Errorf("hoja '%s': %w", wind.Name, err)
+		}
+	}
+	for _, detail := range element.Layout.MullionDetails() {
+		item, err := m.itemFor(detail.ProfileID, constants.PROFILE_TYPE_MULLION)
+		if err != nil {
+			return fmt.Errorf("%s: %w", detail.Position, err)
+		}
+		if err := element.Layout.SetMullionStockItem(detail.Position, item.Profile, item.stockItemWithSKU(), m.colorName()); err != nil {
+			return err
+		}
+	}
+	for i := range element.Glasses {
+		pane := &element.Glasses[i]
+		for _, bead := range pane.Beads {
+			item, err := m.itemFor(bead.ProfileID, constants.PROFILE_TYPE_GLASSBEAD)
+			if err != nil {
+				return fmt.Errorf("junquillo '%s' de '%s': %w", bead.Position, pane.Label, err)
+			}
+			if err := pane.SetBeadStockItem(bead.Position, item.Profile, item.stockItemWithSKU(), m.colorName()); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// AssignFrame asigna a las posiciones del marco su ítem de stock: las que ya tienen un profile_id
+// resuelto conservan su perfil y el resto recibe el del tipo de perfil indicado.
+func (m *ResolvedMaterials) AssignFrame(frame *models.Frame, profileType string) error {
+	for position, detail := range frame.Details {
+		item, err := m.itemFor(detail.ProfileID, profileType)
+		if err != nil {
+			return err
+		}
+		if err := frame.SetFrameStockItem(position, item.Profile, item.stockItemWithSKU(), m.colorName()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AssignWind asigna a las posiciones de la hoja su ítem de stock, igual que AssignFrame
+// (ej. las piezas de traslapo conservan el perfil de traslapo).
+func (m *ResolvedMaterials) AssignWind(wind *models.Wind, profileType string) error {
+	for position, detail := range wind.Details {
+		item, err := m.itemFor(detail.ProfileID, profileType)
+		if err != nil {
+			return err
+		}
+		if err := wind.SetWindStockItem(position, item.Profile, item.stockItemWithSKU(), m.colorName()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// itemFor devuelve el ítem del perfil profileID si está resuelto y, si no, el del tipo de perfil indicado.
+func (m *ResolvedMaterials) itemFor(profileID int64, profileType string) (ResolvedStockItem, error) {
+	if profileID != 0 {
+		for _, item := range m.Items {
+			if item.Profile.ID == profileID {
+				return item, nil
+			}
+		}
+	}
+	item, ok := m.Items[profileType]
+	if !ok {
+		return ResolvedStockItem{}, fmt.Errorf("%w: tipo de perfil '%s' no resuelto", ErrStockItemNotFound, profileType)
+	}
+	return item, nil
+}
+
+func (m *ResolvedMaterials) colorName() string {
+	if m.Color.Color != nil {
+		return m.Color.Color.Name
+	}
+	return ""
+}
+
+// stockItemWithSKU devuelve el ítem de stock con el SKU completo (incluido el sufijo de color).
+func (i ResolvedStockItem) stockItemWithSKU() models.StockItem {
+	item := i.StockItem
+	item.ItemSKU = i.ItemSKU
+	return item
+}
+
+// ItemSKUWithSuffix agrega el sufijo de color al SKU del ítem, salvo que ya lo incluya.
+func ItemSKUWithSuffix(itemSKU, suffix string) string {
+	if suffix == "" || strings.HasSuffix(itemSKU, suffix) {
+		return itemSKU
+	}
+	return itemSKU + suffix
+}
+
+// selectStockItem elige el ítem de un perfil, prefiriendo el del proveedor del sistema
+// y, a igualdad, el de menor stock_item_id.
+func selectStockItem(items []models.StockItem, profileID, supplierID int64) *models.StockItem {
+	var best *models.StockItem
+	for i := range items {
+		item := &items[i]
+		if item.ProfileID != profileID {
+			continue
+		}
+		if best == nil {
+			best = item
+			continue
+		}
+		itemPreferred := item.SupplierID == supplierID
+		bestPreferred := best.SupplierID == supplierID
+		if (itemPreferred && !bestPreferred) || (itemPreferred == bestPreferred && item.ID < best.ID) {
+			best = item
+		}
+	}
+	return best
+}
+
+// stockItemsForPieces busca en stock_items el ítem de cada perfil y color de las piezas, indexado
+// por [profile_id, color_id]. Si hay varios se usa el de menor stock_item_id entre los que informan
+// profile_length. Las piezas sin profile_id (aún sin perfil asignado) se omiten.
+func stockItemsForPieces(ctx context.Context, repo repositories.StockItemRepository, pieces []models.Piece) (map[[2]int64]models.StockItem, error) {
+	profileIDsByColor := make(map[int64][]int64)
+	seen := make(map[[2]int64]bool)
+	for _, piece := range pieces {
+		key := [2]int64{piece.ProfileID, piece.ColorID}
+		if piece.ProfileID == 0 || seen[key] {
+			continue
+		}
+		seen[key] = true
+		profileIDsByColor[piece.ColorID] = append(profileIDsByColor[piece.ColorID], piece.ProfileID)
+	}
+
+	byItem := make(map[[2]int64]models.StockItem)
+	for colorID, profileIDs := range profileIDsByColor {
+		items, err := repo.GetStockItems(ctx, profileIDs, colorID)
+		if err != nil {
+			return nil, err
+		}
+		sort.Slice(items, func(i, j int) bool { return items[i].ID < items[j].ID })
+		for _, item := range items {
+			key := [2]int64{item.ProfileID, item.ColorID}
+			if current, ok := byItem[key]; !ok || (current.LengthMM <= 0 && item.LengthMM > 0) {
+				byItem[key] = item
+			}
+		}
+	}
+	return byItem, nil
+}
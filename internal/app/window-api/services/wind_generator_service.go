@@ -0,0 +1,122 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/mvialf/windraw/internal/app/window-api/models"
+	"github.com/mvialf/windraw/internal/pkg/constants"
+	"github.com/sirupsen/logrus"
+)
+
+// ErrInvalidWindLayout se devuelve cuando la disposición de hojas pedida no se puede construir.
+var ErrInvalidWindLayout = errors.New("services: disposición de hojas inválida")
+
+// WindGeneratorService deriva las hojas de un elemento a partir de su marco y del sistema de perfiles.
+type WindGeneratorService struct {
+	resolver *ProfileSystemResolver
+	logger   *logrus.Entry
+}
+
+// NewWindGeneratorService crea un WindGeneratorService.
+func NewWindGeneratorService(resolver *ProfileSystemResolver, logger *logrus.Logger) *WindGeneratorService {
+	return &WindGeneratorService{
+		resolver: resolver,
+		logger:   logger.WithField("service", "wind_generator"),
+	}
+}
+
+// GenerateSliding reemplaza las hojas de un elemento corredera según la disposición
+// (ej. constants.SLIDING_LAYOUT_2_PANELS), usando el sistema resuelto para su material.
+func (s *WindGeneratorService) GenerateSliding(ctx context.Context, element *models.Element, layoutName string) error {
+	layout, err := models.ParseSlidingLayout(layoutName)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidWindLayout, err)
+	}
+	resolved, err := s.resolver.Resolve(ctx, element.Type, element.Material)
+	if err != nil {
+		return err
+	}
+
+	frameProfile, ok := resolved.Profiles[constants.PROFILE_TYPE_SLIDING_FRAME]
+	if !ok {
+		return fmt.Errorf("%w: el sistema '%s' no tiene perfil '%s'", ErrNoProfileSystem, resolved.System.Name, constants.PROFILE_TYPE_SLIDING_FRAME)
+	}
+	windProfile, ok := resolved.Profiles[constants.PROFILE_TYPE_SLIDING_WIND]
+	if !ok {
+		return fmt.Errorf("%w: el sistema '%s' no tiene perfil '%s'", ErrNoProfileSystem, resolved.System.Name, constants.PROFILE_TYPE_SLIDING_WIND)
+	}
+	profiles := models.SlidingProfiles{Frame: frameProfile, Wind: windProfile}
+	if overlap, ok := resolved.Profiles[constants.PROFILE_TYPE_SLIDING_OVERLAP]; ok {
+		profiles.Overlap = &overlap
+	}
+
+	if err := element.SetSlidingWinds(layout, resolved.System, profiles); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidWindLayout, err)
+	}
+	s.logger.WithFields(logrus.Fields{"element_id": element.ID, "layout": layoutName}).
+		Infof("%d hojas correderas generadas con el sistema '%s'", len(element.Winds), resolved.System.Name)
+	return nil
+}
+
+// GenerateCasement reemplaza las hojas de un elemento de abatir por las pedidas, eligiendo el perfil
+// de hoja interior o exterior según el sentido de apertura de cada una.
+func (s *WindGeneratorService) GenerateCasement(ctx context.Context, element *models.Element, sashes []models.CasementSash) error {
+	resolved, err := s.resolver.Resolve(ctx, element.Type, element.Material)
+	if err != nil {
+		return err
+	}
+	frameProfile, ok := resolved.Profiles[constants.PROFILE_TYPE_CASEMENT_FRAME]
+	if !ok {
+		return fmt.Errorf("%w: el sistema '%s' no tiene perfil '%s'", ErrNoProfileSystem, resolved.System.Name, constants.PROFILE_TYPE_CASEMENT_FRAME)
+	}
+	profiles := casementProfiles(resolved, frameProfile)
+
+	if err := element.SetCasementWinds(sashes, profiles); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidWindLayout, err)
+	}
+	s.logger.WithField("element_id", element.ID).
+		Infof("%d hojas de abatir generadas con el sistema '%s'", len(element.Winds), resolved.System.Name)
+	return nil
+}
+
+// GenerateLayout divide la luz del elemento en campos según root (montantes, travesaños, vidrios y hojas).
+// El marco es el de abatir o el de corredera según el tipo del elemento.
+func (s *WindGeneratorService) GenerateLayout(ctx context.Context, element *models.Element, root models.Field) error {
+	resolved, err := s.resolver.Resolve(ctx, element.Type, element.Material)
+	if err != nil {
+		return err
+	}
+	frameType := constants.PROFILE_TYPE_CASEMENT_FRAME
+	if element.Type == constants.TYPE_SLIDING {
+		frameType = constants.PROFILE_TYPE_SLIDING_FRAME
+	}
+	frameProfile, ok := resolved.Profiles[frameType]
+	if !ok {
+		return fmt.Errorf("%w: el sistema '%s' no tiene perfil '%s'", ErrNoProfileSystem, resolved.System.Name, frameType)
+	}
+	profiles := models.LayoutProfiles{Casement: casementProfiles(resolved, frameProfile)}
+	if mullion, ok := resolved.Profiles[constants.PROFILE_TYPE_MULLION]; ok {
+		profiles.Mullion = &mullion
+	}
+
+	if err := element.SetLayout(root, profiles); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidWindLayout, err)
+	}
+	s.logger.WithField("element_id", element.ID).
+		Infof("Elemento dividido en campos con %d montantes y %d hojas", len(element.Layout.MullionDetails()), len(element.Winds))
+	return nil
+}
+
+// casementProfiles reúne el marco y los perfiles de hoja interior y exterior del sistema resuelto.
+func casementProfiles(resolved *ResolvedSystem, frame models.Profile) models.CasementProfiles {
+	profiles := models.CasementProfiles{Frame: frame}
+	if windIn, ok := resolved.Profiles[constants.PROFILE_TYPE_CASEMENT_WIND_IN]; ok {
+		profiles.WindIn = &windIn
+	}
+	if windOut, ok := resolved.Profiles[constants.PROFILE_TYPE_CASEMENT_WIND_OUT]; ok {
+		profiles.WindOut = &windOut
+	}
+	return profiles
+}
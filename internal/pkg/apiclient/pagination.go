@@ -0,0 +1,123 @@
+package apiclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// DefaultPageSize coincide con el máximo de filas por respuesta que PostgREST aplica por defecto.
+const DefaultPageSize = 1000
+
+// PageOptions configura la lectura paginada.
+type PageOptions struct {
+	PageSize   int  // Filas por página; 0 usa el PageSize del cliente
+	ExactCount bool // Pide a PostgREST el total exacto (Prefer: count=exact), con coste extra en la base de datos
+}
+
+// QueryPages lee todas las filas de path página a página usando las cabeceras Range/Content-Range
+// y llama a fn con el array JSON de cada página, en orden. queryParams no debe incluir limit ni offset;
+// para que las páginas sean estables conviene incluir un order por una columna única. Sin ExactCount
+// la lectura termina con una página vacía, lo que cuesta una petición más que con el total.
+// Devuelve el total de filas informado por Supabase, o -1 si no se conoce (sin ExactCount).
+func (c *SupabaseClient) QueryPages(ctx context.Context, path, queryParams string, opts PageOptions, fn func(page json.RawMessage) error) (int64, error) {
+	pageSize := opts.PageSize
+	if pageSize <= 0 {
+		pageSize = c.PageSize
+	}
+	if pageSize <= 0 {
+		pageSize = DefaultPageSize
+	}
+
+	total := int64(-1)
+	for from := 0; ; {
+		to := from + pageSize - 1
+		headers := map[string]string{
+			"Range-Unit": "items",
+			"Range":      fmt.Sprintf("%d-%d", from, to),
+		}
+		if opts.ExactCount {
+			headers["Prefer"] = "count=exact"
+		}
+
+		var rows []json.RawMessage
+		respHeaders, err := c.execute(ctx, http.MethodGet, path, queryParams, headers, nil, &rows, true)
+		if err != nil {
+			// 416: se pidió un rango más allá del final, que es donde termina la lectura sin total
+			var apiErr *APIError
+			if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusRequestedRangeNotSatisfiable {
+				return total, nil
+			}
+			return total, fmt.Errorf("error leyendo página %d-%d de %s: %w", from, to, path, err)
+		}
+
+		if t, ok := parseContentRangeTotal(respHeaders.Get("Content-Range")); ok {
+			total = t
+		}
+
+		if len(rows) > 0 {
+			page, err := json.Marshal(rows)
+			if err != nil {
+				return total, fmt.Errorf("error re-serializando página %d-%d de %s: %w", from, to, path, err)
+			}
+			if err := fn(page); err != nil {
+				return total, err
+			}
+		}
+
+		// Se avanza por las filas realmente recibidas: el servidor puede devolver menos que pageSize
+		// si su max-rows es menor, así que una página incompleta no marca el final. Con total
+		// conocido se sigue hasta alcanzarlo; sin él, hasta una página vacía o un 416.
+		from += len(rows)
+		if len(rows) == 0 || (total >= 0 && int64(from) >= total) {
+			return total, nil
+		}
+	}
+}
+
+// QueryAll lee todas las páginas de path y acumula las filas en target (puntero a slice).
+// Devuelve el total de filas informado por Supabase, o -1 si no se conoce.
+func (c *SupabaseClient) QueryAll(ctx context.Context, path, queryParams string, opts PageOptions, target interface{}) (int64, error) {
+	all := []json.RawMessage{}
+	total, err := c.QueryPages(ctx, path, queryParams, opts, func(page json.RawMessage) error {
+		var rows []json.RawMessage
+		if err := json.Unmarshal(page, &rows); err != nil {
+			return err
+		}
+		all = append(all, rows...)
+		return nil
+	})
+	if err != nil {
+		return total, err
+	}
+
+	data, err := json.Marshal(all)
+	if err != nil {
+		return total, fmt.Errorf("error serializando filas de %s: %w", path, err)
+	}
+	if err := json.Unmarshal(data, target); err != nil {
+		return total, fmt.Errorf("error decodificando filas de %s: %w", path, err)
+	}
+	if total >= 0 && int64(len(all)) != total {
+		return total, fmt.Errorf("lectura incompleta de %s: se obtuvieron %d de %d filas", path, len(all), total)
+	}
+	return total, nil
+}
+
+// parseContentRangeTotal extrae el total de una cabecera Content-Range ("0-999/5000", "*/0").
+// Devuelve false si el total es desconocido ("0-999/*").
+func parseContentRangeTotal(value string) (int64, bool) {
+	slash := strings.LastIndex(value, "/")
+	if slash < 0 {
+		return 0, false
+	}
+	total, err := strconv.ParseInt(strings.TrimSpace(value[slash+1:]), 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return total, true
+}
@@ -0,0 +1,174 @@
+package apiclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeTable simula una tabla de PostgREST con rows filas (0, 1, 2...) que atiende cabeceras Range.
+type fakeTable struct {
+	rows       int
+	maxRows    int   // Máximo de filas por respuesta del servidor (max-rows); 0 = sin límite
+	reportRows int   // Total que informa con count=exact; 0 = rows
+	end416     bool  // Responde 416 a un rango que empieza después del final, en vez de una página vacía
+	ranges     []int // Inicio de cada rango pedido
+}
+
+func (f *fakeTable) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var from, to int
+	if _, err := fmt.Sscanf(r.Header.Get("Range"), "%d-%d", &from, &to); err != nil {
+		http.Error(w, "falta Range", http.StatusBadRequest)
+		return
+	}
+	f.ranges = append(f.ranges, from)
+	total := "*"
+	if strings.Contains(r.Header.Get("Prefer"), "count=exact") {
+		total = fmt.Sprint(f.rows)
+		if f.reportRows > 0 {
+			total = fmt.Sprint(f.reportRows)
+		}
+	}
+	if from >= f.rows && f.end416 {
+		w.Header().Set("Content-Range", "*/"+total)
+		w.WriteHeader(http.StatusRequestedRangeNotSatisfiable)
+		return
+	}
+	to = min(to, f.rows-1)
+	if f.maxRows > 0 {
+		to = min(to, from+f.maxRows-1)
+	}
+	rows := []int{}
+	for i := from; i <= to; i++ {
+		rows = append(rows, i)
+	}
+	if len(rows) == 0 {
+		w.Header().Set("Content-Range", "*/"+total)
+	} else {
+		w.Header().Set("Content-Range", fmt.Sprintf("%d-%d/%s", from, to, total))
+	}
+	json.NewEncoder(w).Encode(rows)
+}
+
+func TestQueryPages(t *testing.T) {
+	tests := []struct {
+		name   string
+		table  fakeTable
+		opts   PageOptions
+		total  int64
+		ranges []int // Rangos que se deben pedir, por su inicio
+	}{
+		{"termina con página vacía", fakeTable{rows: 7}, PageOptions{PageSize: 3}, -1, []int{0, 3, 6, 7}},
+		{"página exacta y luego vacía", fakeTable{rows: 6}, PageOptions{PageSize: 3}, -1, []int{0, 3, 6}},
+		{"tabla vacía", fakeTable{rows: 0}, PageOptions{PageSize: 3}, -1, []int{0}},
+		{"páginas cortas por max-rows no cortan la lectura", fakeTable{rows: 7, maxRows: 2}, PageOptions{PageSize: 5}, -1, []int{0, 2, 4, 6, 7}},
+		{"termina con 416", fakeTable{rows: 6, end416: true}, PageOptions{PageSize: 3}, -1, []int{0, 3, 6}},
+		{"416 tras página corta", fakeTable{rows: 7, maxRows: 2, end416: true}, PageOptions{PageSize: 5}, -1, []int{0, 2, 4, 6, 7}},
+		{"con total se detiene al alcanzarlo", fakeTable{rows: 7}, PageOptions{PageSize: 3, ExactCount: true}, 7, []int{0, 3, 6}},
+		{"con total y páginas cortas", fakeTable{rows: 7, maxRows: 2}, PageOptions{PageSize: 5, ExactCount: true}, 7, []int{0, 2, 4, 6}},
+		{"con total en tabla vacía", fakeTable{rows: 0}, PageOptions{PageSize: 3, ExactCount: true}, 0, []int{0}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(&tt.table)
+			defer srv.Close()
+			client := &SupabaseClient{BaseURL: srv.URL, HttpClient: srv.Client(), PageSize: 100}
+
+			var got []int
+			total, err := client.QueryPages(context.Background(), "/rest/v1/t", "order=id", tt.opts, func(page json.RawMessage) error {
+				var rows []int
+				if err := json.Unmarshal(page, &rows); err != nil {
+					return err
+				}
+				if len(rows) == 0 {
+					t.Error("se llamó a fn con una página vacía")
+				}
+				got = append(got, rows...)
+				return nil
+			})
+			if err != nil {
+				t.Fatalf("QueryPages: %v", err)
+			}
+			if total != tt.total {
+				t.Errorf("total = %d, se esperaba %d", total, tt.total)
+			}
+			if len(got) != tt.table.rows {
+				t.Fatalf("filas = %d, se esperaban %d", len(got), tt.table.rows)
+			}
+			for i, row := range got {
+				if row != i {
+					t.Fatalf("fila %d = %d: filas repetidas o fuera de orden (%v)", i, row, got)
+				}
+			}
+			if fmt.Sprint(tt.table.ranges) != fmt.Sprint(tt.ranges) {
+				t.Errorf("rangos pedidos = %v, se esperaba %v", tt.table.ranges, tt.ranges)
+			}
+		})
+	}
+}
+
+func TestQueryAll(t *testing.T) {
+	table := fakeTable{rows: 5, maxRows: 2}
+	srv := httptest.NewServer(&table)
+	defer srv.Close()
+	client := &SupabaseClient{BaseURL: srv.URL, HttpClient: srv.Client(), PageSize: 100}
+
+	var rows []int
+	total, err := client.QueryAll(context.Background(), "/rest/v1/t", "", PageOptions{}, &rows)
+	if err != nil || total != -1 || fmt.Sprint(rows) != "[0 1 2 3 4]" {
+		t.Errorf("QueryAll = %v, %d, %v; se esperaba [0 1 2 3 4], -1, nil", rows, total, err)
+	}
+}
+
+// TestQueryAllIncomplete comprueba que se informa una lectura con menos filas que el total.
+func TestQueryAllIncomplete(t *testing.T) {
+	table := fakeTable{rows: 5, reportRows: 8}
+	srv := httptest.NewServer(&table)
+	defer srv.Close()
+	client := &SupabaseClient{BaseURL: srv.URL, HttpClient: srv.Client(), PageSize: 3}
+
+	var rows []int
+	_, err := client.QueryAll(context.Background(), "/rest/v1/t", "", PageOptions{ExactCount: true}, &rows)
+	if err == nil || !strings.Contains(err.Error(), "lectura incompleta") {
+		t.Errorf("error = %v, se esperaba una lectura incompleta", err)
+	}
+}
+
+// TestQueryPagesError comprueba que un error distinto de 416 corta la lectura y se informa.
+func TestQueryPagesError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "sin permiso", http.StatusForbidden)
+	}))
+	defer srv.Close()
+	client := &SupabaseClient{BaseURL: srv.URL, HttpClient: srv.Client(), Retry: RetryPolicy{BaseDelay: time.Millisecond}}
+
+	_, err := client.QueryPages(context.Background(), "/rest/v1/t", "", PageOptions{PageSize: 3}, func(json.RawMessage) error { return nil })
+	if err == nil || !strings.Contains(err.Error(), "página 0-2") {
+		t.Errorf("error = %v, se esperaba el error de la página 0-2", err)
+	}
+}
+
+func TestParseContentRangeTotal(t *testing.T) {
+	tests := []struct {
+		value string
+		total int64
+		ok    bool
+	}{
+		{"0-999/5000", 5000, true},
+		{"*/0", 0, true},
+		{"0-999/*", 0, false},
+		{"", 0, false},
+		{"0-9", 0, false},
+	}
+	for _, tt := range tests {
+		total, ok := parseContentRangeTotal(tt.value)
+		if total != tt.total || ok != tt.ok {
+			t.Errorf("parseContentRangeTotal(%q) = %d, %v; se esperaba %d, %v", tt.value, total, ok, tt.total, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,314 @@
+package apiclient
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	// columnPattern valida nombres de columna, admitiendo columnas de recursos embebidos ("profiles.profile_type").
+	columnPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)*$`)
+	// selectColumnPattern valida una columna simple del select, con alias y cast opcionales ("sku:profile_sku::text").
+	selectColumnPattern = regexp.MustCompile(`^([A-Za-z_][A-Za-z0-9_]*:)?(\*|[A-Za-z_][A-Za-z0-9_]*(::[A-Za-z_][A-Za-z0-9_]*)?)$`)
+	// embedPattern separa un recurso embebido en alias, nombre (con hint opcional) y columnas internas.
+	embedPattern = regexp.MustCompile(`^([A-Za-z_][A-Za-z0-9_]*:)?([A-Za-z_][A-Za-z0-9_]*(![A-Za-z_][A-Za-z0-9_]*)*)\((.*)\)$`)
+)
+
+// queryParam es un par clave/valor ya formateado con la sintaxis de PostgREST, aún sin escapar.
+type queryParam struct {
+	key   string
+	value string
+}
+
+// Query construye query strings de PostgREST validando columnas y escapando valores.
+// Los métodos se encadenan; el primer error encontrado se devuelve en Build.
+//
+//	q := apiclient.NewQuery().Select("*", "profiles(*)").Eq("item_sku", "PVC 60+").Order("profile_id", true).Limit(10)
+//	queryParams, err := q.Build()
+type Query struct {
+	selects []string
+	filters []queryParam
+	orders  []string
+	limit   *int
+	offset  *int
+	err     error
+}
+
+// NewQuery crea un Query vacío.
+func NewQuery() *Query {
+	return &Query{}
+}
+
+// Select añade columnas al select. Acepta "*", "columna", "alias:columna", "columna::tipo"
+// y recursos embebidos como "profiles(*)" o "reinforcement:profiles!fk_nombre(profile_sku,profile_name)".
+func (q *Query) Select(columns ...string) *Query {
+	for _, column := range columns {
+		column = strings.TrimSpace(column)
+		if err := validateSelectItem(column); err != nil {
+			q.setErr(err)
+			continue
+		}
+		q.selects = append(q.selects, column)
+	}
+	return q
+}
+
+// Eq filtra por columna = valor.
+func (q *Query) Eq(column string, value interface{}) *Query { return q.filter(column, "eq", value) }
+
+// Neq filtra por columna <> valor.
+func (q *Query) Neq(column string, value interface{}) *Query { return q.filter(column, "neq", value) }
+
+// Gt filtra por columna > valor.
+func (q *Query) Gt(column string, value interface{}) *Query { return q.filter(column, "gt", value) }
+
+// Gte filtra por columna >= valor.
+func (q *Query) Gte(column string, value interface{}) *Query { return q.filter(column, "gte", value) }
+
+// Lt filtra por columna < valor.
+func (q *Query) Lt(column string, value interface{}) *Query { return q.filter(column, "lt", value) }
+
+// Lte filtra por columna <= valor.
+func (q *Query) Lte(column string, value interface{}) *Query { return q.filter(column, "lte", value) }
+
+// ILike filtra por coincidencia de patrón sin distinguir mayúsculas; '*' actúa como comodín '%'
+// y '%' y '_' conservan su sentido en LIKE. Para comparar con un valor ingresado, ver IEq.
+func (q *Query) ILike(column string, pattern string) *Query {
+	return q.filter(column, "ilike", pattern)
+}
+
+// IEq filtra por columna = valor sin distinguir mayúsculas: un ilike con '%', '_' y '\' escapados.
+// PostgREST convierte todo '*' en '%' sin permitir escaparlo, así que cada '*' se reemplaza por '_':
+// el filtro sigue aceptando el valor exacto, pero si este puede contener '*' conviene confirmar
+// las filas devueltas con strings.EqualFold.
+func (q *Query) IEq(column string, value string) *Query {
+	return q.filter(column, "ilike", likeEscaper.Replace(value))
+}
+
+// IsNull filtra por columna IS NULL.
+func (q *Query) IsNull(column string) *Query {
+	if !q.validColumn(column) {
+		return q
+	}
+	q.filters = append(q.filters, queryParam{key: column, value: "is.null"})
+	return q
+}
+
+// In filtra por columna IN (valores). Los valores con caracteres reservados de PostgREST
+// (comas, paréntesis, comillas...) se encierran entre comillas dobles.
+func (q *Query) In(column string, values ...interface{}) *Query {
+	if !q.validColumn(column) {
+		return q
+	}
+	if len(values) == 0 {
+		q.setErr(fmt.Errorf("apiclient: el filtro in sobre '%s' requiere al menos un valor", column))
+		return q
+	}
+	items := make([]string, 0, len(values))
+	for _, v := range values {
+		formatted, err := formatValue(v)
+		if err != nil {
+			q.setErr(fmt.Errorf("apiclient: valor inválido para '%s': %w", column, err))
+			return q
+		}
+		items = append(items, quoteListItem(formatted))
+	}
+	q.filters = append(q.filters, queryParam{key: column, value: "in.(" + strings.Join(items, ",") + ")"})
+	return q
+}
+
+// Order añade un criterio de orden. Se pueden encadenar varios.
+func (q *Query) Order(column string, ascending bool) *Query {
+	return q.order(column, ascending, "")
+}
+
+// OrderNullsLast añade un criterio de orden dejando los NULL al final.
+func (q *Query) OrderNullsLast(column string, ascending bool) *Query {
+	return q.order(column, ascending, "nullslast")
+}
+
+// Limit limita el número de filas devueltas.
+func (q *Query) Limit(n int) *Query {
+	if n < 0 {
+		q.setErr(fmt.Errorf("apiclient: limit no puede ser negativo (%d)", n))
+		return q
+	}
+	q.limit = &n
+	return q
+}
+
+// Offset omite las primeras n filas.
+func (q *Query) Offset(n int) *Query {
+	if n < 0 {
+		q.setErr(fmt.Errorf("apiclient: offset no puede ser negativo (%d)", n))
+		return q
+	}
+	q.offset = &n
+	return q
+}
+
+// Range limita el resultado a las filas from..to (ambas inclusive, base 0).
+func (q *Query) Range(from, to int) *Query {
+	if from < 0 || to < from {
+		q.setErr(fmt.Errorf("apiclient: rango inválido %d-%d", from, to))
+		return q
+	}
+	q.Offset(from)
+	return q.Limit(to - from + 1)
+}
+
+// Build devuelve el query string escapado, listo para QueryData, Update o Delete.
+func (q *Query) Build() (string, error) {
+	if q.err != nil {
+		return "", q.err
+	}
+	parts := make([]string, 0, len(q.filters)+4)
+	if len(q.selects) > 0 {
+		parts = append(parts, "select="+escapeQueryValue(strings.Join(q.selects, ",")))
+	}
+	for _, f := range q.filters {
+		parts = append(parts, escapeQueryValue(f.key)+"="+escapeQueryValue(f.value))
+	}
+	if len(q.orders) > 0 {
+		parts = append(parts, "order="+escapeQueryValue(strings.Join(q.orders, ",")))
+	}
+	if q.limit != nil {
+		parts = append(parts, "limit="+strconv.Itoa(*q.limit))
+	}
+	if q.offset != nil {
+		parts = append(parts, "offset="+strconv.Itoa(*q.offset))
+	}
+	return strings.Join(parts, "&"), nil
+}
+
+func (q *Query) filter(column, operator string, value interface{}) *Query {
+	if !q.validColumn(column) {
+		return q
+	}
+	formatted, err := formatValue(value)
+	if err != nil {
+		q.setErr(fmt.Errorf("apiclient: valor inválido para '%s': %w", column, err))
+		return q
+	}
+	q.filters = append(q.filters, queryParam{key: column, value: operator + "." + formatted})
+	return q
+}
+
+func (q *Query) order(column string, ascending bool, nulls string) *Query {
+	if !q.validColumn(column) {
+		return q
+	}
+	direction := "desc"
+	if ascending {
+		direction = "asc"
+	}
+	term := column + "." + direction
+	if nulls != "" {
+		term += "." + nulls
+	}
+	q.orders = append(q.orders, term)
+	return q
+}
+
+func (q *Query) validColumn(column string) bool {
+	if !columnPattern.MatchString(column) {
+		q.setErr(fmt.Errorf("apiclient: nombre de columna inválido '%s'", column))
+		return false
+	}
+	return true
+}
+
+// setErr conserva solo el primer error, que suele ser el más informativo.
+func (q *Query) setErr(err error) {
+	if q.err == nil {
+		q.err = err
+	}
+}
+
+// validateSelectItem valida una entrada del select, recorriendo recursivamente los recursos embebidos.
+func validateSelectItem(item string) error {
+	if selectColumnPattern.MatchString(item) {
+		return nil
+	}
+	m := embedPattern.FindStringSubmatch(item)
+	if m == nil {
+		return fmt.Errorf("apiclient: columna de select inválida '%s'", item)
+	}
+	inner := m[4]
+	if strings.TrimSpace(inner) == "" {
+		return fmt.Errorf("apiclient: el recurso embebido '%s' no tiene columnas", item)
+	}
+	for _, sub := range splitTopLevel(inner) {
+		if err := validateSelectItem(strings.TrimSpace(sub)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// splitTopLevel separa por comas que no estén dentro de paréntesis.
+func splitTopLevel(s string) []string {
+	var parts []string
+	depth, start := 0, 0
+	for i, r := range s {
+		switch r {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				parts = append(parts, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(parts, s[start:])
+}
+
+// formatValue convierte un valor de filtro a su representación textual en PostgREST.
+func formatValue(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case string:
+		return v, nil
+	case int:
+		return strconv.Itoa(v), nil
+	case int16:
+		return strconv.FormatInt(int64(v), 10), nil
+	case int32:
+		return strconv.FormatInt(int64(v), 10), nil
+	case int64:
+		return strconv.FormatInt(v, 10), nil
+	case float32:
+		return strconv.FormatFloat(float64(v), 'f', -1, 32), nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case fmt.Stringer:
+		return v.String(), nil
+	default:
+		return "", fmt.Errorf("tipo no soportado %T", value)
+	}
+}
+
+// likeEscaper escapa los comodines de LIKE para que un valor se compare literalmente (ver IEq).
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`, `*`, `_`)
+
+// quoteListItem encierra entre comillas los elementos de in.(...) con caracteres reservados.
+func quoteListItem(value string) string {
+	if value != "" && !strings.ContainsAny(value, `,()":\ `) {
+		return value
+	}
+	escaped := strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(value)
+	return `"` + escaped + `"`
+}
+
+// escapeQueryValue escapa para query string usando %20 para espacios:
+// PostgREST interpreta '+' literal como espacio, así que nunca se deja sin escapar.
+func escapeQueryValue(value string) string {
+	return strings.ReplaceAll(url.QueryEscape(value), "+", "%20")
+}
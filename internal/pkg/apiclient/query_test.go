@@ -0,0 +1,127 @@
+package apiclient
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestQueryEscaping(t *testing.T) {
+	tests := []struct {
+		name  string
+		query *Query
+		want  string            // Query string exacto
+		param map[string]string // Valor de cada parámetro una vez decodificado, como lo lee PostgREST
+	}{
+		{
+			name:  "espacio y más",
+			query: NewQuery().Eq("item_sku", "PVC 60+"),
+			want:  "item_sku=eq.PVC%2060%2B",
+			param: map[string]string{"item_sku": "eq.PVC 60+"},
+		},
+		{
+			name:  "ampersand no corta el parámetro",
+			query: NewQuery().Eq("name", "Blanco & Roble").Eq("system_id", 3),
+			want:  "name=eq.Blanco%20%26%20Roble&system_id=eq.3",
+			param: map[string]string{"name": "eq.Blanco & Roble", "system_id": "eq.3"},
+		},
+		{
+			name:  "coma y paréntesis en eq",
+			query: NewQuery().Eq("name", "Marco (60, 70)"),
+			want:  "name=eq.Marco%20%2860%2C%2070%29",
+			param: map[string]string{"name": "eq.Marco (60, 70)"},
+		},
+		{
+			name:  "igual y porcentaje",
+			query: NewQuery().Eq("code", "a=b%c"),
+			want:  "code=eq.a%3Db%25c",
+			param: map[string]string{"code": "eq.a=b%c"},
+		},
+		{
+			name:  "ieq escapa comodines",
+			query: NewQuery().IEq("name", `PVC 50%_a*b\`),
+			param: map[string]string{"name": `ilike.PVC 50\%\_a_b\\`},
+		},
+		{
+			name:  "in con valores simples",
+			query: NewQuery().In("profile_id", 1, int64(2), "3"),
+			want:  "profile_id=in.%281%2C2%2C3%29",
+			param: map[string]string{"profile_id": "in.(1,2,3)"},
+		},
+		{
+			name:  "in con reservados entre comillas",
+			query: NewQuery().In("item_sku", "PVC 60+", "A,B", "C(D)", `E"F`, "G+H", "I&J", ""),
+			param: map[string]string{"item_sku": `in.("PVC 60+","A,B","C(D)","E\"F",G+H,I&J,"")`},
+		},
+		{
+			name:  "select con embebido, orden y rango",
+			query: NewQuery().Select("profile_id", "sku:profile_sku", "profiles(profile_sku,profile_name)").Order("profile_id", true).OrderNullsLast("primacy", false).Range(10, 19),
+			want:  "select=profile_id%2Csku%3Aprofile_sku%2Cprofiles%28profile_sku%2Cprofile_name%29&order=profile_id.asc%2Cprimacy.desc.nullslast&limit=10&offset=10",
+			param: map[string]string{"select": "profile_id,sku:profile_sku,profiles(profile_sku,profile_name)", "order": "profile_id.asc,primacy.desc.nullslast", "limit": "10", "offset": "10"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.query.Build()
+			if err != nil {
+				t.Fatalf("Build: %v", err)
+			}
+			if tt.want != "" && got != tt.want {
+				t.Errorf("query = %q, se esperaba %q", got, tt.want)
+			}
+			if strings.Contains(got, "+") {
+				t.Errorf("query %q tiene un '+' sin escapar, que PostgREST lee como espacio", got)
+			}
+			values, err := url.ParseQuery(got)
+			if err != nil {
+				t.Fatalf("query %q no se puede decodificar: %v", got, err)
+			}
+			if len(values) != len(tt.param) {
+				t.Errorf("parámetros = %v, se esperaba %v", values, tt.param)
+			}
+			for key, want := range tt.param {
+				if got := values[key]; len(got) != 1 || got[0] != want {
+					t.Errorf("%s = %q, se esperaba %q", key, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestQueryInvalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		query *Query
+		err   string
+	}{
+		{"columna con espacio", NewQuery().Eq("item sku", "x"), "nombre de columna inválido"},
+		{"columna con inyección", NewQuery().Eq("id&select", 1), "nombre de columna inválido"},
+		{"select con coma suelta", NewQuery().Select("profile_id,profile_sku"), "columna de select inválida"},
+		{"select con espacio", NewQuery().Select("profile id"), "columna de select inválida"},
+		{"select embebido sin columnas", NewQuery().Select("profiles()"), "no tiene columnas"},
+		{"select embebido inválido", NewQuery().Select("profiles(profile_sku,bad name)"), "columna de select inválida"},
+		{"select con paréntesis sin cerrar", NewQuery().Select("profiles(profile_sku"), "columna de select inválida"},
+		{"in vacío", NewQuery().In("profile_id"), "al menos un valor"},
+		{"tipo no soportado", NewQuery().Eq("profile_id", []int{1}), "tipo no soportado"},
+		{"limit negativo", NewQuery().Limit(-1), "limit no puede ser negativo"},
+		{"rango invertido", NewQuery().Range(5, 2), "rango inválido"},
+		{"se informa el primer error", NewQuery().Eq("a b", 1).Limit(-1), "nombre de columna inválido"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.query.Build()
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("Build = %q, %v; se esperaba un error con %q", got, err, tt.err)
+			}
+		})
+	}
+}
+
+func TestQuerySelectValid(t *testing.T) {
+	for _, column := range []string{"*", "profile_id", "sku:profile_sku", "length::text", "alias:length::int", "profiles(*)",
+		"reinforcement:profiles!fk_reinforcement(profile_sku,profile_name)", "profiles(profile_sku,colors(name,hex))"} {
+		if _, err := NewQuery().Select(column).Build(); err != nil {
+			t.Errorf("Select(%q): %v", column, err)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package apiclient
+
+import (
+	"context"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RetryPolicy define cuántas veces y con qué espera se reintenta una petición fallida.
+// La espera crece exponencialmente (BaseDelay * 2^intento), se limita a MaxDelay
+// y se le aplica jitter para no sincronizar reintentos de varios clientes.
+type RetryPolicy struct {
+	MaxRetries int           // Reintentos adicionales al primer intento (0 = sin reintentos)
+	BaseDelay  time.Duration // Espera antes del primer reintento
+	MaxDelay   time.Duration // Espera máxima entre reintentos
+}
+
+// backoff calcula la espera antes del reintento número attempt (empezando en 0).
+// Devuelve un valor aleatorio entre la mitad y el total del retardo exponencial.
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	delay := p.BaseDelay
+	for i := 0; i < attempt && delay < p.MaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+	if delay <= 0 {
+		return 0
+	}
+	half := delay / 2
+	return half + rand.N(half+1)
+}
+
+// retryDelay calcula la espera antes del reintento número attempt. Si el servidor envió Retry-After
+// se respeta, pero nunca más allá de MaxDelay. Devuelve false si la espera no cabe en el plazo de
+// ctx: en ese caso conviene devolver el error en lugar de dormir hasta que ctx venza.
+func (p RetryPolicy) retryDelay(ctx context.Context, attempt int, retryAfter string, now time.Time) (time.Duration, bool) {
+	delay := p.backoff(attempt)
+	if value, ok := parseRetryAfter(retryAfter, now); ok {
+		delay = value
+		if p.MaxDelay > 0 && delay > p.MaxDelay {
+			delay = p.MaxDelay
+		}
+	}
+	if deadline, ok := ctx.Deadline(); ok && now.Add(delay).After(deadline) {
+		return 0, false
+	}
+	return delay, true
+}
+
+// retryKey es la clave de contexto con la que WithRetry habilita los reintentos.
+type retryKey struct{}
+
+// WithRetry habilita los reintentos en las llamadas que por defecto no los hacen (Insert, CallRPC
+// y Upsert sin onConflict), porque repetirlas tras un timeout puede duplicar filas que el servidor
+// ya había guardado. Solo debe usarse cuando repetir la llamada es inocuo, por ejemplo en una
+// función RPC de solo lectura o idempotente.
+func WithRetry(ctx context.Context) context.Context {
+	return context.WithValue(ctx, retryKey{}, true)
+}
+
+// retryEnabled indica si ctx fue marcado con WithRetry.
+func retryEnabled(ctx context.Context) bool {
+	enabled, _ := ctx.Value(retryKey{}).(bool)
+	return enabled
+}
+
+// isRetryableStatus indica si un código de estado HTTP corresponde a un error transitorio.
+func isRetryableStatus(status int) bool {
+	return status == http.StatusTooManyRequests || status >= 500
+}
+
+// parseRetryAfter interpreta la cabecera Retry-After, en segundos o como fecha HTTP.
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		delay := date.Sub(now)
+		if delay < 0 {
+			delay = 0
+		}
+		return delay, true
+	}
+	return 0, false
+}
+
+// sleepContext espera la duración indicada o hasta que ctx se cancele.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
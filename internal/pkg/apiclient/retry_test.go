@@ -0,0 +1,196 @@
+package apiclient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestBackoffGrowth(t *testing.T) {
+	policy := RetryPolicy{BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}
+	// Retardo exponencial sin jitter de cada intento; desde el cuarto queda en MaxDelay.
+	full := []time.Duration{100, 200, 400, 800, 1000, 1000, 1000}
+	for attempt, want := range full {
+		want *= time.Millisecond
+		for i := 0; i < 50; i++ {
+			got := policy.backoff(attempt)
+			if got < want/2 || got > want {
+				t.Fatalf("intento %d: espera %v fuera de [%v, %v]", attempt, got, want/2, want)
+			}
+		}
+	}
+	if got := (RetryPolicy{}).backoff(3); got != 0 {
+		t.Errorf("sin BaseDelay la espera = %v, se esperaba 0", got)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name  string
+		value string
+		want  time.Duration
+		ok    bool
+	}{
+		{"segundos", "3", 3 * time.Second, true},
+		{"segundos con espacios", " 120 ", 2 * time.Minute, true},
+		{"cero", "0", 0, true},
+		{"negativo", "-1", 0, false},
+		{"vacío", "", 0, false},
+		{"texto", "pronto", 0, false},
+		{"fecha HTTP", now.Add(90 * time.Second).Format(http.TimeFormat), 90 * time.Second, true},
+		{"fecha RFC 850", now.Add(time.Minute).Format("Monday, 02-Jan-06 15:04:05 GMT"), time.Minute, true},
+		{"fecha pasada", now.Add(-time.Hour).Format(http.TimeFormat), 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := parseRetryAfter(tt.value, now)
+			if got != tt.want || ok != tt.ok {
+				t.Errorf("parseRetryAfter(%q) = %v, %v; se esperaba %v, %v", tt.value, got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	now := time.Now()
+	policy := RetryPolicy{BaseDelay: 100 * time.Millisecond, MaxDelay: 5 * time.Second}
+	deadline, cancel := context.WithDeadline(context.Background(), now.Add(2*time.Second))
+	defer cancel()
+
+	tests := []struct {
+		name       string
+		ctx        context.Context
+		retryAfter string
+		min, max   time.Duration
+		ok         bool
+	}{
+		{"backoff sin cabecera", context.Background(), "", 50 * time.Millisecond, 100 * time.Millisecond, true},
+		{"Retry-After en segundos", context.Background(), "2", 2 * time.Second, 2 * time.Second, true},
+		{"Retry-After limitado a MaxDelay", context.Background(), "3600", 5 * time.Second, 5 * time.Second, true},
+		{"fecha limitada a MaxDelay", context.Background(), now.Add(time.Hour).Format(http.TimeFormat), 5 * time.Second, 5 * time.Second, true},
+		{"cabecera inválida usa backoff", context.Background(), "mañana", 50 * time.Millisecond, 100 * time.Millisecond, true},
+		{"cabe en el plazo", deadline, "1", time.Second, time.Second, true},
+		{"no cabe en el plazo", deadline, "3", 0, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := policy.retryDelay(tt.ctx, 0, tt.retryAfter, now)
+			if ok != tt.ok || got < tt.min || got > tt.max {
+				t.Errorf("retryDelay = %v, %v; se esperaba [%v, %v], %v", got, ok, tt.min, tt.max, tt.ok)
+			}
+		})
+	}
+}
+
+// flakyServer responde failures veces con status (y Retry-After si se indica) y después 200 con
+// un arreglo vacío. Cuenta las peticiones recibidas.
+func flakyServer(t *testing.T, failures, status int, retryAfter string) (*SupabaseClient, *atomic.Int32) {
+	t.Helper()
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if int(requests.Add(1)) <= failures {
+			if retryAfter != "" {
+				w.Header().Set("Retry-After", retryAfter)
+			}
+			w.WriteHeader(status)
+			return
+		}
+		w.Write([]byte("[]"))
+	}))
+	t.Cleanup(srv.Close)
+	client := &SupabaseClient{
+		BaseURL:    srv.URL,
+		HttpClient: srv.Client(),
+		Retry:      RetryPolicy{MaxRetries: 3, BaseDelay: time.Millisecond, MaxDelay: 10 * time.Millisecond},
+	}
+	return client, &requests
+}
+
+func TestRetriesByMethod(t *testing.T) {
+	var rows []map[string]interface{}
+	tests := []struct {
+		name     string
+		call     func(ctx context.Context, c *SupabaseClient) error
+		ctx      context.Context
+		requests int32
+		status   int // 0 si la llamada debe terminar bien
+	}{
+		{"GET se reintenta", func(ctx context.Context, c *SupabaseClient) error {
+			return c.QueryData(ctx, "/rest/v1/t", "", &rows)
+		}, context.Background(), 3, 0},
+		{"PATCH se reintenta", func(ctx context.Context, c *SupabaseClient) error {
+			return c.Update(ctx, "/rest/v1/t", "id=eq.1", map[string]interface{}{"a": 1}, nil)
+		}, context.Background(), 3, 0},
+		{"POST no se reintenta", func(ctx context.Context, c *SupabaseClient) error {
+			return c.Insert(ctx, "/rest/v1/t", map[string]interface{}{"a": 1}, nil)
+		}, context.Background(), 1, http.StatusServiceUnavailable},
+		{"RPC no se reintenta", func(ctx context.Context, c *SupabaseClient) error {
+			return c.CallRPC(ctx, "f", nil, nil)
+		}, context.Background(), 1, http.StatusServiceUnavailable},
+		{"upsert sin on_conflict no se reintenta", func(ctx context.Context, c *SupabaseClient) error {
+			return c.Upsert(ctx, "/rest/v1/t", map[string]interface{}{"a": 1}, "", nil)
+		}, context.Background(), 1, http.StatusServiceUnavailable},
+		{"upsert con on_conflict se reintenta", func(ctx context.Context, c *SupabaseClient) error {
+			return c.Upsert(ctx, "/rest/v1/t", map[string]interface{}{"a": 1}, "id", nil)
+		}, context.Background(), 3, 0},
+		{"POST con WithRetry se reintenta", func(ctx context.Context, c *SupabaseClient) error {
+			return c.Insert(ctx, "/rest/v1/t", map[string]interface{}{"a": 1}, nil)
+		}, WithRetry(context.Background()), 3, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, requests := flakyServer(t, 2, http.StatusServiceUnavailable, "")
+			err := tt.call(tt.ctx, client)
+			if got := requests.Load(); got != tt.requests {
+				t.Errorf("peticiones = %d, se esperaban %d", got, tt.requests)
+			}
+			var apiErr *APIError
+			switch {
+			case tt.status == 0 && err != nil:
+				t.Errorf("error inesperado: %v", err)
+			case tt.status != 0 && (!errors.As(err, &apiErr) || apiErr.StatusCode != tt.status):
+				t.Errorf("error = %v, se esperaba status %d", err, tt.status)
+			}
+		})
+	}
+}
+
+func TestRetryGivesUp(t *testing.T) {
+	client, requests := flakyServer(t, 10, http.StatusTooManyRequests, "0")
+	var rows []map[string]interface{}
+	err := client.QueryData(context.Background(), "/rest/v1/t", "", &rows)
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusTooManyRequests {
+		t.Errorf("error = %v, se esperaba status 429", err)
+	}
+	if got := requests.Load(); got != 4 {
+		t.Errorf("peticiones = %d, se esperaban 4 (1 + 3 reintentos)", got)
+	}
+}
+
+// TestRetryAfterBeyondDeadline comprueba que un Retry-After que no cabe en el plazo de ctx
+// devuelve la respuesta de inmediato en lugar de dormir hasta que ctx venza.
+func TestRetryAfterBeyondDeadline(t *testing.T) {
+	client, requests := flakyServer(t, 10, http.StatusTooManyRequests, "1")
+	client.Retry.MaxDelay = time.Minute
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	var rows []map[string]interface{}
+	err := client.QueryData(ctx, "/rest/v1/t", "", &rows)
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusTooManyRequests {
+		t.Errorf("error = %v, se esperaba status 429", err)
+	}
+	if elapsed := time.Since(start); elapsed > 150*time.Millisecond {
+		t.Errorf("tardó %v, se esperaba volver sin esperar", elapsed)
+	}
+	if got := requests.Load(); got != 1 {
+		t.Errorf("peticiones = %d, se esperaba 1", got)
+	}
+}
@@ -2,64 +2,254 @@ package apiclient
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
+	"strings"
 	"time"
 
 	// Usa el nombre de tu módulo Go aquí
 	"github.com/mvialf/windraw/internal/pkg/config"
 )
 
+// Valores por defecto usados cuando la configuración no los define.
+const (
+	defaultRequestTimeout = 10 * time.Second
+	defaultRetryBaseDelay = 200 * time.Millisecond
+	defaultRetryMaxDelay  = 5 * time.Second
+)
+
 // SupabaseClient encapsula la configuración para hacer llamadas a la API de Supabase.
 type SupabaseClient struct {
 	BaseURL        string
 	ServiceRoleKey string
 	HttpClient     *http.Client
+	Retry          RetryPolicy // Política de reintentos ante errores transitorios
+	PageSize       int         // Filas por página en las lecturas paginadas (QueryPages/QueryAll)
+}
+
+// APIError es el error devuelto cuando Supabase responde con un código de estado no 2xx.
+type APIError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("error de Supabase API (status %d): %s", e.StatusCode, e.Body)
 }
 
 // NewSupabaseClient crea una nueva instancia de SupabaseClient.
 func NewSupabaseClient(cfg *config.APIConfig) *SupabaseClient {
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = defaultRequestTimeout
+	}
+	retry := RetryPolicy{
+		MaxRetries: cfg.MaxRetries,
+		BaseDelay:  cfg.RetryBaseDelay,
+		MaxDelay:   cfg.RetryMaxDelay,
+	}
+	if retry.BaseDelay <= 0 {
+		retry.BaseDelay = defaultRetryBaseDelay
+	}
+	if retry.MaxDelay <= 0 {
+		retry.MaxDelay = defaultRetryMaxDelay
+	}
+	pageSize := cfg.PageSize
+	if pageSize <= 0 {
+		pageSize = DefaultPageSize
+	}
 	return &SupabaseClient{
 		BaseURL:        cfg.BaseURL,
 		ServiceRoleKey: cfg.ServiceRoleKey,
 		HttpClient: &http.Client{
-			Timeout: 10 * time.Second, // Timeout para las peticiones
+			Timeout: timeout, // Timeout por intento; el ctx de la llamada limita el total
 		},
+		Retry:    retry,
+		PageSize: pageSize,
 	}
 }
 
 // QueryData hace una petición GET a un endpoint de Supabase (PostgREST)
+// ctx: controla la cancelación y el plazo total de la llamada, incluidos los reintentos.
 // path: ej. "/rest/v1/nombre_tabla"
 // queryParams: ej. "select=columna1,columna2&otra_columna=eq.valor"
 // target: un puntero a la estructura o slice de estructuras donde decodificar la respuesta JSON.
-func (c *SupabaseClient) QueryData(path string, queryParams string, target interface{}) error {
+func (c *SupabaseClient) QueryData(ctx context.Context, path string, queryParams string, target interface{}) error {
+	return c.doRequest(ctx, http.MethodGet, path, queryParams, nil, nil, target, true)
+}
+
+// Insert inserta una fila o un slice de filas en una tabla (POST).
+// No se reintenta salvo que ctx venga de WithRetry: si el servidor guardó las filas pero la
+// respuesta no llegó, repetir el POST las duplicaría.
+// path: ej. "/rest/v1/nombre_tabla"
+// rows: estructura, mapa o slice serializable a JSON.
+// target: si no es nil, recibe las filas insertadas tal como quedaron en la base de datos.
+func (c *SupabaseClient) Insert(ctx context.Context, path string, rows interface{}, target interface{}) error {
+	return c.doRequest(ctx, http.MethodPost, path, "", preferHeaders(target), rows, target, false)
+}
+
+// Upsert inserta filas o, si ya existen según onConflict, las fusiona con los datos enviados.
+// onConflict: columnas separadas por coma que forman la restricción única (vacío = clave primaria).
+// Solo se reintenta con onConflict, que hace que repetir la llamada fusione en vez de insertar.
+func (c *SupabaseClient) Upsert(ctx context.Context, path string, rows interface{}, onConflict string, target interface{}) error {
+	queryParams := ""
+	if onConflict != "" {
+		queryParams = "on_conflict=" + url.QueryEscape(onConflict)
+	}
+	return c.doRequest(ctx, http.MethodPost, path, queryParams, preferHeaders(target, "resolution=merge-duplicates"), rows, target, onConflict != "")
+}
+
+// Update modifica las filas que cumplen filters (PATCH) con los campos de patch.
+// filters: filtros PostgREST, ej. "id=eq.42". Es obligatorio para no modificar toda la tabla por error.
+func (c *SupabaseClient) Update(ctx context.Context, path string, filters string, patch interface{}, target interface{}) error {
+	if filters == "" {
+		return fmt.Errorf("error en Update sobre %s: se requieren filtros para no modificar toda la tabla", path)
+	}
+	return c.doRequest(ctx, http.MethodPatch, path, filters, preferHeaders(target), patch, target, true)
+}
+
+// Delete elimina las filas que cumplen filters.
+// filters: filtros PostgREST, ej. "id=eq.42". Es obligatorio para no vaciar la tabla por error.
+func (c *SupabaseClient) Delete(ctx context.Context, path string, filters string, target interface{}) error {
+	if filters == "" {
+		return fmt.Errorf("error en Delete sobre %s: se requieren filtros para no vaciar la tabla", path)
+	}
+	return c.doRequest(ctx, http.MethodDelete, path, filters, preferHeaders(target), nil, target, true)
+}
+
+// CallRPC invoca una función de Postgres expuesta por PostgREST en /rest/v1/rpc/<function>.
+// params se envía como objeto JSON con los argumentos con nombre de la función (puede ser nil).
+// target recibe el resultado de la función (escalar, fila o conjunto de filas).
+// Como la función puede modificar datos, no se reintenta salvo que ctx venga de WithRetry.
+func (c *SupabaseClient) CallRPC(ctx context.Context, function string, params interface{}, target interface{}) error {
+	if function == "" {
+		return fmt.Errorf("error en CallRPC: el nombre de la función no puede estar vacío")
+	}
+	if params == nil {
+		params = map[string]interface{}{}
+	}
+	return c.doRequest(ctx, http.MethodPost, "/rest/v1/rpc/"+url.PathEscape(function), "", nil, params, target, false)
+}
+
+// preferHeaders arma la cabecera Prefer de PostgREST. Si target no es nil se pide
+// return=representation para que la respuesta incluya las filas afectadas.
+func preferHeaders(target interface{}, extra ...string) map[string]string {
+	prefs := append([]string{}, extra...)
+	if target != nil {
+		prefs = append(prefs, "return=representation")
+	} else {
+		prefs = append(prefs, "return=minimal")
+	}
+	return map[string]string{"Prefer": strings.Join(prefs, ",")}
+}
+
+// doRequest construye, envía y procesa una petición a Supabase, reintentando según c.Retry
+// ante errores de red y respuestas 429/5xx. Las cabeceras extra se añaden después de las de autenticación.
+// idempotent indica si la petición puede repetirse sin efectos duplicados; si es false solo se
+// reintenta cuando ctx viene de WithRetry.
+func (c *SupabaseClient) doRequest(ctx context.Context, method, path, queryParams string, headers map[string]string, body interface{}, target interface{}, idempotent bool) error {
+	_, err := c.execute(ctx, method, path, queryParams, headers, body, target, idempotent)
+	return err
+}
+
+// execute es como doRequest pero devuelve además las cabeceras de la respuesta final
+// (necesarias, por ejemplo, para leer Content-Range al paginar).
+func (c *SupabaseClient) execute(ctx context.Context, method, path, queryParams string, headers map[string]string, body interface{}, target interface{}, idempotent bool) (http.Header, error) {
 	fullURL := c.BaseURL + path
 	if queryParams != "" {
 		fullURL += "?" + queryParams
 	}
 
-	req, err := http.NewRequest("GET", fullURL, nil)
+	// El cuerpo se serializa una sola vez para poder reenviarlo en cada reintento
+	var payload []byte
+	if body != nil {
+		var err error
+		payload, err = json.Marshal(body)
+		if err != nil {
+			return nil, fmt.Errorf("error serializando cuerpo de la petición %s: %w", method, err)
+		}
+	}
+
+	maxRetries := c.Retry.MaxRetries
+	if !idempotent && !retryEnabled(ctx) {
+		maxRetries = 0
+	}
+
+	for attempt := 0; ; attempt++ {
+		resp, err := c.send(ctx, method, fullURL, headers, payload)
+		if err != nil {
+			// Un ctx cancelado o vencido no se reintenta
+			if ctx.Err() != nil {
+				return nil, fmt.Errorf("petición %s a Supabase cancelada: %w", method, ctx.Err())
+			}
+			delay, ok := c.Retry.retryDelay(ctx, attempt, "", time.Now())
+			if attempt >= maxRetries || !ok {
+				return nil, fmt.Errorf("error haciendo petición a Supabase (tras %d intentos): %w", attempt+1, err)
+			}
+			if waitErr := sleepContext(ctx, delay); waitErr != nil {
+				return nil, fmt.Errorf("petición %s a Supabase cancelada: %w", method, waitErr)
+			}
+			continue
+		}
+
+		if isRetryableStatus(resp.StatusCode) && attempt < maxRetries {
+			delay, ok := c.Retry.retryDelay(ctx, attempt, resp.Header.Get("Retry-After"), time.Now())
+			if !ok {
+				// La espera pedida no cabe en el plazo de ctx: se devuelve la respuesta tal cual
+				return resp.Header, c.handleResponse(resp, target)
+			}
+			// Se descarta el cuerpo para poder reutilizar la conexión
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+			if waitErr := sleepContext(ctx, delay); waitErr != nil {
+				return nil, fmt.Errorf("petición %s a Supabase cancelada: %w", method, waitErr)
+			}
+			continue
+		}
+
+		return resp.Header, c.handleResponse(resp, target)
+	}
+}
+
+// send ejecuta un único intento de la petición con las cabeceras de Supabase.
+func (c *SupabaseClient) send(ctx context.Context, method, fullURL string, headers map[string]string, payload []byte) (*http.Response, error) {
+	var bodyReader io.Reader
+	if payload != nil {
+		bodyReader = bytes.NewReader(payload)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, fullURL, bodyReader)
 	if err != nil {
-		return fmt.Errorf("error creando petición GET: %w", err)
+		return nil, fmt.Errorf("error creando petición %s: %w", method, err)
 	}
 
 	// Cabeceras importantes para Supabase
 	req.Header.Set("apikey", c.ServiceRoleKey)                  // Usamos la service_role key como apikey
 	req.Header.Set("Authorization", "Bearer "+c.ServiceRoleKey) // Y también como Bearer token
 	req.Header.Set("Content-Type", "application/json")
-	// req.Header.Set("Prefer", "return=representation") // Opcional para GET, más útil para POST/PATCH/PUT
-
-	resp, err := c.HttpClient.Do(req)
-	if err != nil {
-		return fmt.Errorf("error haciendo petición a Supabase: %w", err)
+	for key, value := range headers {
+		req.Header.Set(key, value)
 	}
+
+	return c.HttpClient.Do(req)
+}
+
+// handleResponse valida el código de estado y decodifica el cuerpo en target. Cierra resp.Body.
+func (c *SupabaseClient) handleResponse(resp *http.Response, target interface{}) error {
 	defer resp.Body.Close()
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
 		bodyBytes, _ := io.ReadAll(resp.Body) // Intenta leer el cuerpo del error
-		return fmt.Errorf("error de Supabase API (status %d): %s", resp.StatusCode, string(bodyBytes))
+		return &APIError{StatusCode: resp.StatusCode, Body: string(bodyBytes)}
+	}
+
+	// Sin destino (o sin contenido, ej. 204) no hay nada que decodificar
+	if target == nil || resp.StatusCode == http.StatusNoContent {
+		return nil
 	}
 
 	// Decodificar la respuesta JSON en la estructura 'target'
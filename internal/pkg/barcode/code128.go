@@ -0,0 +1,114 @@
+// Package barcode genera códigos de barras Code128 y códigos QR sin dependencias externas.
+// Los códigos se devuelven como módulos (barras o cuadros) para que cada salida los dibuje a su
+// escala: PDF, SVG o lo que haga falta. La zona de silencio no se incluye.
+package barcode
+
+import (
+	"fmt"
+)
+
+// code128Patterns son los anchos de barra y espacio de cada valor de Code128, alternando
+// barra-espacio-barra... Los valores 103 a 105 son los inicios A, B y C y el 106 es la parada.
+var code128Patterns = [...]string{
+	"212222", "222122", "222221", "121223", "121322", "131222", "122213", "122312", "132212", "221213",
+	"221312", "231212", "112232", "122132", "122231", "113222", "123122", "123221", "223211", "221132",
+	"221231", "213212", "223112", "312131", "311222", "321122", "321221", "312212", "322112", "322211",
+	"212123", "212321", "232121", "111323", "131123", "131321", "112313", "132113", "132311", "211313",
+	"231113", "231311", "112133", "112331", "132131", "113123", "113321", "133121", "313121", "211331",
+	"231131", "213113", "213311", "213131", "311123", "311321", "331121", "312113", "312311", "332111",
+	"314111", "221411", "431111", "111224", "111422", "121124", "121421", "141122", "141221", "112214",
+	"112412", "122114", "122411", "142112", "142211", "241211", "221114", "413111", "241112", "134111",
+	"111242", "121142", "121241", "114212", "124112", "124211", "411212", "421112", "421211", "212141",
+	"214121", "412121", "111143", "111341", "131141", "114113", "114311", "411113", "411311", "113141",
+	"114131", "311141", "411131", "211412", "211214", "211232", "2331112",
+}
+
+// Valores especiales de Code128.
+const (
+	code128CodeC  = 99
+	code128CodeB  = 100
+	code128StartB = 104
+	code128StartC = 105
+	code128Stop   = 106
+)
+
+// Code128 codifica text (ASCII imprimible) en Code128 y devuelve sus módulos de izquierda a
+// derecha: true es barra. Los tramos de cuatro o más dígitos se codifican con el juego C, de a
+// pares, para acortar el código; el resto va en el juego B.
+func Code128(text string) ([]bool, error) {
+	if text == "" {
+		return nil, fmt.Errorf("barcode: el texto a codificar en Code128 está vacío")
+	}
+	for i := 0; i < len(text); i++ {
+		if text[i] < 32 || text[i] > 126 {
+			return nil, fmt.Errorf("barcode: carácter no imprimible %q en la posición %d para Code128", text[i], i)
+		}
+	}
+
+	var values []int
+	inC := false
+	for i := 0; i < len(text); {
+		digits := digitRun(text, i)
+		// El juego C conviene con al menos 4 dígitos, o 2 si ocupan todo el texto.
+		if digits >= 4 || (digits == 2 && len(text) == 2) {
+			if digits%2 == 1 {
+				// El dígito impar va en B para cerrar el tramo en pares.
+				if len(values) == 0 {
+					values = append(values, code128StartB)
+				} else if inC {
+					values = append(values, code128CodeB)
+					inC = false
+				}
+				values = append(values, int(text[i])-32)
+				i++
+				digits--
+			}
+			if len(values) == 0 {
+				values = append(values, code128StartC)
+			} else if !inC {
+				values = append(values, code128CodeC)
+			}
+			inC = true
+			for ; digits > 0; digits -= 2 {
+				values = append(values, int(text[i]-'0')*10+int(text[i+1]-'0'))
+				i += 2
+			}
+			continue
+		}
+		if len(values) == 0 {
+			values = append(values, code128StartB)
+		} else if inC {
+			values = append(values, code128CodeB)
+			inC = false
+		}
+		values = append(values, int(text[i])-32)
+		i++
+	}
+
+	checksum := values[0]
+	for i, value := range values[1:] {
+		checksum += (i + 1) * value
+	}
+	values = append(values, checksum%103, code128Stop)
+
+	var modules []bool
+	for _, value := range values {
+		bar := true
+		for _, width := range code128Patterns[value] {
+			for n := 0; n < int(width-'0'); n++ {
+				modules = append(modules, bar)
+			}
+			bar = !bar
+		}
+	}
+	return modules, nil
+}
+
+// digitRun devuelve cuántos dígitos seguidos hay en text desde la posición i.
+func digitRun(text string, i int) int {
+	n := 0
+	for i+n < len(text) && text[i+n] >= '0' && text[i+n] <= '9' {
+		n++
+	}
+	return n
+}
@@ -0,0 +1,187 @@
+package barcode
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// TestCode128Patterns comprueba la tabla de patrones: cada símbolo ocupa 11 módulos (la parada
+// 13), con un número par de módulos de barra, y no hay dos iguales.
+func TestCode128Patterns(t *testing.T) {
+	if len(code128Patterns) != 107 {
+		t.Fatalf("patrones = %d, se esperaban 107", len(code128Patterns))
+	}
+	seen := make(map[string]int)
+	for value, pattern := range code128Patterns {
+		total, bars := 0, 0
+		for i, width := range pattern {
+			total += int(width - '0')
+			if i%2 == 0 {
+				bars += int(width - '0')
+			}
+		}
+		want := 11
+		if value == code128Stop {
+			want = 13
+		}
+		if total != want || bars%2 != 0 {
+			t.Errorf("valor %d (%s): %d módulos y %d de barra", value, pattern, total, bars)
+		}
+		if previous, ok := seen[pattern]; ok {
+			t.Errorf("los valores %d y %d tienen el mismo patrón %s", previous, value, pattern)
+		}
+		seen[pattern] = value
+	}
+	// Patrones de la norma (ISO/IEC 15417, tabla 1).
+	for value, want := range map[int]string{0: "212222", 103: "211412", code128StartB: "211214", code128StartC: "211232", code128Stop: "2331112"} {
+		if code128Patterns[value] != want {
+			t.Errorf("valor %d = %s, se esperaba %s", value, code128Patterns[value], want)
+		}
+	}
+}
+
+// decodeCode128 lee los módulos de vuelta a los valores de cada símbolo, incluidos inicio,
+// verificación y parada.
+func decodeCode128(modules []bool) ([]int, error) {
+	byPattern := make(map[string]int, len(code128Patterns))
+	for value, pattern := range code128Patterns {
+		byPattern[pattern] = value
+	}
+	var widths []int
+	for i := 0; i < len(modules); {
+		if len(widths)%2 == 0 != modules[i] {
+			return nil, fmt.Errorf("módulo %d: se esperaba %v", i, len(widths)%2 == 0)
+		}
+		n := 1
+		for i+n < len(modules) && modules[i+n] == modules[i] {
+			n++
+		}
+		widths = append(widths, n)
+		i += n
+	}
+
+	var values []int
+	for i := 0; i < len(widths); {
+		size := 6
+		if len(widths)-i == 7 {
+			size = 7 // Parada
+		}
+		if i+size > len(widths) {
+			return nil, fmt.Errorf("símbolo incompleto en el ancho %d", i)
+		}
+		var pattern strings.Builder
+		for _, w := range widths[i : i+size] {
+			pattern.WriteByte(byte('0' + w))
+		}
+		value, ok := byPattern[pattern.String()]
+		if !ok {
+			return nil, fmt.Errorf("patrón desconocido %s", pattern.String())
+		}
+		values = append(values, value)
+		i += size
+	}
+	return values, nil
+}
+
+// code128Text reconstruye el texto a partir de los valores, comprobando la verificación.
+func code128Text(values []int) (string, error) {
+	if len(values) < 3 || values[len(values)-1] != code128Stop {
+		return "", fmt.Errorf("falta la parada: %v", values)
+	}
+	data, check := values[:len(values)-2], values[len(values)-2]
+	sum := data[0]
+	for i, value := range data[1:] {
+		sum += (i + 1) * value
+	}
+	if sum%103 != check {
+		return "", fmt.Errorf("verificación %d, se esperaba %d", check, sum%103)
+	}
+
+	var text strings.Builder
+	setC := data[0] == code128StartC
+	for _, value := range data[1:] {
+		switch {
+		case value == code128CodeB:
+			setC = false
+		case value == code128CodeC:
+			setC = true
+		case setC:
+			fmt.Fprintf(&text, "%02d", value)
+		default:
+			text.WriteByte(byte(value + 32))
+		}
+	}
+	return text.String(), nil
+}
+
+func TestCode128(t *testing.T) {
+	tests := []struct {
+		text   string
+		values []int // Inicio, datos, verificación y parada, calculados a mano
+	}{
+		// 104 + 1×35 + 2×17 = 173; 173 mod 103 = 70.
+		{"C1", []int{code128StartB, 35, 17, 70, code128Stop}},
+		// Dos dígitos solos van en C: 105 + 1×42 = 147; 147 mod 103 = 44.
+		{"42", []int{code128StartC, 42, 44, code128Stop}},
+		// 105 + 12 + 2×34 + 3×56 = 353; 353 mod 103 = 44.
+		{"123456", []int{code128StartC, 12, 34, 56, 44, code128Stop}},
+		// Cinco dígitos: el primero en B y el resto en C. 104 + 33 + 2×34 + 3×17 + 4×99 + 5×23 + 6×45 = 1037; mod 103 = 7.
+		{"AB12345", []int{code128StartB, 33, 34, 17, code128CodeC, 23, 45, 7, code128Stop}},
+		// 105 + 12 + 2×34 + 3×100 + 4×33 = 617; 617 mod 103 = 102.
+		{"1234A", []int{code128StartC, 12, 34, code128CodeB, 33, 102, code128Stop}},
+		// Tres dígitos no justifican el juego C. 104 + 37 + 2×17 + 3×13 + 4×17 + 5×18 + 6×19 = 486; mod 103 = 74.
+		{"E1-123", []int{code128StartB, 37, 17, 13, 17, 18, 19, 74, code128Stop}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			modules, err := Code128(tt.text)
+			if err != nil {
+				t.Fatalf("Code128: %v", err)
+			}
+			if want := 11*(len(tt.values)-1) + 13; len(modules) != want {
+				t.Errorf("módulos = %d, se esperaban %d", len(modules), want)
+			}
+			values, err := decodeCode128(modules)
+			if err != nil {
+				t.Fatalf("decodificando: %v", err)
+			}
+			if fmt.Sprint(values) != fmt.Sprint(tt.values) {
+				t.Errorf("valores = %v, se esperaba %v", values, tt.values)
+			}
+			text, err := code128Text(values)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if text != tt.text {
+				t.Errorf("texto decodificado = %q, se esperaba %q", text, tt.text)
+			}
+		})
+	}
+}
+
+// TestCode128RoundTrip decodifica textos con tramos de dígitos de todos los largos.
+func TestCode128RoundTrip(t *testing.T) {
+	for _, text := range []string{"0", "01", "012", "0123", "01234", "A0123456789Z", "C1-M2-E3-04R", "p/1234/5678", " ~"} {
+		modules, err := Code128(text)
+		if err != nil {
+			t.Fatalf("%q: %v", text, err)
+		}
+		values, err := decodeCode128(modules)
+		if err != nil {
+			t.Fatalf("%q: %v", text, err)
+		}
+		got, err := code128Text(values)
+		if err != nil || got != text {
+			t.Errorf("%q: decodificado %q (%v)", text, got, err)
+		}
+	}
+}
+
+func TestCode128Invalid(t *testing.T) {
+	for _, text := range []string{"", "línea", "a\nb"} {
+		if _, err := Code128(text); err == nil {
+			t.Errorf("%q: se esperaba un error", text)
+		}
+	}
+}
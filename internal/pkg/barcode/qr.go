@@ -0,0 +1,434 @@
+package barcode
+
+import (
+	"fmt"
+)
+
+// QRCode es un código QR: una matriz cuadrada de Size x Size módulos.
+type QRCode struct {
+	Version int
+	Size    int
+	modules [][]bool
+}
+
+// Dark indica si el módulo de la fila y columna indicadas es oscuro.
+func (q *QRCode) Dark(row, col int) bool {
+	return q.modules[row][col]
+}
+
+// qrVersion describe la corrección de errores nivel M de una versión: codewords totales,
+// codewords de corrección por bloque y cantidad de bloques.
+type qrVersion struct {
+	totalCodewords int
+	eccPerBlock    int
+	blocks         int
+	alignment      []int // Centros de los patrones de alineación
+}
+
+// qrVersions cubre las versiones 1 a 10 con nivel M, suficientes para textos de hasta 213 bytes.
+var qrVersions = [...]qrVersion{
+	{26, 10, 1, nil},
+	{44, 16, 1, []int{6, 18}},
+	{70, 26, 1, []int{6, 22}},
+	{100, 18, 2, []int{6, 26}},
+	{134, 24, 2, []int{6, 30}},
+	{172, 16, 4, []int{6, 34}},
+	{196, 18, 4, []int{6, 22, 38}},
+	{242, 22, 4, []int{6, 24, 42}},
+	{292, 22, 5, []int{6, 26, 46}},
+	{346, 26, 5, []int{6, 28, 50}},
+}
+
+// qrFormatBitsM son los bits del nivel de corrección M en la información de formato.
+const qrFormatBitsM = 0
+
+// QR codifica data en modo byte con corrección de errores nivel M (alrededor del 15 % del código
+// puede dañarse), usando la versión más chica en que cabe y la máscara de menor penalización.
+func QR(data []byte) (*QRCode, error) {
+	version := 0
+	for v := 1; v <= len(qrVersions); v++ {
+		info := qrVersions[v-1]
+		capacity := (info.totalCodewords - info.eccPerBlock*info.blocks) * 8
+		if 4+qrCountBits(v)+8*len(data) <= capacity {
+			version = v
+			break
+		}
+	}
+	if version == 0 {
+		return nil, fmt.Errorf("barcode: el texto de %d bytes no cabe en un código QR de versión %d", len(data), len(qrVersions))
+	}
+
+	q := newQRMatrix(version)
+	codewords := qrCodewords(version, data)
+	q.drawCodewords(codewords)
+
+	best, bestPenalty := 0, -1
+	for mask := 0; mask < 8; mask++ {
+		q.applyMask(mask)
+		q.drawFormatBits(mask)
+		if penalty := q.penalty(); bestPenalty < 0 || penalty < bestPenalty {
+			best, bestPenalty = mask, penalty
+		}
+		q.applyMask(mask) // La máscara es un XOR: aplicarla de nuevo la quita
+	}
+	q.applyMask(best)
+	q.drawFormatBits(best)
+
+	return &QRCode{Version: version, Size: q.size, modules: q.modules}, nil
+}
+
+// qrCountBits es el largo del indicador de cantidad de bytes en modo byte.
+func qrCountBits(version int) int {
+	if version < 10 {
+		return 8
+	}
+	return 16
+}
+
+// qrCodewords arma los codewords de datos con relleno, los reparte en bloques, calcula la
+// corrección de errores de cada uno y los intercala en el orden en que se ubican en la matriz.
+func qrCodewords(version int, data []byte) []byte {
+	info := qrVersions[version-1]
+	dataCodewords := info.totalCodewords - info.eccPerBlock*info.blocks
+
+	var bits bitBuffer
+	bits.append(0x4, 4) // Modo byte
+	bits.append(len(data), qrCountBits(version))
+	for _, b := range data {
+		bits.append(int(b), 8)
+	}
+	capacity := dataCodewords * 8
+	bits.append(0, min(4, capacity-len(bits))) // Terminador
+	bits.append(0, (8-len(bits)%8)%8)
+	for pad := 0xEC; len(bits) < capacity; pad ^= 0xEC ^ 0x11 {
+		bits.append(pad, 8)
+	}
+	payload := bits.bytes()
+
+	// Los primeros bloques son cortos y los últimos tienen un codeword de datos más.
+	shortBlocks := info.blocks - dataCodewords%info.blocks
+	shortLen := dataCodewords / info.blocks
+	divisor := reedSolomonDivisor(info.eccPerBlock)
+	blocks := make([][]byte, info.blocks)
+	eccs := make([][]byte, info.blocks)
+	for i, offset := 0, 0; i < info.blocks; i++ {
+		n := shortLen
+		if i >= shortBlocks {
+			n++
+		}
+		blocks[i] = payload[offset : offset+n]
+		eccs[i] = reedSolomonRemainder(blocks[i], divisor)
+		offset += n
+	}
+
+	result := make([]byte, 0, info.totalCodewords)
+	for i := 0; i <= shortLen; i++ {
+		for _, block := range blocks {
+			if i < len(block) {
+				result = append(result, block[i])
+			}
+		}
+	}
+	for i := 0; i < info.eccPerBlock; i++ {
+		for _, ecc := range eccs {
+			result = append(result, ecc[i])
+		}
+	}
+	return result
+}
+
+// bitBuffer es una secuencia de bits, del más significativo al menos significativo.
+type bitBuffer []bool
+
+func (b *bitBuffer) append(value, count int) {
+	for i := count - 1; i >= 0; i-- {
+		*b = append(*b, (value>>i)&1 == 1)
+	}
+}
+
+func (b bitBuffer) bytes() []byte {
+	out := make([]byte, len(b)/8)
+	for i, bit := range b {
+		if bit {
+			out[i/8] |= 0x80 >> (i % 8)
+		}
+	}
+	return out
+}
+
+// qrMatrix es la matriz en construcción; function marca los módulos de los patrones fijos, que
+// no llevan datos ni se enmascaran.
+type qrMatrix struct {
+	version  int
+	size     int
+	modules  [][]bool
+	function [][]bool
+}
+
+// newQRMatrix crea la matriz de la versión con los patrones de posición, alineación y tiempo,
+// el lugar de la información de formato y, desde la versión 7, la información de versión.
+func newQRMatrix(version int) *qrMatrix {
+	size := 17 + 4*version
+	q := &qrMatrix{version: version, size: size, modules: make([][]bool, size), function: make([][]bool, size)}
+	for i := range q.modules {
+		q.modules[i] = make([]bool, size)
+		q.function[i] = make([]bool, size)
+	}
+
+	for i := 0; i < size; i++ {
+		q.set(6, i, i%2 == 0)
+		q.set(i, 6, i%2 == 0)
+	}
+	q.drawFinder(3, 3)
+	q.drawFinder(3, size-4)
+	q.drawFinder(size-4, 3)
+
+	alignment := qrVersions[version-1].alignment
+	last := len(alignment) - 1
+	for i, row := range alignment {
+		for j, col := range alignment {
+			if (i == 0 && j == 0) || (i == 0 && j == last) || (i == last && j == 0) {
+				continue // Se solapan con los patrones de posición
+			}
+			for dr := -2; dr <= 2; dr++ {
+				for dc := -2; dc <= 2; dc++ {
+					q.set(row+dr, col+dc, max(abs(dr), abs(dc)) != 1)
+				}
+			}
+		}
+	}
+
+	q.drawFormatBits(0) // Reserva los módulos; se reescriben al elegir la máscara
+	if version >= 7 {
+		rem := version
+		for i := 0; i < 12; i++ {
+			rem = (rem << 1) ^ ((rem >> 11) * 0x1F25)
+		}
+		bits := version<<12 | rem
+		for i := 0; i < 18; i++ {
+			dark := (bits>>i)&1 == 1
+			a, b := size-11+i%3, i/3
+			q.set(b, a, dark)
+			q.set(a, b, dark)
+		}
+	}
+	return q
+}
+
+// set fija un módulo de un patrón fijo.
+func (q *qrMatrix) set(row, col int, dark bool) {
+	q.modules[row][col] = dark
+	q.function[row][col] = true
+}
+
+// drawFinder dibuja un patrón de posición con su separador claro alrededor.
+func (q *qrMatrix) drawFinder(row, col int) {
+	for dr := -4; dr <= 4; dr++ {
+		for dc := -4; dc <= 4; dc++ {
+			r, c := row+dr, col+dc
+			if r < 0 || r >= q.size || c < 0 || c >= q.size {
+				continue
+			}
+			dist := max(abs(dr), abs(dc))
+			q.set(r, c, dist != 2 && dist != 4)
+		}
+	}
+}
+
+// drawFormatBits escribe las dos copias de la información de formato (nivel M y máscara).
+func (q *qrMatrix) drawFormatBits(mask int) {
+	data := qrFormatBitsM<<3 | mask
+	rem := data
+	for i := 0; i < 10; i++ {
+		rem = (rem << 1) ^ ((rem >> 9) * 0x537)
+	}
+	bits := (data<<10 | rem) ^ 0x5412
+	bit := func(i int) bool { return (bits>>i)&1 == 1 }
+
+	for i := 0; i <= 5; i++ {
+		q.set(i, 8, bit(i))
+	}
+	q.set(7, 8, bit(6))
+	q.set(8, 8, bit(7))
+	q.set(8, 7, bit(8))
+	for i := 9; i < 15; i++ {
+		q.set(8, 14-i, bit(i))
+	}
+
+	for i := 0; i < 8; i++ {
+		q.set(8, q.size-1-i, bit(i))
+	}
+	for i := 8; i < 15; i++ {
+		q.set(q.size-15+i, 8, bit(i))
+	}
+	q.set(q.size-8, 8, true) // Módulo oscuro fijo
+}
+
+// drawCodewords ubica los bits de los codewords en zigzag, en columnas de a dos desde la esquina
+// inferior derecha, saltando los patrones fijos. Los módulos que sobran quedan claros.
+func (q *qrMatrix) drawCodewords(codewords []byte) {
+	i := 0
+	for right := q.size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5 // La columna del patrón de tiempo no lleva datos
+		}
+		upward := (right+1)&2 == 0
+		for vert := 0; vert < q.size; vert++ {
+			row := vert
+			if upward {
+				row = q.size - 1 - vert
+			}
+			for j := 0; j < 2; j++ {
+				col := right - j
+				if q.function[row][col] || i >= len(codewords)*8 {
+					continue
+				}
+				q.modules[row][col] = (codewords[i/8]>>(7-i%8))&1 == 1
+				i++
+			}
+		}
+	}
+}
+
+// applyMask invierte los módulos de datos que cumplen la condición de la máscara.
+func (q *qrMatrix) applyMask(mask int) {
+	for r := 0; r < q.size; r++ {
+		for c := 0; c < q.size; c++ {
+			if q.function[r][c] {
+				continue
+			}
+			var invert bool
+			switch mask {
+			case 0:
+				invert = (r+c)%2 == 0
+			case 1:
+				invert = r%2 == 0
+			case 2:
+				invert = c%3 == 0
+			case 3:
+				invert = (r+c)%3 == 0
+			case 4:
+				invert = (r/2+c/3)%2 == 0
+			case 5:
+				invert = r*c%2+r*c%3 == 0
+			case 6:
+				invert = (r*c%2+r*c%3)%2 == 0
+			default:
+				invert = ((r+c)%2+r*c%3)%2 == 0
+			}
+			if invert {
+				q.modules[r][c] = !q.modules[r][c]
+			}
+		}
+	}
+}
+
+// penalty calcula la penalización de la norma para la matriz actual: tramos largos del mismo
+// color, bloques de 2x2, patrones parecidos a los de posición y desbalance entre claros y oscuros.
+func (q *qrMatrix) penalty() int {
+	penalty, dark := 0, 0
+	finderLike := [2][11]bool{
+		{true, false, true, true, true, false, true, false, false, false, false},
+		{false, false, false, false, true, false, true, true, true, false, true},
+	}
+	for _, transpose := range []bool{false, true} {
+		at := func(i, j int) bool {
+			if transpose {
+				return q.modules[j][i]
+			}
+			return q.modules[i][j]
+		}
+		for i := 0; i < q.size; i++ {
+			run := 1
+			for j := 1; j <= q.size; j++ {
+				if j < q.size && at(i, j) == at(i, j-1) {
+					run++
+					continue
+				}
+				if run >= 5 {
+					penalty += run - 2
+				}
+				run = 1
+			}
+			for j := 0; j+11 <= q.size; j++ {
+				for _, pattern := range finderLike {
+					match := true
+					for k, want := range pattern {
+						if at(i, j+k) != want {
+							match = false
+							break
+						}
+					}
+					if match {
+						penalty += 40
+					}
+				}
+			}
+		}
+	}
+	for r := 0; r < q.size; r++ {
+		for c := 0; c < q.size; c++ {
+			if q.modules[r][c] {
+				dark++
+			}
+			if r+1 < q.size && c+1 < q.size {
+				color := q.modules[r][c]
+				if q.modules[r][c+1] == color && q.modules[r+1][c] == color && q.modules[r+1][c+1] == color {
+					penalty += 3
+				}
+			}
+		}
+	}
+	total := q.size * q.size
+	penalty += ((abs(dark*20-total*10)+total-1)/total - 1) * 10
+	return penalty
+}
+
+// reedSolomonDivisor devuelve el polinomio generador de grado degree sobre GF(256), sin el
+// coeficiente principal.
+func reedSolomonDivisor(degree int) []byte {
+	result := make([]byte, degree)
+	result[degree-1] = 1
+	root := byte(1)
+	for i := 0; i < degree; i++ {
+		for j := range result {
+			result[j] = gfMultiply(result[j], root)
+			if j+1 < len(result) {
+				result[j] ^= result[j+1]
+			}
+		}
+		root = gfMultiply(root, 0x02)
+	}
+	return result
+}
+
+// reedSolomonRemainder devuelve los codewords de corrección de data.
+func reedSolomonRemainder(data, divisor []byte) []byte {
+	result := make([]byte, len(divisor))
+	for _, b := range data {
+		factor := b ^ result[0]
+		copy(result, result[1:])
+		result[len(result)-1] = 0
+		for i := range result {
+			result[i] ^= gfMultiply(divisor[i], factor)
+		}
+	}
+	return result
+}
+
+// gfMultiply multiplica en GF(256) con el polinomio reductor de QR (0x11D).
+func gfMultiply(x, y byte) byte {
+	z := 0
+	for i := 7; i >= 0; i-- {
+		z = (z << 1) ^ ((z >> 7) * 0x11D)
+		z ^= int((y>>i)&1) * int(x)
+	}
+	return byte(z)
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
@@ -0,0 +1,340 @@
+package barcode
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// qrFormatStringsM es la información de formato del nivel M para las máscaras 0 a 7, del bit 14
+// al 0 (ISO/IEC 18004, tabla C.1).
+var qrFormatStringsM = [8]string{
+	"101010000010010",
+	"101000100100101",
+	"101111001111100",
+	"101101101001011",
+	"100010111111001",
+	"100000011001110",
+	"100111110010111",
+	"100101010100000",
+}
+
+// readFormatBits lee las dos copias de la información de formato de la matriz.
+func readFormatBits(modules [][]bool) (first, second int) {
+	size := len(modules)
+	bit := func(dark bool, i int, value *int) {
+		if dark {
+			*value |= 1 << i
+		}
+	}
+	for i := 0; i <= 5; i++ {
+		bit(modules[i][8], i, &first)
+	}
+	bit(modules[7][8], 6, &first)
+	bit(modules[8][8], 7, &first)
+	bit(modules[8][7], 8, &first)
+	for i := 9; i < 15; i++ {
+		bit(modules[8][14-i], i, &first)
+	}
+	for i := 0; i < 8; i++ {
+		bit(modules[8][size-1-i], i, &second)
+	}
+	for i := 8; i < 15; i++ {
+		bit(modules[size-15+i][8], i, &second)
+	}
+	return first, second
+}
+
+func TestQRFormatBits(t *testing.T) {
+	for mask, want := range qrFormatStringsM {
+		q := newQRMatrix(1)
+		q.drawFormatBits(mask)
+		first, second := readFormatBits(q.modules)
+		if got := fmt.Sprintf("%015b", first); got != want {
+			t.Errorf("máscara %d: formato %s, se esperaba %s", mask, got, want)
+		}
+		if first != second {
+			t.Errorf("máscara %d: las copias difieren (%015b y %015b)", mask, first, second)
+		}
+		if !q.modules[q.size-8][8] {
+			t.Errorf("máscara %d: falta el módulo oscuro fijo", mask)
+		}
+	}
+}
+
+// TestQRVersionBits compara la información de versión con la tabla D.1 de la norma.
+func TestQRVersionBits(t *testing.T) {
+	for version, want := range map[int]int{7: 0x07C94, 8: 0x085BC, 9: 0x09A99, 10: 0x0A4D3} {
+		q := newQRMatrix(version)
+		var below, right int
+		for i := 0; i < 18; i++ {
+			a, b := q.size-11+i%3, i/3
+			if q.modules[a][b] {
+				below |= 1 << i
+			}
+			if q.modules[b][a] {
+				right |= 1 << i
+			}
+		}
+		if below != want || right != want {
+			t.Errorf("versión %d: %05X y %05X, se esperaba %05X", version, below, right, want)
+		}
+	}
+}
+
+// TestReedSolomon usa el ejemplo "HELLO WORLD" 1-M de la norma (anexo I): 16 codewords de datos
+// y 10 de corrección.
+func TestReedSolomon(t *testing.T) {
+	data := []byte{32, 91, 11, 120, 209, 114, 220, 77, 67, 64, 236, 17, 236, 17, 236, 17}
+	want := []byte{196, 35, 39, 119, 235, 215, 231, 226, 93, 23}
+	if got := reedSolomonRemainder(data, reedSolomonDivisor(10)); !bytes.Equal(got, want) {
+		t.Errorf("corrección = %v, se esperaba %v", got, want)
+	}
+}
+
+// qrMaskInverts es la condición de cada máscara de la norma (tabla 10), con i fila y j columna.
+var qrMaskInverts = [8]func(i, j int) bool{
+	func(i, j int) bool { return (i+j)%2 == 0 },
+	func(i, j int) bool { return i%2 == 0 },
+	func(i, j int) bool { return j%3 == 0 },
+	func(i, j int) bool { return (i+j)%3 == 0 },
+	func(i, j int) bool { return (i/2+j/3)%2 == 0 },
+	func(i, j int) bool { return (i*j)%2+(i*j)%3 == 0 },
+	func(i, j int) bool { return ((i*j)%2+(i*j)%3)%2 == 0 },
+	func(i, j int) bool { return ((i+j)%2+(i*j)%3)%2 == 0 },
+}
+
+// decodeQR lee un código QR de vuelta a sus datos: formato, quitar la máscara, leer los codewords
+// en zigzag, separar los bloques, verificar la corrección de errores y leer el modo byte.
+func decodeQR(code *QRCode) ([]byte, error) {
+	size := code.Size
+	if size != 17+4*code.Version {
+		return nil, fmt.Errorf("tamaño %d para la versión %d", size, code.Version)
+	}
+	modules := make([][]bool, size)
+	for r := range modules {
+		modules[r] = make([]bool, size)
+		for c := range modules[r] {
+			modules[r][c] = code.Dark(r, c)
+		}
+	}
+
+	first, second := readFormatBits(modules)
+	if first != second {
+		return nil, fmt.Errorf("las copias del formato difieren: %015b y %015b", first, second)
+	}
+	mask := -1
+	for m, format := range qrFormatStringsM {
+		if fmt.Sprintf("%015b", first) == format {
+			mask = m
+		}
+	}
+	if mask < 0 {
+		return nil, fmt.Errorf("formato %015b no es de nivel M", first)
+	}
+
+	function := newQRMatrix(code.Version).function
+	var bits []bool
+	for right := size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+		upward := (right+1)&2 == 0
+		for vert := 0; vert < size; vert++ {
+			row := vert
+			if upward {
+				row = size - 1 - vert
+			}
+			for j := 0; j < 2; j++ {
+				col := right - j
+				if !function[row][col] {
+					bits = append(bits, modules[row][col] != qrMaskInverts[mask](row, col))
+				}
+			}
+		}
+	}
+
+	info := qrVersions[code.Version-1]
+	raw := make([]byte, info.totalCodewords)
+	for i := range raw {
+		for _, bit := range bits[i*8 : i*8+8] {
+			raw[i] <<= 1
+			if bit {
+				raw[i] |= 1
+			}
+		}
+	}
+
+	// Separa los bloques: primero los datos intercalados y después la corrección intercalada.
+	dataCodewords := info.totalCodewords - info.eccPerBlock*info.blocks
+	shortBlocks := info.blocks - dataCodewords%info.blocks
+	blocks := make([][]byte, info.blocks)
+	next := 0
+	for i := 0; i <= dataCodewords/info.blocks; i++ {
+		for b := range blocks {
+			if i < dataCodewords/info.blocks || b >= shortBlocks {
+				blocks[b] = append(blocks[b], raw[next])
+				next++
+			}
+		}
+	}
+	var data []byte
+	for b := range blocks {
+		data = append(data, blocks[b]...)
+	}
+	for i := 0; i < info.eccPerBlock; i++ {
+		for b := range blocks {
+			blocks[b] = append(blocks[b], raw[next])
+			next++
+		}
+	}
+	// Un bloque correcto se anula en las raíces α^0 ... α^(ecc-1) del polinomio generador.
+	for b, block := range blocks {
+		root := byte(1)
+		for i := 0; i < info.eccPerBlock; i++ {
+			syndrome := byte(0)
+			for _, codeword := range block {
+				syndrome = gfMultiply(syndrome, root) ^ codeword
+			}
+			if syndrome != 0 {
+				return nil, fmt.Errorf("bloque %d: síndrome %d no nulo", b, i)
+			}
+			root = gfMultiply(root, 0x02)
+		}
+	}
+
+	stream := bitBuffer(nil)
+	for _, b := range data {
+		stream.append(int(b), 8)
+	}
+	read := func(from, count int) int {
+		value := 0
+		for _, bit := range stream[from : from+count] {
+			value <<= 1
+			if bit {
+				value |= 1
+			}
+		}
+		return value
+	}
+	if mode := read(0, 4); mode != 0x4 {
+		return nil, fmt.Errorf("modo %04b, se esperaba byte", mode)
+	}
+	countBits := 8
+	if code.Version >= 10 {
+		countBits = 16
+	}
+	length := read(4, countBits)
+	start := 4 + countBits
+	if start+8*length > len(stream) {
+		return nil, fmt.Errorf("largo %d fuera de la capacidad", length)
+	}
+	out := make([]byte, length)
+	for i := range out {
+		out[i] = byte(read(start+8*i, 8))
+	}
+	// Tras el terminador y el ajuste a byte, el relleno alterna 0xEC y 0x11.
+	padFrom := (start + 8*length + 4 + 7) / 8
+	if padFrom < len(data) {
+		for i, b := range data[padFrom:] {
+			if want := []byte{0xEC, 0x11}[i%2]; b != want {
+				return nil, fmt.Errorf("relleno %d = %#x, se esperaba %#x", i, b, want)
+			}
+		}
+	}
+	return out, nil
+}
+
+// TestQRFunctionPatterns comprueba los patrones de posición, tiempo y alineación de la versión 7.
+func TestQRFunctionPatterns(t *testing.T) {
+	code, err := QR(bytes.Repeat([]byte("x"), 110))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if code.Version != 7 {
+		t.Fatalf("versión = %d, se esperaba 7", code.Version)
+	}
+	finder := []string{"#######", "#.....#", "#.###.#", "#.###.#", "#.###.#", "#.....#", "#######"}
+	for _, corner := range [][2]int{{0, 0}, {0, code.Size - 7}, {code.Size - 7, 0}} {
+		for r, line := range finder {
+			for c, ch := range line {
+				if code.Dark(corner[0]+r, corner[1]+c) != (ch == '#') {
+					t.Fatalf("patrón de posición en %v: módulo (%d, %d)", corner, r, c)
+				}
+			}
+		}
+	}
+	for i := 8; i < code.Size-8; i++ {
+		if code.Dark(6, i) != (i%2 == 0) || code.Dark(i, 6) != (i%2 == 0) {
+			t.Fatalf("patrón de tiempo en %d", i)
+		}
+	}
+	// Versión 7: alineaciones centradas en 6, 22 y 38, salvo las que tocan los patrones de posición.
+	for _, center := range [][2]int{{6, 22}, {22, 6}, {22, 22}, {22, 38}, {38, 22}, {38, 38}} {
+		for dr := -2; dr <= 2; dr++ {
+			for dc := -2; dc <= 2; dc++ {
+				want := max(abs(dr), abs(dc)) != 1
+				if code.Dark(center[0]+dr, center[1]+dc) != want {
+					t.Fatalf("alineación en %v: módulo (%d, %d)", center, dr, dc)
+				}
+			}
+		}
+	}
+}
+
+// TestQRRoundTrip codifica textos en el límite de capacidad de cada versión (modo byte, nivel M,
+// tabla 7 de la norma) y los decodifica de vuelta.
+func TestQRRoundTrip(t *testing.T) {
+	capacities := []int{14, 26, 42, 62, 84, 106, 122, 152, 180, 213}
+	tests := []struct {
+		name    string
+		data    []byte
+		version int
+	}{
+		{"etiqueta", []byte("p/8f3a2c1d/e/5b7e9a01/w/c2d4f6a8/03"), 3},
+		{"binario", []byte{0x00, 0xFF, 0x80, 0x7F, 0xEC, 0x11}, 1},
+		{"un byte", []byte("A"), 1},
+	}
+	for v, capacity := range capacities {
+		text := strings.Repeat("C1-M1-E1-01/", capacity/12+1)
+		tests = append(tests, struct {
+			name    string
+			data    []byte
+			version int
+		}{fmt.Sprintf("versión %d llena", v+1), []byte(text[:capacity]), v + 1})
+		if v+1 < len(capacities) {
+			text = strings.Repeat("0123456789", capacity/10+1)
+			tests = append(tests, struct {
+				name    string
+				data    []byte
+				version int
+			}{fmt.Sprintf("versión %d más un byte", v+1), []byte(text[:capacity+1]), v + 2})
+		}
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, err := QR(tt.data)
+			if err != nil {
+				t.Fatalf("QR: %v", err)
+			}
+			if code.Version != tt.version {
+				t.Errorf("versión = %d, se esperaba %d", code.Version, tt.version)
+			}
+			got, err := decodeQR(code)
+			if err != nil {
+				t.Fatalf("decodificando: %v", err)
+			}
+			if !bytes.Equal(got, tt.data) {
+				t.Errorf("datos = %q, se esperaba %q", got, tt.data)
+			}
+		})
+	}
+}
+
+func TestQRTooLong(t *testing.T) {
+	if _, err := QR(bytes.Repeat([]byte("x"), 214)); err == nil {
+		t.Error("se esperaba un error con 214 bytes")
+	}
+}
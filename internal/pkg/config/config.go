@@ -4,20 +4,103 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv" // Asegúrate de tener esta dependencia: go get github.com/joho/godotenv
+	"github.com/mvialf/windraw/internal/pkg/constants"
 )
 
 // APIConfig almacena la configuración para interactuar con la API de Supabase.
 type APIConfig struct {
 	BaseURL        string // Ej: https://ajviyshznobobicabwbw.supabase.co
 	ServiceRoleKey string // Tu service_role secret key
+
+	Timeout        time.Duration // Timeout de cada intento de petición HTTP
+	MaxRetries     int           // Reintentos ante errores de red, 429 y 5xx en peticiones idempotentes
+	RetryBaseDelay time.Duration // Espera inicial del backoff exponencial
+	RetryMaxDelay  time.Duration // Espera máxima entre reintentos
+	PageSize       int           // Filas por página al leer tablas completas (máximo de PostgREST por defecto: 1000)
+}
+
+// ServerConfig almacena la configuración del servidor HTTP de la API.
+type ServerConfig struct {
+	Addr            string        // Dirección de escucha (ej. ":8080" o "127.0.0.1:8080")
+	ReadTimeout     time.Duration // Tiempo máximo para leer una petición completa
+	WriteTimeout    time.Duration // Tiempo máximo para escribir la respuesta
+	ShutdownTimeout time.Duration // Tiempo de gracia para cerrar conexiones al recibir SIGTERM
+}
+
+// Backends de almacenamiento de proyectos soportados.
+const (
+	ProjectStorageSupabase = "supabase"
+	ProjectStorageFile     = "file"
+)
+
+// StorageConfig define dónde se persisten los proyectos y el inventario de retazos.
+type StorageConfig struct {
+	ProjectStorage string // ProjectStorageSupabase o ProjectStorageFile
+	ProjectsDir    string // Directorio de los archivos JSON cuando ProjectStorage es ProjectStorageFile
+	RemnantStorage string // ProjectStorageSupabase o ProjectStorageFile; por defecto el mismo que ProjectStorage
+	RemnantsFile   string // Archivo JSON del inventario cuando RemnantStorage es ProjectStorageFile
+}
+
+// ReinforcementConfig define qué piezas llevan refuerzo según el material del elemento.
+type ReinforcementConfig struct {
+	// MinLengthByMaterial: largo mínimo de pieza en mm por material (0 = siempre).
+	// Los materiales ausentes no se refuerzan.
+	MinLengthByMaterial map[string]int
+}
+
+// OptimizerConfig define los valores por defecto del optimizador de corte de barras.
+type OptimizerConfig struct {
+	KerfMM             float64       // Ancho del disco de sierra en mm
+	TrimMM             float64       // Recorte en cada extremo de barra en mm
+	DefaultBarLengthMM int           // Largo de barra si el ítem de stock no lo informa
+	SearchBudget       time.Duration // Tiempo de búsqueda por defecto del modo de búsqueda
+}
+
+// RemnantConfig define qué sobrantes de corte se guardan en el inventario de retazos.
+type RemnantConfig struct {
+	MinLengthMM int // Los sobrantes más cortos se descartan
+}
+
+// PricingConfig define los precios por defecto de los presupuestos.
+// Los precios de perfiles salen de stock_items; el resto se configura aquí.
+type PricingConfig struct {
+	ProfilePricing   string             // constants.PROFILE_PRICING_*
+	GlassPerM2       map[string]float64 // Precio por m² según tipo de vidrio
+	HardwarePerWind  map[string]float64 // Precio del herraje según tipo de hoja
+	LabourPerElement float64            // Mano de obra fija por elemento
+	LabourPerM2      float64            // Mano de obra por m² de elemento
+}
+
+// CatalogConfig define de qué columna de 'profiles' se toma cada medida de cálculo de los perfiles
+// (ver models.ProfileDimensionMap). Vacío deja la medida en 0.
+type CatalogConfig struct {
+	SashOverlapColumn string // Solape de la hoja de abatir sobre el marco
+	GlassRebateColumn string // Profundidad del galce del vidrio
+	InterlockColumn   string // Encaje de traslapos y piezas superpuestas
+	CouplingColumn    string // Lo que un montante entra en el perfil contiguo
+}
+
+// ExportConfig define los archivos de configuración de las exportaciones a máquinas.
+type ExportConfig struct {
+	SawDialectsFile string // JSON con dialectos de sierra adicionales; se ignora si no existe
 }
 
 // Config almacena toda la configuración de la aplicación.
 type Config struct {
-	SupabaseAPI APIConfig
-	// APIPort     string // Descomenta si necesitas configurar el puerto de tu API
+	SupabaseAPI   APIConfig
+	Server        ServerConfig
+	Storage       StorageConfig
+	Reinforcement ReinforcementConfig
+	Optimizer     OptimizerConfig
+	Remnants      RemnantConfig
+	Pricing       PricingConfig
+	Export        ExportConfig
+	Catalog       CatalogConfig
 }
 
 // LoadConfig carga la configuración desde variables de entorno (o un archivo .env).
@@ -43,8 +126,102 @@ func LoadConfig() (*Config, error) {
 		return nil, fmt.Errorf("la variable de entorno SUPABASE_SERVICE_KEY no está configurada")
 	}
 
-	// Cargar otras configuraciones
-	// cfg.APIPort = getEnv("API_PORT", "8080")
+	// Cargar timeouts y reintentos del cliente de Supabase
+	if cfg.SupabaseAPI.Timeout, err = getEnvDuration("SUPABASE_TIMEOUT", 10*time.Second); err != nil {
+		return nil, err
+	}
+	if cfg.SupabaseAPI.MaxRetries, err = getEnvInt("SUPABASE_MAX_RETRIES", 3); err != nil {
+		return nil, err
+	}
+	if cfg.SupabaseAPI.RetryBaseDelay, err = getEnvDuration("SUPABASE_RETRY_BASE_DELAY", 200*time.Millisecond); err != nil {
+		return nil, err
+	}
+	if cfg.SupabaseAPI.RetryMaxDelay, err = getEnvDuration("SUPABASE_RETRY_MAX_DELAY", 5*time.Second); err != nil {
+		return nil, err
+	}
+
+	if cfg.SupabaseAPI.PageSize, err = getEnvInt("SUPABASE_PAGE_SIZE", 1000); err != nil {
+		return nil, err
+	}
+
+	// Cargar configuración del servidor HTTP
+	cfg.Server.Addr = getEnv("API_ADDR", ":8080")
+	if cfg.Server.ReadTimeout, err = getEnvDuration("API_READ_TIMEOUT", 15*time.Second); err != nil {
+		return nil, err
+	}
+	if cfg.Server.WriteTimeout, err = getEnvDuration("API_WRITE_TIMEOUT", 30*time.Second); err != nil {
+		return nil, err
+	}
+	if cfg.Server.ShutdownTimeout, err = getEnvDuration("API_SHUTDOWN_TIMEOUT", 10*time.Second); err != nil {
+		return nil, err
+	}
+
+	// Cargar configuración de almacenamiento de proyectos
+	cfg.Storage.ProjectStorage = getEnv("PROJECT_STORAGE", ProjectStorageSupabase)
+	cfg.Storage.ProjectsDir = getEnv("PROJECTS_DIR", "data/projects")
+	if cfg.Storage.ProjectStorage != ProjectStorageSupabase && cfg.Storage.ProjectStorage != ProjectStorageFile {
+		return nil, fmt.Errorf("la variable de entorno PROJECT_STORAGE debe ser '%s' o '%s', se recibió '%s'",
+			ProjectStorageSupabase, ProjectStorageFile, cfg.Storage.ProjectStorage)
+	}
+	cfg.Storage.RemnantStorage = getEnv("REMNANT_STORAGE", cfg.Storage.ProjectStorage)
+	cfg.Storage.RemnantsFile = getEnv("REMNANTS_FILE", "data/remnants.json")
+	if cfg.Storage.RemnantStorage != ProjectStorageSupabase && cfg.Storage.RemnantStorage != ProjectStorageFile {
+		return nil, fmt.Errorf("la variable de entorno REMNANT_STORAGE debe ser '%s' o '%s', se recibió '%s'",
+			ProjectStorageSupabase, ProjectStorageFile, cfg.Storage.RemnantStorage)
+	}
+
+	// Cargar reglas de refuerzo, ej. "PVC=always" o "PVC=600,Aluminio=never"
+	if cfg.Reinforcement.MinLengthByMaterial, err = parseReinforcementRules(getEnv("REINFORCEMENT_RULES", "PVC=always")); err != nil {
+		return nil, err
+	}
+
+	// Cargar valores por defecto del optimizador de corte
+	if cfg.Optimizer.KerfMM, err = getEnvFloat("SAW_KERF_MM", 4); err != nil {
+		return nil, err
+	}
+	if cfg.Optimizer.TrimMM, err = getEnvFloat("BAR_TRIM_MM", 10); err != nil {
+		return nil, err
+	}
+	if cfg.Optimizer.DefaultBarLengthMM, err = getEnvInt("DEFAULT_BAR_LENGTH_MM", 6000); err != nil {
+		return nil, err
+	}
+	if cfg.Optimizer.SearchBudget, err = getEnvDuration("OPTIMIZER_SEARCH_BUDGET", 2*time.Second); err != nil {
+		return nil, err
+	}
+
+	// Cargar el largo mínimo de los retazos que se guardan en inventario
+	if cfg.Remnants.MinLengthMM, err = getEnvInt("REMNANT_MIN_LENGTH_MM", 500); err != nil {
+		return nil, err
+	}
+
+	// Cargar precios por defecto, ej. GLASS_PRICES="Monolítico=18000,DVH=52000"
+	cfg.Pricing.ProfilePricing = getEnv("PROFILE_PRICING", constants.PROFILE_PRICING_BARS)
+	if cfg.Pricing.ProfilePricing != constants.PROFILE_PRICING_BARS && cfg.Pricing.ProfilePricing != constants.PROFILE_PRICING_METERS {
+		return nil, fmt.Errorf("la variable de entorno PROFILE_PRICING debe ser '%s' o '%s', se recibió '%s'",
+			constants.PROFILE_PRICING_BARS, constants.PROFILE_PRICING_METERS, cfg.Pricing.ProfilePricing)
+	}
+	if cfg.Pricing.GlassPerM2, err = parsePrices("GLASS_PRICES", getEnv("GLASS_PRICES", "")); err != nil {
+		return nil, err
+	}
+	if cfg.Pricing.HardwarePerWind, err = parsePrices("HARDWARE_PRICES", getEnv("HARDWARE_PRICES", "")); err != nil {
+		return nil, err
+	}
+	if cfg.Pricing.LabourPerElement, err = getEnvFloat("LABOUR_PER_ELEMENT", 0); err != nil {
+		return nil, err
+	}
+	if cfg.Pricing.LabourPerM2, err = getEnvFloat("LABOUR_PER_M2", 0); err != nil {
+		return nil, err
+	}
+
+	// Cargar las columnas de las medidas de cálculo de los perfiles; los valores por defecto son los
+	// de models.DefaultProfileDimensionMap
+	cfg.Catalog.SashOverlapColumn = getEnv("PROFILE_SASH_OVERLAP_COLUMN", "profile_h1")
+	cfg.Catalog.GlassRebateColumn = getEnv("PROFILE_GLASS_REBATE_COLUMN", "profile_h2")
+	cfg.Catalog.InterlockColumn = getEnv("PROFILE_INTERLOCK_COLUMN", "profile_h3")
+	cfg.Catalog.CouplingColumn = getEnv("PROFILE_COUPLING_COLUMN", "profile_w1")
+
+	// Cargar la ubicación de los dialectos de sierra
+	cfg.Export.SawDialectsFile = getEnv("SAW_DIALECTS_FILE", "configs/saw_dialects.json")
 
 	return cfg, nil
 }
@@ -56,3 +233,95 @@ func getEnv(key, fallback string) string {
 	}
 	return fallback
 }
+
+// getEnvDuration lee una variable de entorno con formato de duración de Go (ej. "15s", "1m").
+func getEnvDuration(key string, fallback time.Duration) (time.Duration, error) {
+	value, exists := os.LookupEnv(key)
+	if !exists || value == "" {
+		return fallback, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("la variable de entorno %s no es una duración válida ('%s'): %w", key, value, err)
+	}
+	return d, nil
+}
+
+// getEnvInt lee una variable de entorno entera no negativa.
+func getEnvInt(key string, fallback int) (int, error) {
+	value, exists := os.LookupEnv(key)
+	if !exists || value == "" {
+		return fallback, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("la variable de entorno %s debe ser un entero no negativo, se recibió '%s'", key, value)
+	}
+	return n, nil
+}
+
+// getEnvFloat lee una variable de entorno decimal no negativa.
+func getEnvFloat(key string, fallback float64) (float64, error) {
+	value, exists := os.LookupEnv(key)
+	if !exists || value == "" {
+		return fallback, nil
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil || f < 0 {
+		return 0, fmt.Errorf("la variable de entorno %s debe ser un número no negativo, se recibió '%s'", key, value)
+	}
+	return f, nil
+}
+
+// parseReinforcementRules interpreta reglas "Material=valor" separadas por comas, donde valor es
+// "always" (refuerzo siempre), "never" (sin refuerzo) o un largo mínimo de pieza en mm.
+func parseReinforcementRules(value string) (map[string]int, error) {
+	rules := make(map[string]int)
+	for _, rule := range strings.Split(value, ",") {
+		rule = strings.TrimSpace(rule)
+		if rule == "" {
+			continue
+		}
+		material, setting, ok := strings.Cut(rule, "=")
+		material, setting = strings.TrimSpace(material), strings.TrimSpace(setting)
+		if !ok || material == "" {
+			return nil, fmt.Errorf("regla de REINFORCEMENT_RULES inválida '%s': se espera 'Material=always|never|<mm>'", rule)
+		}
+		switch strings.ToLower(setting) {
+		case "always":
+			rules[material] = 0
+		case "never":
+			delete(rules, material)
+		default:
+			n, err := strconv.Atoi(setting)
+			if err != nil || n < 0 {
+				return nil, fmt.Errorf("regla de REINFORCEMENT_RULES inválida '%s': el largo mínimo debe ser un entero no negativo", rule)
+			}
+			rules[material] = n
+		}
+	}
+	return rules, nil
+}
+
+// parsePrices interpreta precios "Nombre=precio" separados por comas. key es el nombre de la
+// variable de entorno, usado en los mensajes de error.
+func parsePrices(key, value string) (map[string]float64, error) {
+	prices := make(map[string]float64)
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		name, setting, ok := strings.Cut(entry, "=")
+		name, setting = strings.TrimSpace(name), strings.TrimSpace(setting)
+		if !ok || name == "" {
+			return nil, fmt.Errorf("precio de %s inválido '%s': se espera 'Nombre=precio'", key, entry)
+		}
+		price, err := strconv.ParseFloat(setting, 64)
+		if err != nil || price < 0 {
+			return nil, fmt.Errorf("precio de %s inválido '%s': el precio debe ser un número no negativo", key, entry)
+		}
+		prices[name] = price
+	}
+	return prices, nil
+}
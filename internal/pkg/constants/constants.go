@@ -40,8 +40,12 @@ const (
 )
 
 const (
-	GEOMETRY_RECTANGULAR = "Rectangular"
-	GEOMETRY_TRIANGULAR  = "Triangular"
+	GEOMETRY_RECTANGULAR     = "Rectangular"
+	GEOMETRY_TRIANGULAR      = "Triangular"
+	GEOMETRY_TRAPEZOIDAL     = "Trapezoidal"   // Lado superior inclinado (rake)
+	GEOMETRY_PENTAGONAL      = "Pentagonal"    // Frontón: dos lados superiores inclinados hasta un vértice
+	GEOMETRY_SEGMENTAL_ARCH  = "Arco rebajado" // Arco superior con flecha menor que medio ancho
+	GEOMETRY_HALF_ROUND_ARCH = "Arco de medio punto"
 )
 
 const (
@@ -49,6 +53,34 @@ const (
 	POSITION_BOTTOM = "Abajo"
 	POSITION_RIGHT  = "Derecha"
 	POSITION_TOP    = "Arriba"
+
+	// Lados superiores de un marco pentagonal (frontón)
+	POSITION_TOP_LEFT  = "Arriba izquierda"
+	POSITION_TOP_RIGHT = "Arriba derecha"
+
+	// Piezas de traslapo (interlock) montadas sobre el montante de encuentro de una hoja corredera
+	POSITION_OVERLAP_LEFT  = "Traslapo izquierdo"
+	POSITION_OVERLAP_RIGHT = "Traslapo derecho"
+)
+
+// Orientación de una división de campos: un montante vertical reparte el ancho
+// y un travesaño horizontal reparte el alto.
+const (
+	SPLIT_VERTICAL   = "Vertical"
+	SPLIT_HORIZONTAL = "Horizontal"
+)
+
+// Relleno de un campo final de la división.
+const (
+	FIELD_FILL_GLASS = "Vidrio"
+	FIELD_FILL_SASH  = "Hoja"
+)
+
+// Tipos de vidrio de un paño. DVH es el doble vidriado hermético (cámara de aire entre dos vidrios).
+const (
+	GLASS_TYPE_MONOLITHIC = "Monolítico"
+	GLASS_TYPE_LAMINATED  = "Laminado"
+	GLASS_TYPE_DVH        = "DVH"
 )
 
 const (
@@ -56,6 +88,10 @@ const (
 	CUT_ANGLE  = "Ángulo"
 )
 
+// WELD_MARGIN_MM es el margen de soldadura por extremo que se suma a los perfiles con weld_margin
+// (PVC termosoldado): el material que se funde al soldar cada inglete.
+const WELD_MARGIN_MM = 3.0
+
 const (
 	CUT_SQUARE_WIND             = "Cuadrado"
 	CUT_HORIZONTAL_WIND         = "Horizontal"
@@ -76,6 +112,40 @@ const (
 	WIND_KIND_TILT_ONLY     = "Hoja oscilante"
 )
 
+// Disposiciones predefinidas de hojas correderas. Se aceptan también variantes con el mismo
+// formato ("N hojas", "N hojas en R rieles", "N hojas K centrales móviles").
+const (
+	SLIDING_LAYOUT_2_PANELS          = "2 hojas"
+	SLIDING_LAYOUT_3_PANELS_3_TRACKS = "3 hojas en 3 rieles"
+	SLIDING_LAYOUT_4_PANELS_2_CENTER = "4 hojas 2 centrales móviles"
+)
+
+// Modos del optimizador de corte de barras.
+const (
+	OPTIMIZER_MODE_FIRST_FIT = "Primer ajuste" // Primer ajuste decreciente: rápido y determinista
+	OPTIMIZER_MODE_SEARCH    = "Búsqueda"      // Búsqueda con presupuesto de tiempo para mejorar el aprovechamiento
+)
+
+// Origen de una barra en un plan de corte.
+const (
+	BAR_SOURCE_NEW    = "Barra nueva"
+	BAR_SOURCE_OFFCUT = "Retazo"
+)
+
+// Forma de valorizar los perfiles en un presupuesto.
+const (
+	PROFILE_PRICING_BARS   = "Barras" // Barras nuevas del plan de corte × precio de barra
+	PROFILE_PRICING_METERS = "Metros" // Metros de pieza × precio por metro (precio de barra / largo de barra)
+)
+
+// Categorías de las líneas de un presupuesto.
+const (
+	QUOTE_CATEGORY_PROFILE  = "Perfiles"
+	QUOTE_CATEGORY_GLASS    = "Vidrios"
+	QUOTE_CATEGORY_HARDWARE = "Herrajes"
+	QUOTE_CATEGORY_LABOUR   = "Mano de obra"
+)
+
 const (
 	WIND_STATUS_ACTIVE   = "activa"
 	WIND_STATUS_INACTIVE = "inactiva"
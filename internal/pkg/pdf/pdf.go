@@ -0,0 +1,190 @@
+// Package pdf escribe documentos PDF simples sin dependencias externas: texto con las fuentes
+// estándar del visor (Helvetica y Courier), líneas y rectángulos. Alcanza para listas y etiquetas
+// de taller; no incrusta fuentes ni imágenes.
+//
+// Las coordenadas están en puntos (1/72 de pulgada) con origen en la esquina inferior izquierda
+// de la página, como en el propio PDF.
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"unicode/utf8"
+)
+
+// Tamaño A4 en puntos y factor de conversión desde milímetros.
+const (
+	A4Width  = 595.28
+	A4Height = 841.89
+	MMToPt   = 72 / 25.4
+)
+
+// Font es una de las fuentes estándar que todo visor de PDF trae incorporadas.
+type Font int
+
+const (
+	Helvetica Font = iota
+	HelveticaBold
+	Courier
+	CourierBold
+)
+
+var fontNames = [...]string{"Helvetica", "Helvetica-Bold", "Courier", "Courier-Bold"}
+
+// Document es un documento PDF en construcción con páginas del mismo tamaño.
+type Document struct {
+	width, height float64
+	pages         []*Page
+}
+
+// New crea un documento vacío con páginas de width x height puntos.
+func New(width, height float64) *Document {
+	return &Document{width: width, height: height}
+}
+
+// Width devuelve el ancho de página en puntos.
+func (d *Document) Width() float64 { return d.width }
+
+// Height devuelve el alto de página en puntos.
+func (d *Document) Height() float64 { return d.height }
+
+// AddPage agrega una página en blanco al final del documento y la devuelve.
+func (d *Document) AddPage() *Page {
+	page := &Page{}
+	d.pages = append(d.pages, page)
+	return page
+}
+
+// Page es una página del documento; sus métodos agregan operaciones de dibujo en orden.
+type Page struct {
+	content bytes.Buffer
+}
+
+// Text escribe text con su línea base en (x, y). Los caracteres fuera de Windows-1252 se
+// reemplazan por "?".
+func (p *Page) Text(x, y float64, font Font, size float64, text string) {
+	fmt.Fprintf(&p.content, "BT /F%d %s Tf %s %s Td (%s) Tj ET\n",
+		int(font)+1, num(size), num(x), num(y), escapeString(encodeWinAnsi(text)))
+}
+
+// Line traza una línea de (x1, y1) a (x2, y2) con el grosor indicado.
+func (p *Page) Line(x1, y1, x2, y2, width float64) {
+	fmt.Fprintf(&p.content, "%s w %s %s m %s %s l S\n", num(width), num(x1), num(y1), num(x2), num(y2))
+}
+
+// Rect dibuja un rectángulo con esquina inferior izquierda en (x, y), relleno o solo el borde.
+func (p *Page) Rect(x, y, w, h float64, fill bool) {
+	op := "S"
+	if fill {
+		op = "f"
+	}
+	fmt.Fprintf(&p.content, "%s %s %s %s re %s\n", num(x), num(y), num(w), num(h), op)
+}
+
+// SetGray fija el color de trazo y de relleno en escala de grises (0 negro, 1 blanco).
+func (p *Page) SetGray(level float64) {
+	fmt.Fprintf(&p.content, "%s g %s G\n", num(level), num(level))
+}
+
+// MonoWidth devuelve el ancho en puntos de text escrito en Courier de tamaño size.
+// Todos los caracteres de Courier miden 600/1000 del tamaño de la fuente.
+func MonoWidth(size float64, text string) float64 {
+	return 0.6 * size * float64(utf8.RuneCountInString(text))
+}
+
+// WriteTo escribe el documento completo en w.
+func (d *Document) WriteTo(w io.Writer) (int64, error) {
+	var buf bytes.Buffer
+	var offsets []int
+	object := func(body string) {
+		offsets = append(offsets, buf.Len())
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	buf.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	// 1: catálogo, 2: árbol de páginas, 3..6: fuentes, luego página y contenido por cada página.
+	const firstFont, firstPage = 3, 3 + len(fontNames)
+	kids := bytes.Buffer{}
+	for i := range d.pages {
+		fmt.Fprintf(&kids, "%d 0 R ", firstPage+2*i)
+	}
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d /MediaBox [0 0 %s %s] >>",
+		bytes.TrimSpace(kids.Bytes()), len(d.pages), num(d.width), num(d.height)))
+	fonts := bytes.Buffer{}
+	for i, name := range fontNames {
+		object(fmt.Sprintf("<< /Type /Font /Subtype /Type1 /BaseFont /%s /Encoding /WinAnsiEncoding >>", name))
+		fmt.Fprintf(&fonts, "/F%d %d 0 R ", i+1, firstFont+i)
+	}
+	for i, page := range d.pages {
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /Resources << /Font << %s>> >> /Contents %d 0 R >>",
+			fonts.String(), firstPage+2*i+1))
+
+		var compressed bytes.Buffer
+		zw := zlib.NewWriter(&compressed)
+		if _, err := zw.Write(page.content.Bytes()); err != nil {
+			return 0, err
+		}
+		if err := zw.Close(); err != nil {
+			return 0, err
+		}
+		object(fmt.Sprintf("<< /Length %d /Filter /FlateDecode >>\nstream\n%s\nendstream", compressed.Len(), compressed.Bytes()))
+	}
+
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+
+	n, err := w.Write(buf.Bytes())
+	return int64(n), err
+}
+
+// num formatea un número con hasta dos decimales, sin ceros de sobra.
+func num(v float64) string {
+	return strconv.FormatFloat(math.Round(v*100)/100, 'f', -1, 64)
+}
+
+// winAnsiExtra son los caracteres de Windows-1252 fuera del rango Latin-1 que se usan en los textos.
+var winAnsiExtra = map[rune]byte{
+	'€': 0x80, '…': 0x85, '‘': 0x91, '’': 0x92, '“': 0x93, '”': 0x94, '•': 0x95, '–': 0x96, '—': 0x97,
+}
+
+// encodeWinAnsi convierte text de UTF-8 a Windows-1252, la codificación de las fuentes estándar.
+func encodeWinAnsi(text string) []byte {
+	out := make([]byte, 0, len(text))
+	for _, r := range text {
+		switch {
+		case r == '\t' || r == '\n' || r == '\r':
+			out = append(out, ' ')
+		case r >= 0x20 && r < 0x7f, r >= 0xa0 && r <= 0xff:
+			out = append(out, byte(r))
+		default:
+			if b, ok := winAnsiExtra[r]; ok {
+				out = append(out, b)
+			} else {
+				out = append(out, '?')
+			}
+		}
+	}
+	return out
+}
+
+// escapeString escapa los delimitadores de una cadena literal de PDF.
+func escapeString(text []byte) []byte {
+	out := make([]byte, 0, len(text))
+	for _, b := range text {
+		if b == '(' || b == ')' || b == '\\' {
+			out = append(out, '\\')
+		}
+		out = append(out, b)
+	}
+	return out
+}